@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/goccy/go-json"
+
+	"github.com/apageadev/jsontype"
+)
+
+func runCompat(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("compat", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: jsontype compat [flags] <old> <new>")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Compares two versions of a schema and reports whether every change is backward")
+		fmt.Fprintln(stderr, "compatible, forward compatible or breaking. old and new are schema files, or")
+		fmt.Fprintln(stderr, "references such as order@1.0.0 to schemas loaded with -schema.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	var schemaPaths stringList
+	flags.Var(&schemaPaths, "schema", "schema `file` or directory of schemas that are extended or referenced, may be repeated")
+	mode := flags.String("mode", "backward", "required compatibility `mode`: backward, forward, full or none")
+	format := flags.String("format", "text", "output `format`: text or json")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	if flags.NArg() != 2 {
+		fmt.Fprintln(stderr, "jsontype compat: an old and a new schema are required")
+		return exitError
+	}
+	required := jsontype.Compatibility(*mode)
+	switch required {
+	case jsontype.CompatibilityBackward, jsontype.CompatibilityForward, jsontype.CompatibilityFull, jsontype.CompatibilityNone:
+	default:
+		fmt.Fprintf(stderr, "jsontype compat: unknown mode %q\n", *mode)
+		return exitError
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "jsontype compat: unknown format %q\n", *format)
+		return exitError
+	}
+
+	files, err := readSchemaFiles(schemaPaths)
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype compat: %v\n", err)
+		return exitError
+	}
+	from, err := compatSchema(files, flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype compat: %v\n", err)
+		return exitError
+	}
+	to, err := compatSchema(files, flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype compat: %v\n", err)
+		return exitError
+	}
+
+	report, err := jsontype.CompareSchemas(from, to)
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype compat: %v\n", err)
+		return exitError
+	}
+
+	if *format == "json" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "jsontype compat: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "%s\n", b)
+	} else {
+		for _, change := range report.Changes {
+			fmt.Fprintf(stdout, "%-8s %s\n", change.Compatibility, change.Message)
+		}
+		fmt.Fprintf(stdout, "%s is %s compatible with %s\n", report.New, report.Compatibility, report.Old)
+	}
+
+	if err := report.Check(required); err != nil {
+		return exitInvalid
+	}
+	return exitOK
+}
+
+// compatSchema loads the schemas that may be extended or referenced into a
+// new SchemaManager, along with the schema to compare which is either a file
+// or a reference to one of the loaded schemas
+func compatSchema(files map[string][]byte, arg string) (*jsontype.Schema, error) {
+	def, err := os.ReadFile(arg)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// a schema file replaces the schemas loaded from the same file
+	dependencies := make(map[string][]byte, len(files))
+	for name, dependency := range files {
+		if def == nil || !sameFile(name, arg) {
+			dependencies[name] = dependency
+		}
+	}
+	sm := jsontype.NewSchemaManager()
+	err = sm.LoadSchemas(dependencies)
+	if err != nil {
+		return nil, err
+	}
+	if def == nil {
+		return sm.GetSchema(arg)
+	}
+
+	s := &jsontype.Schema{}
+	err = json.Unmarshal(def, s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", arg, err)
+	}
+	err = sm.LoadSchema(def, jsontype.Replace)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", arg, err)
+	}
+	return sm.GetSchema(s.Type + "@" + s.Version)
+}
+
+// sameFile reports whether two paths name the same file
+func sameFile(a, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && a == b
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apageadev/jsontype"
+)
+
+func runDocs(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("docs", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: jsontype docs [flags] <file|dir>...")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Writes documentation for schema files as Markdown or HTML. Every property is")
+		fmt.Fprintln(stderr, "listed with its type, description and rules, and every schema has an example")
+		fmt.Fprintln(stderr, "document. Every .json file within a directory is documented.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	format := flags.String("format", "markdown", "output `format`: markdown or html")
+	title := flags.String("title", "Schemas", "`title` of the documentation")
+	seed := flags.Int64("seed", 0, "`seed` of the generated example documents")
+	output := flags.String("o", "", "`file` to write the documentation to instead of stdout")
+	filePath := flags.String("file-path", "", "existing `file` named by examples of the filepath format")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "jsontype docs: at least one schema file or directory is required")
+		return exitError
+	}
+	if *format != "markdown" && *format != "html" {
+		fmt.Fprintf(stderr, "jsontype docs: unknown format %q\n", *format)
+		return exitError
+	}
+
+	sm, err := loadSchemas(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype docs: %v\n", err)
+		return exitError
+	}
+
+	var buf bytes.Buffer
+	options := jsontype.DocsOptions{Title: *title, Generate: jsontype.GenerateOptions{Seed: *seed, FilePath: *filePath}}
+	if *format == "html" {
+		err = sm.WriteHTML(&buf, options)
+	} else {
+		err = sm.WriteMarkdown(&buf, options)
+	}
+	if err == nil {
+		if *output != "" {
+			err = os.WriteFile(*output, buf.Bytes(), 0644)
+		} else {
+			_, err = stdout.Write(buf.Bytes())
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype docs: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
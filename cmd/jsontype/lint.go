@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/goccy/go-json"
+
+	"github.com/apageadev/jsontype"
+)
+
+// maxFixPasses limits how often fixes are applied, as fixing one problem can
+// reveal another
+const maxFixPasses = 10
+
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: jsontype lint [flags] <file|dir>...")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Checks schema files for problems such as unknown rules and formats, rule")
+		fmt.Fprintln(stderr, "arguments of the wrong type, broken extends chains and unreachable references.")
+		fmt.Fprintln(stderr, "Every .json file within a directory is checked.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	fix := flags.Bool("fix", false, "rewrite files to fix mechanical problems, such as misspelled rule names")
+	format := flags.String("format", "text", "output `format`: text or json")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	if flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "jsontype lint: at least one schema file or directory is required")
+		return exitError
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "jsontype lint: unknown format %q\n", *format)
+		return exitError
+	}
+
+	files, err := readSchemaFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype lint: %v\n", err)
+		return exitError
+	}
+
+	diagnostics := jsontype.LintSchemas(files)
+	if *fix {
+		fixed := 0
+		diagnostics, fixed, err = fixSchemas(files, diagnostics)
+		if err != nil {
+			fmt.Fprintf(stderr, "jsontype lint: %v\n", err)
+			return exitError
+		}
+		if fixed > 0 {
+			fmt.Fprintf(stderr, "jsontype lint: fixed %d problems\n", fixed)
+		}
+	}
+
+	if *format == "json" {
+		b, err := json.MarshalIndent(diagnostics, "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "jsontype lint: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "%s\n", b)
+	} else {
+		for _, d := range diagnostics {
+			fmt.Fprintln(stdout, d)
+		}
+	}
+
+	for _, d := range diagnostics {
+		if d.Severity == jsontype.SeverityError {
+			return exitInvalid
+		}
+	}
+	return exitOK
+}
+
+// fixSchemas applies fixes to files and writes them back, until no fixable
+// problems remain. The remaining diagnostics are returned along with the
+// number of problems fixed.
+func fixSchemas(files map[string][]byte, diagnostics []jsontype.Diagnostic) ([]jsontype.Diagnostic, int, error) {
+	fixed := 0
+	for pass := 0; pass < maxFixPasses; pass++ {
+		byFile := map[string][]jsontype.Diagnostic{}
+		for _, d := range diagnostics {
+			if d.Fix != nil {
+				byFile[d.File] = append(byFile[d.File], d)
+			}
+		}
+		if len(byFile) == 0 {
+			break
+		}
+
+		for file, fileDiagnostics := range byFile {
+			files[file] = jsontype.ApplyFixes(files[file], fileDiagnostics)
+			err := os.WriteFile(file, files[file], 0644)
+			if err != nil {
+				return nil, fixed, err
+			}
+			fixed += len(fileDiagnostics)
+		}
+		diagnostics = jsontype.LintSchemas(files)
+	}
+	return diagnostics, fixed, nil
+}
@@ -0,0 +1,77 @@
+// Command jsontype validates JSON documents against JSONType schemas.
+//
+// Usage:
+//
+//	jsontype <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	validate    validate documents against a schema
+//	lint        check schema files for problems
+//	compat      check two versions of a schema are compatible
+//	serve       serve a schema registry over HTTP
+//	docs        write documentation for schemas
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// exit codes shared by every command
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitError   = 2
+)
+
+// command is a jsontype subcommand, run returns the process exit code
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	{name: "validate", summary: "validate documents against a schema", run: runValidate},
+	{name: "lint", summary: "check schema files for problems", run: runLint},
+	{name: "compat", summary: "check two versions of a schema are compatible", run: runCompat},
+	{name: "serve", summary: "serve a schema registry over HTTP", run: runServe},
+	{name: "docs", summary: "write documentation for schemas", run: runDocs},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return exitError
+		}
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "jsontype: unknown command %q\n", args[0])
+	usage(stderr)
+	return exitError
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: jsontype <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'jsontype <command> -h' for help with a command.")
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files into a temporary directory and returns its path
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func runCommand(args []string, stdin string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+var validateFiles = map[string]string{
+	"schemas/animal.json": `{"type": "Animal", "properties": {"name": {"type": "string", "rules": {"max_length": 5}}}}`,
+	"schemas/dog.json":    `{"type": "Dog", "extends": "Animal", "properties": {"kind": {"type": "string"}, "breed": {"type": "string"}}}`,
+	"docs/good.json":      `{"kind": "dog", "name": "Luna", "breed": "beagle"}`,
+	"docs/bad.json":       `{"kind": "dog", "name": "Blueberry", "breed": "beagle"}`,
+	"docs/lines.ndjson":   "{\"kind\": \"dog\", \"name\": \"Luna\", \"breed\": \"beagle\"}\n\n{\"kind\": \"dog\", \"name\": \"Luna\"}\n",
+}
+
+func TestRunUsage(t *testing.T) {
+	code, _, stderr := runCommand(nil, "")
+	if code != exitError || !strings.Contains(stderr, "validate") {
+		t.Fatalf("unexpected usage %d %s", code, stderr)
+	}
+
+	code, _, _ = runCommand([]string{"bad"}, "")
+	if code != exitError {
+		t.Fatalf("expected exit code %d but got %d", exitError, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := writeFiles(t, validateFiles)
+	schemas := filepath.Join(dir, "schemas")
+
+	code, stdout, stderr := runCommand([]string{"validate", "-schema", schemas, "-type", "dog", filepath.Join(dir, "docs", "good.json")}, "")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d but got %d: %s %s", exitOK, code, stdout, stderr)
+	}
+
+	// test globs
+	code, stdout, _ = runCommand([]string{"validate", "-schema", schemas, "-type", "dog", filepath.Join(dir, "docs", "*.json")}, "")
+	if code != exitInvalid {
+		t.Fatalf("expected exit code %d but got %d", exitInvalid, code)
+	}
+	if !strings.Contains(stdout, "2 documents, 1 failed") {
+		t.Fatalf("unexpected output %s", stdout)
+	}
+
+	// test stdin
+	code, _, _ = runCommand([]string{"validate", "-schema", schemas, "-type", "dog"}, validateFiles["docs/good.json"])
+	if code != exitOK {
+		t.Fatalf("expected exit code %d but got %d", exitOK, code)
+	}
+
+	// test schema inferred from a field
+	code, _, _ = runCommand([]string{"validate", "-schema", schemas, "-type-field", "kind", "-"}, validateFiles["docs/bad.json"])
+	if code != exitInvalid {
+		t.Fatalf("expected exit code %d but got %d", exitInvalid, code)
+	}
+
+	// test json lines
+	code, stdout, _ = runCommand([]string{"validate", "-schema", schemas, "-type", "dog", "-lines", filepath.Join(dir, "docs", "lines.ndjson")}, "")
+	if code != exitInvalid {
+		t.Fatalf("expected exit code %d but got %d", exitInvalid, code)
+	}
+	if !strings.Contains(stdout, "lines.ndjson:3") {
+		t.Fatalf("expected the line number in %s", stdout)
+	}
+}
+
+func TestValidateFormats(t *testing.T) {
+	dir := writeFiles(t, validateFiles)
+	args := []string{"validate", "-schema", filepath.Join(dir, "schemas"), "-type", "dog"}
+	docs := filepath.Join(dir, "docs", "*.json")
+
+	code, stdout, _ := runCommand(append(args, "-format", "json", docs), "")
+	if code != exitInvalid {
+		t.Fatalf("expected exit code %d but got %d", exitInvalid, code)
+	}
+	var results []map[string]interface{}
+	err := json.Unmarshal([]byte(stdout), &results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results but got %d", len(results))
+	}
+
+	code, stdout, _ = runCommand(append(args, "-format", "junit", docs), "")
+	if code != exitInvalid {
+		t.Fatalf("expected exit code %d but got %d", exitInvalid, code)
+	}
+	var suites junitTestSuites
+	err = xml.Unmarshal([]byte(stdout), &suites)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suites.Tests != 2 || suites.Failures != 1 {
+		t.Fatalf("unexpected junit report %s", stdout)
+	}
+
+	// test unknown format
+	code, _, _ = runCommand(append(args, "-format", "yaml", docs), "")
+	if code != exitError {
+		t.Fatalf("expected exit code %d but got %d", exitError, code)
+	}
+}
+
+func TestValidateBadArguments(t *testing.T) {
+	dir := writeFiles(t, validateFiles)
+	schemas := filepath.Join(dir, "schemas")
+
+	for _, args := range [][]string{
+		{"validate", "-type", "dog"},
+		{"validate", "-schema", schemas},
+		{"validate", "-schema", schemas, "-type", "cat"},
+		{"validate", "-schema", filepath.Join(dir, "missing"), "-type", "dog"},
+		{"validate", "-schema", schemas, "-type", "dog", filepath.Join(dir, "docs", "*.yaml")},
+	} {
+		code, _, _ := runCommand(args, "")
+		if code != exitError {
+			t.Fatalf("%v: expected exit code %d but got %d", args, exitError, code)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schemas/animal.json": "{\n  \"type\": \"Animal\",\n  \"properties\": {\"name\": {\"type\": \"String\", \"rules\": {\"maxLength\": \"5\"}}}\n}\n",
+		"schemas/dog.json":    `{"type": "Dog", "extends": "Wolf", "properties": {}}`,
+	})
+	schemas := filepath.Join(dir, "schemas")
+
+	code, stdout, _ := runCommand([]string{"lint", schemas}, "")
+	if code != exitInvalid {
+		t.Fatalf("expected exit code %d but got %d", exitInvalid, code)
+	}
+	if !strings.Contains(stdout, "animal.json:3:55: error: property name has an unknown rule maxLength, did you mean max_length") {
+		t.Fatalf("unexpected output %s", stdout)
+	}
+
+	code, stdout, _ = runCommand([]string{"lint", "-format", "json", schemas}, "")
+	var diagnostics []map[string]interface{}
+	err := json.Unmarshal([]byte(stdout), &diagnostics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != exitInvalid || len(diagnostics) != 4 {
+		t.Fatalf("unexpected output %d %s", code, stdout)
+	}
+
+	// test fixing files, only the broken extends chain remains
+	code, stdout, _ = runCommand([]string{"lint", "--fix", schemas}, "")
+	if code != exitInvalid || strings.Count(stdout, "\n") != 1 || !strings.Contains(stdout, "extends unknown schema Wolf") {
+		t.Fatalf("unexpected output %d %s", code, stdout)
+	}
+	animal, err := os.ReadFile(filepath.Join(schemas, "animal.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  \"type\": \"Animal\",\n  \"properties\": {\"name\": {\"type\": \"string\", \"rules\": {\"max_length\": 5}}}\n}\n"
+	if string(animal) != expected {
+		t.Fatalf("unexpected fixed file %s", animal)
+	}
+
+	code, _, _ = runCommand([]string{"lint", filepath.Join(schemas, "animal.json")}, "")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d but got %d", exitOK, code)
+	}
+}
+
+func TestCompat(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schemas/address.json": `{"type": "Address", "properties": {"zip": {"type": "string"}}}`,
+		"schemas/order.json":   `{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string", "rules": {"max_length": 10}}, "address": {"type": "object", "ref": "Address"}}}`,
+		"v2/order.json":        `{"type": "Order", "version": "2.0.0", "properties": {"id": {"type": "string", "rules": {"max_length": 20}}, "address": {"type": "object", "ref": "Address"}}, "optional_properties": {"note": {"type": "string"}}}`,
+		"v3/order.json":        `{"type": "Order", "version": "3.0.0", "properties": {"id": {"type": "string", "rules": {"max_length": 20}}, "address": {"type": "object", "ref": "Address"}, "customer": {"type": "string"}}}`,
+	})
+	schemas := filepath.Join(dir, "schemas")
+	v2 := filepath.Join(dir, "v2", "order.json")
+	v3 := filepath.Join(dir, "v3", "order.json")
+
+	code, stdout, stderr := runCommand([]string{"compat", "-schema", schemas, "order@1.0.0", v2}, "")
+	if code != exitOK || !strings.Contains(stdout, "Order@2.0.0 is backward compatible with Order@1.0.0") {
+		t.Fatalf("unexpected output %d %s %s", code, stdout, stderr)
+	}
+
+	// test a breaking change fails the check
+	code, stdout, _ = runCommand([]string{"compat", "-schema", schemas, "-format", "json", v2, v3}, "")
+	var report map[string]interface{}
+	err := json.Unmarshal([]byte(stdout), &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != exitInvalid || report["compatibility"] != "none" || len(report["changes"].([]interface{})) != 2 {
+		t.Fatalf("unexpected output %d %s", code, stdout)
+	}
+
+	code, _, _ = runCommand([]string{"compat", "-schema", schemas, "-mode", "none", v2, v3}, "")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d but got %d", exitOK, code)
+	}
+
+	code, _, _ = runCommand([]string{"compat", "-mode", "sideways", v2, v3}, "")
+	if code != exitError {
+		t.Fatalf("expected exit code %d but got %d", exitError, code)
+	}
+}
+
+func TestServe(t *testing.T) {
+	dir := writeFiles(t, validateFiles)
+	defer func() { listenAndServe = http.ListenAndServe }()
+
+	var handler http.Handler
+	listenAndServe = func(addr string, h http.Handler) error {
+		handler = h
+		return nil
+	}
+	code, _, stderr := runCommand([]string{"serve", "-addr", "localhost:0", "-schema", filepath.Join(dir, "schemas"), "-dir", filepath.Join(dir, "store")}, "")
+	if code != exitOK || stderr != "serving 2 schemas on localhost:0\n" {
+		t.Fatalf("unexpected output %d %s", code, stderr)
+	}
+
+	r := httptest.NewRequest("POST", "/schemas/dog/validate", strings.NewReader(`{"kind": "dog", "name": "Luna", "breed": "beagle"}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != `{"valid":true}` {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body)
+	}
+
+	code, _, _ = runCommand([]string{"serve", "-compatibility", "sideways"}, "")
+	if code != exitError {
+		t.Fatalf("expected exit code %d but got %d", exitError, code)
+	}
+}
+
+func TestDocs(t *testing.T) {
+	dir := writeFiles(t, validateFiles)
+	schemas := filepath.Join(dir, "schemas")
+
+	code, stdout, stderr := runCommand([]string{"docs", "-title", "Pets", schemas}, "")
+	if code != exitOK || !strings.HasPrefix(stdout, "# Pets\n") || !strings.Contains(stdout, "| `name` | string | yes | Inherited from [Animal](#animal). | must be at most 5 characters |") {
+		t.Fatalf("unexpected output %d %s %s", code, stdout, stderr)
+	}
+
+	// test writing HTML to a file
+	output := filepath.Join(dir, "docs.html")
+	code, _, stderr = runCommand([]string{"docs", "-format", "html", "-o", output, schemas}, "")
+	if code != exitOK {
+		t.Fatalf("unexpected output %d %s", code, stderr)
+	}
+	html, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), `<section id="dog">`) {
+		t.Fatalf("unexpected HTML %s", html)
+	}
+
+	code, _, _ = runCommand([]string{"docs", "-format", "pdf", schemas}, "")
+	if code != exitError {
+		t.Fatalf("expected exit code %d but got %d", exitError, code)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// reporters write validation results in each of the supported formats
+var reporters = map[string]func(w io.Writer, results []*result) error{
+	"text":  reportText,
+	"json":  reportJSON,
+	"junit": reportJUnit,
+}
+
+// messages returns every error message of a result
+func (r *result) messages() []string {
+	if r.Error != "" {
+		return []string{r.Error}
+	}
+	messages := make([]string, len(r.Errors))
+	for i, err := range r.Errors {
+		messages[i] = err.Message
+	}
+	return messages
+}
+
+func reportText(w io.Writer, results []*result) error {
+	failed := 0
+	for _, r := range results {
+		if r.Valid {
+			fmt.Fprintf(w, "ok   %s\n", r.File)
+			continue
+		}
+		failed++
+		fmt.Fprintf(w, "FAIL %s\n", r.File)
+		for _, message := range r.messages() {
+			fmt.Fprintf(w, "     %s\n", message)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d documents, %d failed\n", len(results), failed)
+	return err
+}
+
+func reportJSON(w io.Writer, results []*result) error {
+	b, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func reportJUnit(w io.Writer, results []*result) error {
+	suite := junitTestSuite{Name: "jsontype validate", Tests: len(results)}
+	for _, r := range results {
+		tc := junitTestCase{Name: r.File, ClassName: r.Schema}
+		if !r.Valid {
+			suite.Failures++
+			messages := r.messages()
+			tc.Failure = &junitFailure{
+				Message: messages[0],
+				Type:    "ValidationError",
+				Body:    strings.Join(messages, "\n"),
+			}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	suites := junitTestSuites{Tests: suite.Tests, Failures: suite.Failures, Suites: []junitTestSuite{suite}}
+	b, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, b)
+	return err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apageadev/jsontype"
+)
+
+// stringList is a flag that can be repeated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// schemaFiles returns the schema files, and every .json file within schema
+// directories, named by paths
+func schemaFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(file), ".json") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// readSchemaFiles reads the schema files named by paths, keyed by file name
+func readSchemaFiles(paths []string) (map[string][]byte, error) {
+	names, err := schemaFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte, len(names))
+	for _, name := range names {
+		files[name], err = os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// loadSchemas loads schema files, and every .json file within schema
+// directories, into a new SchemaManager
+func loadSchemas(paths []string) (*jsontype.SchemaManager, error) {
+	files, err := readSchemaFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	sm := jsontype.NewSchemaManager()
+	err = sm.LoadSchemas(files)
+	if err != nil {
+		return nil, err
+	}
+	return sm, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/apageadev/jsontype"
+)
+
+// listenAndServe serves the registry, it is replaced in tests
+var listenAndServe = http.ListenAndServe
+
+func runServe(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: jsontype serve [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Serves a schema registry over HTTP. Clients can list, fetch, register and")
+		fmt.Fprintln(stderr, "delete schemas, and validate documents against them. Registered schemas are")
+		fmt.Fprintln(stderr, "kept in memory unless -dir is given, servers sharing a directory see each")
+		fmt.Fprintln(stderr, "other's changes.")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	var schemaPaths stringList
+	flags.Var(&schemaPaths, "schema", "schema `file` or directory of schemas to serve, may be repeated")
+	addr := flags.String("addr", ":8080", "`address` to listen on")
+	dir := flags.String("dir", "", "`directory` to store registered schemas in")
+	compatibility := flags.String("compatibility", "backward", "compatibility new schema versions must have: backward, forward, full or none")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	if flags.NArg() != 0 {
+		fmt.Fprintln(stderr, "jsontype serve: unexpected arguments")
+		return exitError
+	}
+	required := jsontype.Compatibility(*compatibility)
+	switch required {
+	case jsontype.CompatibilityBackward, jsontype.CompatibilityForward, jsontype.CompatibilityFull, jsontype.CompatibilityNone:
+	default:
+		fmt.Fprintf(stderr, "jsontype serve: unknown compatibility %q\n", *compatibility)
+		return exitError
+	}
+
+	sm, err := loadSchemas(schemaPaths)
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype serve: %v\n", err)
+		return exitError
+	}
+	var store jsontype.Store = jsontype.NewMemoryStore()
+	if *dir != "" {
+		store, err = jsontype.NewDirStore(*dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "jsontype serve: %v\n", err)
+			return exitError
+		}
+	}
+	// other replicas sharing the directory may change the schemas
+	storeSync, err := sm.UseStore(store, jsontype.StoreOptions{})
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype serve: %v\n", err)
+		return exitError
+	}
+	defer storeSync.Close()
+	registry, err := jsontype.NewRegistry(sm, store, jsontype.RegistryOptions{Compatibility: required})
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype serve: %v\n", err)
+		return exitError
+	}
+
+	fmt.Fprintf(stderr, "serving %d schemas on %s\n", sm.SchemaCount(), *addr)
+	err = listenAndServe(*addr, registry)
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype serve: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
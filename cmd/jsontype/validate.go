@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/goccy/go-json"
+
+	"github.com/apageadev/jsontype"
+)
+
+// maxLineSize is the longest line accepted in a JSON Lines document
+const maxLineSize = 64 * 1024 * 1024
+
+// result is the outcome of validating a single document
+type result struct {
+	File   string                      `json:"file"`
+	Schema string                      `json:"schema,omitempty"`
+	Valid  bool                        `json:"valid"`
+	Errors []*jsontype.ValidationError `json:"errors,omitempty"`
+	Error  string                      `json:"error,omitempty"`
+}
+
+// setError records the error returned by validating a document
+func (r *result) setError(err error) {
+	if err == nil {
+		r.Valid = true
+		return
+	}
+	var verrs jsontype.ValidationErrors
+	if errors.As(err, &verrs) {
+		r.Errors = verrs
+		return
+	}
+	r.Error = err.Error()
+}
+
+func runValidate(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: jsontype validate -schema <file|dir> [-type <name> | -type-field <field>] [flags] [files...]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Validates JSON documents against a schema. Files may be glob patterns, and")
+		fmt.Fprintln(stderr, "documents are read from stdin when no files are given or a file is \"-\".")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	var schemaPaths stringList
+	flags.Var(&schemaPaths, "schema", "schema `file` or directory of schemas to load, may be repeated")
+	schemaType := flags.String("type", "", "name of the schema to validate documents against")
+	typeField := flags.String("type-field", "", "name of a document `field` holding the schema to validate against")
+	format := flags.String("format", "text", "output `format`: text, json or junit")
+	lines := flags.Bool("lines", false, "treat every line of a document as a separate document (JSON Lines)")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	if len(schemaPaths) == 0 {
+		fmt.Fprintln(stderr, "jsontype validate: at least one -schema is required")
+		return exitError
+	}
+	if (*schemaType == "") == (*typeField == "") {
+		fmt.Fprintln(stderr, "jsontype validate: exactly one of -type or -type-field is required")
+		return exitError
+	}
+	report, ok := reporters[*format]
+	if !ok {
+		fmt.Fprintf(stderr, "jsontype validate: unknown format %q\n", *format)
+		return exitError
+	}
+
+	sm, err := loadSchemas(schemaPaths)
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype validate: %v\n", err)
+		return exitError
+	}
+	if *schemaType != "" {
+		if _, err := sm.GetSchema(*schemaType); err != nil {
+			fmt.Fprintf(stderr, "jsontype validate: %v\n", err)
+			return exitError
+		}
+	}
+
+	files, err := expandFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "jsontype validate: %v\n", err)
+		return exitError
+	}
+
+	v := &validator{sm: sm, schemaType: *schemaType, typeField: *typeField}
+	results := []*result{}
+	for _, file := range files {
+		err := func() error {
+			r := stdin
+			if file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+
+			if *lines {
+				fileResults, err := v.validateLines(file, r)
+				results = append(results, fileResults...)
+				return err
+			}
+			res, err := v.validate(file, r)
+			if err != nil {
+				return err
+			}
+			results = append(results, res)
+			return nil
+		}()
+		if err != nil {
+			fmt.Fprintf(stderr, "jsontype validate: %v\n", err)
+			return exitError
+		}
+	}
+
+	if err := report(stdout, results); err != nil {
+		fmt.Fprintf(stderr, "jsontype validate: %v\n", err)
+		return exitError
+	}
+	for _, r := range results {
+		if !r.Valid {
+			return exitInvalid
+		}
+	}
+	return exitOK
+}
+
+// expandFiles expands glob patterns, no files means stdin
+func expandFiles(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{"-"}, nil
+	}
+
+	files := []string{}
+	for _, arg := range args {
+		if arg == "-" {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", arg)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// validator validates documents against a named schema, or the schema named
+// by a field of each document
+type validator struct {
+	sm         *jsontype.SchemaManager
+	schemaType string
+	typeField  string
+}
+
+// validate validates a single document, an error is only returned if the
+// document cannot be read
+func (v *validator) validate(name string, r io.Reader) (*result, error) {
+	res := &result{File: name, Schema: v.schemaType}
+	if v.schemaType != "" {
+		schema, _ := v.sm.GetSchema(v.schemaType)
+		res.setError(schema.ValidateStream(r))
+		return res, nil
+	}
+
+	// the document has to be decoded to find the schema it names
+	document, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	err = json.Unmarshal(document, &data)
+	if err != nil {
+		res.setError(err)
+		return res, nil
+	}
+	object, _ := data.(map[string]interface{})
+	schemaType, ok := object[v.typeField].(string)
+	if !ok {
+		res.setError(fmt.Errorf("document does not have a string %s field naming its schema", v.typeField))
+		return res, nil
+	}
+	res.Schema = schemaType
+
+	schema, err := v.sm.GetSchema(schemaType)
+	if err != nil {
+		res.setError(err)
+		return res, nil
+	}
+	res.setError(schema.ValidateValue(data))
+	return res, nil
+}
+
+// validateLines validates every line of a JSON Lines document
+func (v *validator) validateLines(name string, r io.Reader) ([]*result, error) {
+	results := []*result{}
+	if v.schemaType != "" {
+		schema, _ := v.sm.GetSchema(v.schemaType)
+		err := schema.ValidateLines(r, jsontype.LinesOptions{MaxLineSize: maxLineSize}, func(line int, err error) {
+			res := &result{File: fmt.Sprintf("%s:%d", name, line), Schema: v.schemaType}
+			res.setError(err)
+			results = append(results, res)
+		})
+		return results, err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		res, err := v.validate(fmt.Sprintf("%s:%d", name, line), bytes.NewReader(scanner.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, scanner.Err()
+}
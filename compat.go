@@ -0,0 +1,404 @@
+package jsontype
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Compatibility describes which documents keep validating after a schema
+// changes
+type Compatibility string
+
+const (
+	// CompatibilityFull changes are both backward and forward compatible
+	CompatibilityFull Compatibility = "full"
+	// CompatibilityBackward changes accept every document the old schema
+	// accepted, so consumers can upgrade before producers
+	CompatibilityBackward Compatibility = "backward"
+	// CompatibilityForward changes only accept documents the old schema
+	// accepted, so producers can upgrade before consumers
+	CompatibilityForward Compatibility = "forward"
+	// CompatibilityNone changes are breaking, documents written with either
+	// schema may not validate against the other
+	CompatibilityNone Compatibility = "none"
+)
+
+// includes reports whether changes of compatibility c satisfy a required
+// compatibility
+func (c Compatibility) includes(required Compatibility) bool {
+	switch required {
+	case CompatibilityNone:
+		return true
+	case CompatibilityFull:
+		return c == CompatibilityFull
+	}
+	return c == CompatibilityFull || c == required
+}
+
+// intersect returns the compatibility of two changes made together
+func (c Compatibility) intersect(o Compatibility) Compatibility {
+	switch {
+	case c == o || o == CompatibilityFull:
+		return c
+	case c == CompatibilityFull:
+		return o
+	}
+	return CompatibilityNone
+}
+
+// A SchemaChange is a single difference between two versions of a schema
+type SchemaChange struct {
+	// Path is the property that changed, such as address.zip or tags[], it is
+	// empty for changes to the schema itself
+	Path string `json:"path"`
+	// Change identifies the kind of change, such as property_added or
+	// rule_tightened
+	Change        string        `json:"change"`
+	Compatibility Compatibility `json:"compatibility"`
+	Message       string        `json:"message"`
+}
+
+// A CompatibilityReport lists every change between two schemas
+type CompatibilityReport struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+	// Compatibility is the compatibility of all of the changes together
+	Compatibility Compatibility  `json:"compatibility"`
+	Changes       []SchemaChange `json:"changes"`
+}
+
+// Check returns an error listing every change that does not satisfy the
+// required compatibility, CompatibilityNone allows any change
+func (r *CompatibilityReport) Check(required Compatibility) error {
+	messages := []string{}
+	for _, change := range r.Changes {
+		if !change.Compatibility.includes(required) {
+			messages = append(messages, change.Message)
+		}
+	}
+	if len(messages) > 0 {
+		return fmt.Errorf("%s is not %s compatible with %s: %s", r.New, required, r.Old, strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// CompareSchemas compares two versions of a schema and classifies every change
+// as backward compatible, forward compatible, both or breaking. Properties of
+// extended schemas are compared too, as are the schemas referenced by object
+// properties, so schemas that extend or reference other schemas must have
+// been loaded into a SchemaManager.
+func CompareSchemas(from, to *Schema) (*CompatibilityReport, error) {
+	c := &schemaComparer{seen: map[[2]*Schema]bool{}}
+	err := c.compareRoots(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CompatibilityReport{
+		Old:           from.ref(),
+		New:           to.ref(),
+		Compatibility: CompatibilityFull,
+		Changes:       c.changes,
+	}
+	for _, change := range c.changes {
+		report.Compatibility = report.Compatibility.intersect(change.Compatibility)
+	}
+	return report, nil
+}
+
+// schemaComparer collects the changes between schemas, seen prevents
+// comparing recursive references forever
+type schemaComparer struct {
+	changes []SchemaChange
+	seen    map[[2]*Schema]bool
+}
+
+func (c *schemaComparer) add(path, change string, compatibility Compatibility, format string, args ...interface{}) {
+	c.changes = append(c.changes, SchemaChange{
+		Path:          path,
+		Change:        change,
+		Compatibility: compatibility,
+		Message:       fmt.Sprintf(format, args...),
+	})
+}
+
+// compareRoots compares the kind of document two schemas validate, and then
+// their properties when both validate objects with their own properties
+func (c *schemaComparer) compareRoots(from, to *Schema) error {
+	switch {
+	case from.hasObjectRoot() && to.hasObjectRoot():
+		c.compareRules("", from.root(), to.root())
+		return c.compareSchemas("", from, to)
+	case from.hasObjectRoot() || to.hasObjectRoot():
+		c.add("", "root_changed", CompatibilityNone, "schema %s root changed from %s to %s", to.ref(), rootKind(from), rootKind(to))
+		return nil
+	}
+	return c.compareProperties("", from, to, *from.Root, *to.Root)
+}
+
+// rootKind describes the kind of document a schema validates
+func rootKind(s *Schema) string {
+	switch {
+	case s.hasObjectRoot():
+		return "object"
+	case s.Root.Ref != "":
+		return "schema " + s.Root.Ref
+	}
+	return s.Root.Type
+}
+
+func (c *schemaComparer) compareSchemas(path string, from, to *Schema) error {
+	if c.seen[[2]*Schema{from, to}] {
+		return nil
+	}
+	c.seen[[2]*Schema{from, to}] = true
+
+	oldProperties, oldOptional, err := from.resolveProperties()
+	if err != nil {
+		return err
+	}
+	newProperties, newOptional, err := to.resolveProperties()
+	if err != nil {
+		return err
+	}
+
+	// a property missing from a document is valid against the old schema if it
+	// is optional, and a property that is present if the schema defines it or
+	// allows undefined properties
+	for _, name := range sortedKeys(newProperties) {
+		property := joinPath(path, name)
+		if _, ok := oldProperties[name]; ok {
+			continue
+		}
+		if _, ok := oldOptional[name]; ok {
+			c.add(property, "property_required", CompatibilityForward, "optional property %s became required", property)
+			continue
+		}
+		compatibility := CompatibilityNone
+		if from.AllowUndefinedProperties {
+			compatibility = CompatibilityForward
+		}
+		c.add(property, "property_added", compatibility, "required property %s was added", property)
+	}
+	for _, name := range sortedKeys(newOptional) {
+		property := joinPath(path, name)
+		if _, ok := oldProperties[name]; ok {
+			c.add(property, "property_optional", CompatibilityBackward, "required property %s became optional", property)
+			continue
+		}
+		if _, ok := oldOptional[name]; ok {
+			continue
+		}
+		compatibility := CompatibilityBackward
+		if from.AllowUndefinedProperties {
+			compatibility = CompatibilityFull
+		}
+		c.add(property, "property_added", compatibility, "optional property %s was added", property)
+	}
+	for _, removed := range []map[string]Property{oldProperties, oldOptional} {
+		for _, name := range sortedKeys(removed) {
+			_, required := newProperties[name]
+			_, optional := newOptional[name]
+			if required || optional {
+				continue
+			}
+			property := joinPath(path, name)
+			compatibility := CompatibilityForward
+			if to.AllowUndefinedProperties {
+				compatibility = CompatibilityFull
+			}
+			what := "optional"
+			if _, ok := oldProperties[name]; ok {
+				what = "required"
+				compatibility = compatibility.intersect(CompatibilityBackward)
+			}
+			c.add(property, "property_removed", compatibility, "%s property %s was removed", what, property)
+		}
+	}
+
+	switch {
+	case from.AllowUndefinedProperties && !to.AllowUndefinedProperties:
+		c.add(path, "undefined_properties_disallowed", CompatibilityForward, "%s no longer allows undefined properties", pathOrSchema(path, to))
+	case !from.AllowUndefinedProperties && to.AllowUndefinedProperties:
+		c.add(path, "undefined_properties_allowed", CompatibilityBackward, "%s now allows undefined properties", pathOrSchema(path, to))
+	}
+
+	// properties are compared whether they are required or optional
+	fromAll, toAll := mergeProperties(oldProperties, oldOptional), mergeProperties(newProperties, newOptional)
+	for _, name := range sortedKeys(fromAll) {
+		if p, ok := toAll[name]; ok {
+			if err := c.compareProperties(joinPath(path, name), from, to, fromAll[name], p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compareProperties compares the definitions of a property that exists in
+// both schemas
+func (c *schemaComparer) compareProperties(path string, oldSchema, newSchema *Schema, from, to Property) error {
+	if from.Type != to.Type {
+		c.add(path, "type_changed", CompatibilityNone, "%s changed type from %s to %s", describePath(path), from.Type, to.Type)
+		return nil
+	}
+
+	c.compareRules(path, from, to)
+
+	if from.Items != nil || to.Items != nil {
+		switch {
+		case from.Items == nil:
+			c.add(path+"[]", "items_added", CompatibilityForward, "%s items now have type %s", describePath(path), to.Items.Type)
+		case to.Items == nil:
+			c.add(path+"[]", "items_removed", CompatibilityBackward, "%s items no longer have a type", describePath(path))
+		default:
+			if err := c.compareProperties(path+"[]", oldSchema, newSchema, *from.Items, *to.Items); err != nil {
+				return err
+			}
+		}
+	}
+
+	if from.Ref != "" || to.Ref != "" {
+		switch {
+		case from.Ref == "":
+			c.add(path, "ref_added", CompatibilityForward, "%s must now satisfy schema %s", describePath(path), to.Ref)
+		case to.Ref == "":
+			c.add(path, "ref_removed", CompatibilityBackward, "%s no longer has to satisfy schema %s", describePath(path), from.Ref)
+		default:
+			oldRef, err := oldSchema.lookup(from.Ref)
+			if err != nil {
+				return err
+			}
+			newRef, err := newSchema.lookup(to.Ref)
+			if err != nil {
+				return err
+			}
+			return c.compareSchemas(path, oldRef, newRef)
+		}
+	}
+	return nil
+}
+
+// ruleDirections describes whether a rule's argument becoming larger, or its
+// options growing, makes the rule accept more values (1) or fewer (-1)
+var ruleDirections = map[string]int{
+	"min":        -1,
+	"min_length": -1,
+	"max":        1,
+	"max_length": 1,
+	"oneof":      1,
+	"allof":      1,
+	"anyof":      1,
+	"noneof":     -1,
+}
+
+// compareRules classifies changes to a property's rules, a rule that accepts
+// fewer values is forward compatible and one that accepts more is backward
+// compatible
+func (c *schemaComparer) compareRules(path string, from, to Property) {
+	rules := map[string]bool{}
+	for rule := range from.Rules {
+		rules[rule] = true
+	}
+	for rule := range to.Rules {
+		rules[rule] = true
+	}
+
+	for _, rule := range sortedKeys(rules) {
+		oldArg, hadRule := from.Rules[rule]
+		newArg, hasRule := to.Rules[rule]
+		switch {
+		case !hadRule:
+			c.add(path, "rule_added", CompatibilityForward, "%s rule %s was added", describePath(path), rule)
+			continue
+		case !hasRule:
+			c.add(path, "rule_removed", CompatibilityBackward, "%s rule %s was removed", describePath(path), rule)
+			continue
+		case equal(oldArg, newArg):
+			continue
+		}
+
+		direction := 0
+		if oldN, ok := toFloat(oldArg); ok {
+			if newN, ok := toFloat(newArg); ok {
+				switch {
+				case newN > oldN:
+					direction = ruleDirections[rule]
+				case newN < oldN:
+					direction = -ruleDirections[rule]
+				}
+			}
+		}
+		if oldOptions, ok := toList(oldArg); ok && ruleDirections[rule] != 0 {
+			if newOptions, ok := toList(newArg); ok {
+				added, removed := diffOptions(oldOptions, newOptions)
+				switch {
+				case added && !removed:
+					direction = ruleDirections[rule]
+				case removed && !added:
+					direction = -ruleDirections[rule]
+				}
+			}
+		}
+
+		switch direction {
+		case 1:
+			c.add(path, "rule_loosened", CompatibilityBackward, "%s rule %s was loosened from %v to %v", describePath(path), rule, oldArg, newArg)
+		case -1:
+			c.add(path, "rule_tightened", CompatibilityForward, "%s rule %s was tightened from %v to %v", describePath(path), rule, oldArg, newArg)
+		default:
+			c.add(path, "rule_changed", CompatibilityNone, "%s rule %s changed from %v to %v", describePath(path), rule, oldArg, newArg)
+		}
+	}
+}
+
+// diffOptions reports whether options were added to or removed from a rule
+func diffOptions(from, to []interface{}) (added, removed bool) {
+	contains := func(options []interface{}, value interface{}) bool {
+		for _, option := range options {
+			if equal(option, value) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, option := range to {
+		if !contains(from, option) {
+			added = true
+		}
+	}
+	for _, option := range from {
+		if !contains(to, option) {
+			removed = true
+		}
+	}
+	return added, removed
+}
+
+// mergeProperties returns the required and optional properties of a schema
+func mergeProperties(properties, optionalProperties map[string]Property) map[string]Property {
+	merged := make(map[string]Property, len(properties)+len(optionalProperties))
+	for name, p := range properties {
+		merged[name] = p
+	}
+	for name, p := range optionalProperties {
+		merged[name] = p
+	}
+	return merged
+}
+
+// describePath names a property by its path, or the document itself
+func describePath(path string) string {
+	if path == "" {
+		return "the document"
+	}
+	return "property " + path
+}
+
+// pathOrSchema names a nested object by its path, or the schema itself
+func pathOrSchema(path string, s *Schema) string {
+	if path == "" {
+		return "schema " + s.ref()
+	}
+	return "property " + path
+}
@@ -0,0 +1,179 @@
+package jsontype_test
+
+import (
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+// compareSchemas compares two schema definitions, any other definitions are
+// loaded first
+func compareSchemas(t *testing.T, from, to string, defs ...string) *jsontype.CompatibilityReport {
+	t.Helper()
+	sm := loadSchemas(t, append(defs, from, to)...)
+	v1 := getSchema(t, sm, "order@1.0.0")
+	v2 := getSchema(t, sm, "order@2.0.0")
+	report, err := jsontype.CompareSchemas(v1, v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+// expectChange fails the test unless the report has a single change
+func expectChange(t *testing.T, report *jsontype.CompatibilityReport, path, change string, compatibility jsontype.Compatibility) {
+	t.Helper()
+	if len(report.Changes) != 1 {
+		t.Fatalf("expected a single change but got %+v", report.Changes)
+	}
+	c := report.Changes[0]
+	if c.Path != path || c.Change != change || c.Compatibility != compatibility || report.Compatibility != compatibility {
+		t.Fatalf("expected %s of %q with %s compatibility but got %+v", change, path, compatibility, c)
+	}
+}
+
+func TestCompareSchemas(t *testing.T) {
+	report := compareSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string"}}}`,
+		`{"type": "Order", "version": "2.0.0", "properties": {"id": {"type": "string"}}}`,
+	)
+	if report.Old != "Order@1.0.0" || report.New != "Order@2.0.0" || report.Compatibility != jsontype.CompatibilityFull || len(report.Changes) != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestCompareSchemasPropertyAdded(t *testing.T) {
+	report := compareSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string"}}}`,
+		`{"type": "Order", "version": "2.0.0", "properties": {"id": {"type": "string"}, "customer": {"type": "string"}}}`,
+	)
+	expectChange(t, report, "customer", "property_added", jsontype.CompatibilityNone)
+
+	// an optional property can be added without breaking old documents
+	report = compareSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string"}}}`,
+		`{"type": "Order", "version": "2.0.0", "properties": {"id": {"type": "string"}}, "optional_properties": {"coupon": {"type": "string"}}}`,
+	)
+	expectChange(t, report, "coupon", "property_added", jsontype.CompatibilityBackward)
+}
+
+func TestCompareSchemasPropertyRemoved(t *testing.T) {
+	report := compareSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string"}}, "optional_properties": {"note": {"type": "string"}}}`,
+		`{"type": "Order", "version": "2.0.0", "properties": {"id": {"type": "string"}}, "allow_undefined_properties": true}`,
+	)
+	if len(report.Changes) != 2 || report.Changes[0].Path != "note" || report.Changes[0].Change != "property_removed" || report.Changes[0].Compatibility != jsontype.CompatibilityFull {
+		t.Fatalf("unexpected changes %+v", report.Changes)
+	}
+}
+
+func TestCompareSchemasUndefinedPropertiesAllowed(t *testing.T) {
+	report := compareSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string"}}}`,
+		`{"type": "Order", "version": "2.0.0", "properties": {"id": {"type": "string"}}, "allow_undefined_properties": true}`,
+	)
+	expectChange(t, report, "", "undefined_properties_allowed", jsontype.CompatibilityBackward)
+}
+
+func TestCompareSchemasRuleTightened(t *testing.T) {
+	report := compareSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"status": {"type": "string", "rules": {"oneof": ["new", "paid", "shipped"]}}}}`,
+		`{"type": "Order", "version": "2.0.0", "properties": {"status": {"type": "string", "rules": {"oneof": ["new", "paid"]}}}}`,
+	)
+	expectChange(t, report, "status", "rule_tightened", jsontype.CompatibilityForward)
+
+	// test the reverse comparison
+	report = compareSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string", "rules": {"max_length": 5}}}}`,
+		`{"type": "Order", "version": "2.0.0", "properties": {"id": {"type": "string", "rules": {"max_length": 10}}}}`,
+	)
+	expectChange(t, report, "id", "rule_loosened", jsontype.CompatibilityBackward)
+}
+
+func TestCompareSchemasTypeChanged(t *testing.T) {
+	report := compareSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"total": {"type": "number"}}}`,
+		`{"type": "Order", "version": "2.0.0", "properties": {"total": {"type": "string"}}}`,
+	)
+	expectChange(t, report, "total", "type_changed", jsontype.CompatibilityNone)
+}
+
+func TestCompareSchemasReferences(t *testing.T) {
+	// a referenced schema that did not change does not change compatibility
+	report := compareSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"address": {"type": "object", "ref": "Address"}}}`,
+		`{"type": "Order", "version": "2.0.0", "properties": {"address": {"type": "object", "ref": "Address"}}}`,
+		`{"type": "Address", "properties": {"zip": {"type": "string", "rules": {"max_length": 10}}}}`,
+	)
+	if len(report.Changes) != 0 {
+		t.Fatalf("expected no changes but got %+v", report.Changes)
+	}
+}
+
+func TestCompareSchemaRoots(t *testing.T) {
+	sm := loadSchemas(t,
+		`{"type": "Order", "properties": {"id": {"type": "number"}}}`,
+		`{"type": "Orders", "version": "1.0.0", "properties": {"orders": {"type": "array", "items": {"type": "object", "ref": "Order"}}}}`,
+		`{"type": "Orders", "version": "2.0.0", "root": {"type": "array", "items": {"type": "object", "ref": "Order"}, "rules": {"max_length": 10}}}`,
+		`{"type": "Orders", "version": "3.0.0", "root": {"type": "array", "items": {"type": "object", "ref": "Order"}, "rules": {"max_length": 20}}}`,
+	)
+	v1, _ := sm.GetSchema("orders@1.0.0")
+	v2, _ := sm.GetSchema("orders@2.0.0")
+	v3, _ := sm.GetSchema("orders@3.0.0")
+
+	// test changing the kind of the root breaks compatibility
+	report, err := jsontype.CompareSchemas(v1, v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].Change != "root_changed" || report.Compatibility != jsontype.CompatibilityNone {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	// test the roots themselves are compared
+	report, err = jsontype.CompareSchemas(v2, v3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 1 || report.Changes[0].Change != "rule_loosened" || report.Changes[0].Message != "the document rule max_length was loosened from 10 to 20" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestCompatibilityReportCheck(t *testing.T) {
+	sm := loadSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string", "rules": {"max_length": 5}}}}`,
+		`{"type": "Order", "version": "1.1.0", "properties": {"id": {"type": "string", "rules": {"max_length": 10}}}, "optional_properties": {"note": {"type": "string"}}}`,
+		`{"type": "Order", "version": "1.2.0", "properties": {"id": {"type": "string", "rules": {"max_length": 10}}}, "optional_properties": {"note": {"type": "string", "description": "a note"}}}`,
+	)
+	v1, _ := sm.GetSchema("order@1.0.0")
+	v11, _ := sm.GetSchema("order@1.1.0")
+	v12, _ := sm.GetSchema("order@1.2.0")
+
+	report, err := jsontype.CompareSchemas(v1, v11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Compatibility != jsontype.CompatibilityBackward {
+		t.Fatalf("expected backward compatibility but got %s", report.Compatibility)
+	}
+	for mode, compatible := range map[jsontype.Compatibility]bool{
+		jsontype.CompatibilityBackward: true,
+		jsontype.CompatibilityForward:  false,
+		jsontype.CompatibilityFull:     false,
+		jsontype.CompatibilityNone:     true,
+	} {
+		if err := report.Check(mode); (err == nil) != compatible {
+			t.Fatalf("%s: unexpected result %v", mode, err)
+		}
+	}
+
+	// test descriptions do not affect compatibility
+	report, err = jsontype.CompareSchemas(v11, v12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 0 || report.Check(jsontype.CompatibilityFull) != nil {
+		t.Fatalf("expected no changes but got %+v", report.Changes)
+	}
+}
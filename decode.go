@@ -0,0 +1,326 @@
+package jsontype
+
+import (
+	"encoding"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-reflect"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Decode validates a JSON document against the schema and decodes it into a
+// value of type T. The document is only parsed once, and is decoded following
+// the same rules as encoding/json. If the document does not satisfy the
+// schema, the zero value of T is returned along with the ValidationErrors.
+func Decode[T any](s *Schema, document []byte) (T, error) {
+	var v T
+	data, err := decodeDocument(document)
+	if err != nil {
+		return v, err
+	}
+
+	err = s.validateDocument(data)
+	if err != nil {
+		return v, err
+	}
+
+	err = decodeValue("", data, reflect.ValueOf(&v).Elem())
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
+
+// DecodeAs gets a schema from the SchemaManager, then validates a JSON
+// document against it and decodes it into a value of type T
+func DecodeAs[T any](sm *SchemaManager, schemaType string, document []byte) (T, error) {
+	schema, err := sm.GetSchema(schemaType)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return Decode[T](schema, document)
+}
+
+// decodeValue stores decoded JSON data in a settable Go value, path is the
+// location of the data within the document and is used in errors
+func decodeValue(path string, data interface{}, rv reflect.Value) error {
+
+	// types with their own JSON or text decoding are decoded with it
+	if rv.CanAddr() {
+		if u, ok := rv.Addr().Interface().(json.Unmarshaler); ok && rv.Type().Kind() != reflect.Ptr {
+			b, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+			return u.UnmarshalJSON(b)
+		}
+		if u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok && rv.Type().Kind() != reflect.Ptr {
+			if str, ok := data.(string); ok {
+				return u.UnmarshalText([]byte(str))
+			}
+			if data == nil {
+				return nil
+			}
+			return decodeError(path, data, rv)
+		}
+	}
+
+	if data == nil {
+		switch rv.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return decodeValue(path, data, rv.Elem())
+
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return decodeError(path, data, rv)
+		}
+		rv.Set(reflect.ValueOf(plainNumbers(data)))
+		return nil
+
+	case reflect.String:
+		str, ok := data.(string)
+		if !ok {
+			return decodeError(path, data, rv)
+		}
+		rv.SetString(str)
+		return nil
+
+	case reflect.Bool:
+		b, ok := data.(bool)
+		if !ok {
+			return decodeError(path, data, rv)
+		}
+		rv.SetBool(b)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := data.(json.Number)
+		if !ok {
+			return decodeError(path, data, rv)
+		}
+		i, err := strconv.ParseInt(string(n), 10, 64)
+		if err != nil || rv.OverflowInt(i) {
+			return decodeError(path, data, rv)
+		}
+		rv.SetInt(i)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := data.(json.Number)
+		if !ok {
+			return decodeError(path, data, rv)
+		}
+		u, err := strconv.ParseUint(string(n), 10, 64)
+		if err != nil || rv.OverflowUint(u) {
+			return decodeError(path, data, rv)
+		}
+		rv.SetUint(u)
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		n, ok := data.(json.Number)
+		if !ok {
+			return decodeError(path, data, rv)
+		}
+		f, err := strconv.ParseFloat(string(n), rv.Type().Bits())
+		if err != nil {
+			return decodeError(path, data, rv)
+		}
+		rv.SetFloat(f)
+		return nil
+
+	case reflect.Slice:
+		// byte slices are decoded from base64 strings
+		if str, ok := data.(string); ok && rv.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := base64.StdEncoding.DecodeString(str)
+			if err != nil {
+				return fmt.Errorf("cannot decode %s: %w", pathOrDocument(path), err)
+			}
+			rv.SetBytes(b)
+			return nil
+		}
+		items, ok := data.([]interface{})
+		if !ok {
+			return decodeError(path, data, rv)
+		}
+		slice := reflect.MakeSlice(rv.Type(), len(items), len(items))
+		for i, item := range items {
+			err := decodeValue(fmt.Sprintf("%s[%d]", path, i), item, slice.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+		rv.Set(slice)
+		return nil
+
+	case reflect.Array:
+		items, ok := data.([]interface{})
+		if !ok {
+			return decodeError(path, data, rv)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if i >= len(items) {
+				rv.Index(i).Set(reflect.Zero(rv.Type().Elem()))
+				continue
+			}
+			err := decodeValue(fmt.Sprintf("%s[%d]", path, i), items[i], rv.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		object, ok := data.(map[string]interface{})
+		if !ok {
+			return decodeError(path, data, rv)
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), len(object)))
+		}
+		for key, item := range object {
+			k := reflect.New(rv.Type().Key()).Elem()
+			err := decodeMapKey(key, k)
+			if err != nil {
+				return fmt.Errorf("cannot decode key %s of %s: %w", key, pathOrDocument(path), err)
+			}
+			v := reflect.New(rv.Type().Elem()).Elem()
+			err = decodeValue(joinPath(path, key), item, v)
+			if err != nil {
+				return err
+			}
+			rv.SetMapIndex(k, v)
+		}
+		return nil
+
+	case reflect.Struct:
+		object, ok := data.(map[string]interface{})
+		if !ok {
+			return decodeError(path, data, rv)
+		}
+		fields := jsonFields(rv.Type())
+		for key, item := range object {
+			f, ok := matchField(fields, key)
+			if !ok {
+				continue
+			}
+			fv, ok := fieldByIndex(rv, f.Index, true)
+			if !ok {
+				continue
+			}
+
+			// the string option decodes numbers and bools from strings
+			if str, isString := item.(string); f.Quoted && isString {
+				if quoted, err := decodeDocument([]byte(str)); err == nil {
+					item = quoted
+				}
+			}
+
+			err := decodeValue(joinPath(path, key), item, fv)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return decodeError(path, data, rv)
+}
+
+// plainNumbers converts the json.Number values within decoded data to
+// float64, which is how encoding/json decodes numbers into an interface{}
+func plainNumbers(data interface{}) interface{} {
+	switch data := data.(type) {
+	case json.Number:
+		f, _ := data.Float64()
+		return f
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(data))
+		for key, item := range data {
+			object[key] = plainNumbers(item)
+		}
+		return object
+	case []interface{}:
+		items := make([]interface{}, len(data))
+		for i, item := range data {
+			items[i] = plainNumbers(item)
+		}
+		return items
+	}
+	return data
+}
+
+// matchField finds the field for an object key, preferring an exact match but
+// falling back to a case-insensitive match like encoding/json
+func matchField(fields []jsonField, key string) (jsonField, bool) {
+	for _, f := range fields {
+		if f.Name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, key) {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// decodeMapKey decodes an object key into a map key of any supported type
+func decodeMapKey(key string, k reflect.Value) error {
+	if u, ok := k.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(key))
+	}
+	switch k.Kind() {
+	case reflect.String:
+		k.SetString(key)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || k.OverflowInt(n) {
+			return fmt.Errorf("%s is not a valid %v", key, k.Type())
+		}
+		k.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || k.OverflowUint(n) {
+			return fmt.Errorf("%s is not a valid %v", key, k.Type())
+		}
+		k.SetUint(n)
+		return nil
+	}
+	return fmt.Errorf("unsupported map key type %v", k.Type())
+}
+
+func decodeError(path string, data interface{}, rv reflect.Value) error {
+	return fmt.Errorf("cannot decode %s of type %s into %v", pathOrDocument(path), jsonKind(data), rv.Type())
+}
+
+// pathOrDocument describes a location within a document for errors
+func pathOrDocument(path string) string {
+	if path == "" {
+		return "document"
+	}
+	return path
+}
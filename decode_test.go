@@ -0,0 +1,205 @@
+package jsontype_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apageadev/jsontype"
+)
+
+type Base struct {
+	ID uint64 `json:"id"`
+}
+
+type Order struct {
+	*Base
+	Customer  string            `json:"customer"`
+	Quantity  int8              `json:"quantity"`
+	Price     float32           `json:"price"`
+	Tags      []string          `json:"tags"`
+	Meta      map[string]int    `json:"meta"`
+	Extra     json.RawMessage   `json:"extra"`
+	Placed    time.Time         `json:"placed"`
+	Note      *string           `json:"note"`
+	Anything  interface{}       `json:"anything"`
+	Shipments map[int][2]string `json:"shipments"`
+}
+
+const orderSchema = `{
+	"type": "Order",
+	"properties": {
+		"id": {"type": "number"},
+		"customer": {"type": "string", "rules": {"min_length": 1}},
+		"quantity": {"type": "number", "rules": {"min": 1}},
+		"price": {"type": "number"},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"meta": {"type": "object"},
+		"extra": {"type": "object"},
+		"placed": {"type": "string"},
+		"anything": {"type": "list"},
+		"shipments": {"type": "object"}
+	},
+	"optional_properties": {
+		"note": {"type": "string"}
+	}
+}`
+
+func TestDecode(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	err := sm.LoadSchema([]byte(orderSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := sm.GetSchema("order")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := jsontype.Decode[Order](schema, []byte(`{
+		"id": 42,
+		"customer": "Luna",
+		"quantity": 3,
+		"price": 9.99,
+		"tags": ["a", "b"],
+		"meta": {"x": 1},
+		"extra": {"gift": true},
+		"placed": "2023-01-02T03:04:05Z",
+		"anything": [1, "two"],
+		"shipments": {"1": ["box", "truck"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if order.Base == nil || order.ID != 42 {
+		t.Fatal("expected embedded id to be decoded")
+	}
+	if order.Customer != "Luna" || order.Quantity != 3 || order.Price != 9.99 {
+		t.Fatalf("unexpected order %+v", order)
+	}
+	if len(order.Tags) != 2 || order.Meta["x"] != 1 {
+		t.Fatalf("unexpected order %+v", order)
+	}
+	if string(order.Extra) != `{"gift":true}` {
+		t.Fatalf("unexpected extra %s", order.Extra)
+	}
+	if order.Placed.Year() != 2023 {
+		t.Fatalf("unexpected placed %v", order.Placed)
+	}
+	if order.Note != nil {
+		t.Fatal("expected note to be nil")
+	}
+	if anything := order.Anything.([]interface{}); len(anything) != 2 || anything[0] != float64(1) {
+		t.Fatalf("unexpected anything %v", order.Anything)
+	}
+	if order.Shipments[1][1] != "truck" {
+		t.Fatalf("unexpected shipments %v", order.Shipments)
+	}
+}
+
+func TestDecodeValidationErrors(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	err := sm.LoadSchema([]byte(orderSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test structured validation errors
+	order, err := jsontype.DecodeAs[*Order](sm, "order", []byte(`{"customer": "", "quantity": 0, "unknown": true}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if order != nil {
+		t.Fatal("expected nil order")
+	}
+
+	var verrs jsontype.ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors but got %T", err)
+	}
+
+	kinds := map[jsontype.ErrorKind]int{}
+	for _, verr := range verrs {
+		kinds[verr.Kind]++
+	}
+	if kinds[jsontype.ErrorRuleViolation] != 2 {
+		t.Fatalf("expected 2 rule violations but got %d", kinds[jsontype.ErrorRuleViolation])
+	}
+	if kinds[jsontype.ErrorUndefinedProperty] != 1 {
+		t.Fatalf("expected 1 undefined property but got %d", kinds[jsontype.ErrorUndefinedProperty])
+	}
+	if kinds[jsontype.ErrorMissingProperty] != 8 {
+		t.Fatalf("expected 8 missing properties but got %d", kinds[jsontype.ErrorMissingProperty])
+	}
+
+	// test bad json
+	_, err = jsontype.DecodeAs[Order](sm, "order", []byte(`{`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test unknown schema
+	_, err = jsontype.DecodeAs[Order](sm, "bad", []byte(`{}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestDecodeTypeMismatch(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	err := sm.LoadSchema([]byte(`{"type": "Count", "properties": {"count": {"type": "number"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Count struct {
+		Count uint8 `json:"count"`
+	}
+
+	// the document satisfies the schema but does not fit the Go type
+	_, err = jsontype.DecodeAs[Count](sm, "count", []byte(`{"count": 300}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	_, err = jsontype.DecodeAs[Count](sm, "count", []byte(`{"count": 1.5}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	count, err := jsontype.DecodeAs[Count](sm, "count", []byte(`{"count": 255}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count.Count != 255 {
+		t.Fatalf("expected 255 but got %d", count.Count)
+	}
+}
+
+func TestDecodeInt64Precision(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	err := sm.LoadSchema([]byte(`{"type": "Entity", "properties": {"id": {"type": "number"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Entity struct {
+		ID int64 `json:"id"`
+	}
+
+	// test an integer that cannot be represented by a float64
+	entity, err := jsontype.DecodeAs[Entity](sm, "entity", []byte(`{"id": 9007199254740993}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected Entity
+	if err := json.Unmarshal([]byte(`{"id": 9007199254740993}`), &expected); err != nil {
+		t.Fatal(err)
+	}
+	if entity.ID != 9007199254740993 || entity != expected {
+		t.Fatalf("expected id 9007199254740993 but got %d", entity.ID)
+	}
+}
@@ -0,0 +1,358 @@
+package jsontype
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// DiffKind identifies how a value changed between two documents
+type DiffKind string
+
+const (
+	// DiffAdded is reported for a property or list item that was added
+	DiffAdded DiffKind = "added"
+	// DiffRemoved is reported for a property or list item that was removed
+	DiffRemoved DiffKind = "removed"
+	// DiffChanged is reported for a value that was replaced
+	DiffChanged DiffKind = "changed"
+)
+
+// A DocumentChange is a single difference between two documents
+type DocumentChange struct {
+	// Path is the location of the value within the documents, such as
+	// address.zip or tags[0]. Items added to or removed from a list that is
+	// compared as a set have the path of the list.
+	Path string      `json:"path"`
+	Kind DiffKind    `json:"kind"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+
+	// pointer is the JSON Pointer the change is applied to by a JSON Patch
+	pointer string
+	// item is true for items added to or removed from a set
+	item bool
+}
+
+// A DocumentDiff lists every difference between two documents
+type DocumentDiff struct {
+	Changes []DocumentChange `json:"changes"`
+}
+
+// DiffOptions changes how documents are compared
+type DiffOptions struct {
+	// OrderedLists compares list properties by position, like array
+	// properties. By default lists are compared as sets and moving an item
+	// within a list is not a change.
+	OrderedLists bool
+
+	// ListKeys names the property that identifies the object items of a list
+	// compared as a set, by the path of the list such as orders[].lines. Items
+	// with the same key are compared property by property, rather than being
+	// removed and added when they differ.
+	ListKeys map[string]string
+}
+
+// A PatchOperation is a single operation of an RFC 6902 JSON Patch
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Diff compares two documents that satisfy the schema and returns the
+// changes that turn the first document into the second. Properties are
+// compared using the schema, array properties are compared by position and
+// list properties as sets unless options say otherwise.
+func (s *Schema) Diff(from, to []byte, options DiffOptions) (*DocumentDiff, error) {
+	documents := []interface{}{}
+	for _, document := range [][]byte{from, to} {
+		data, err := decodeDocument(document)
+		if err != nil {
+			return nil, err
+		}
+		err = s.validateDocument(data)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, data)
+	}
+
+	d := &differ{options: options}
+	var err error
+	if s.hasObjectRoot() {
+		from, _ := documents[0].(map[string]interface{})
+		to, _ := documents[1].(map[string]interface{})
+		err = d.diffObject(s, diffLocation{}, from, to)
+	} else {
+		root := s.root()
+		err = d.diffValue(s, &root, diffLocation{}, documents[0], documents[1])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &DocumentDiff{Changes: d.changes}, nil
+}
+
+// Patch returns an RFC 6902 JSON Patch that applies the changes. Items of a
+// list compared as a set are added to the end of the list.
+func (d *DocumentDiff) Patch() []PatchOperation {
+	operations := make([]PatchOperation, 0, len(d.Changes))
+	for _, change := range d.Changes {
+		operation := PatchOperation{Path: change.pointer}
+		switch change.Kind {
+		case DiffAdded:
+			operation.Op = "add"
+			operation.Value, _ = json.Marshal(change.New)
+		case DiffRemoved:
+			operation.Op = "remove"
+		case DiffChanged:
+			operation.Op = "replace"
+			operation.Value, _ = json.Marshal(change.New)
+		}
+		operations = append(operations, operation)
+	}
+	return operations
+}
+
+// String returns a human readable summary of the changes, one per line
+func (d *DocumentDiff) String() string {
+	lines := make([]string, len(d.Changes))
+	for i, change := range d.Changes {
+		lines[i] = change.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c DocumentChange) String() string {
+	path := c.Path
+	if path == "" {
+		path = "document"
+	}
+	switch {
+	case c.item && c.Kind == DiffAdded:
+		return fmt.Sprintf("%s was added to %s", formatValue(c.New), path)
+	case c.item:
+		return fmt.Sprintf("%s was removed from %s", formatValue(c.Old), path)
+	case c.Kind == DiffAdded:
+		return fmt.Sprintf("%s was added with %s", path, formatValue(c.New))
+	case c.Kind == DiffRemoved:
+		return fmt.Sprintf("%s was removed, it was %s", path, formatValue(c.Old))
+	}
+	return fmt.Sprintf("%s changed from %s to %s", path, formatValue(c.Old), formatValue(c.New))
+}
+
+// formatValue formats a value as compact JSON
+func formatValue(value interface{}) string {
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+// diffLocation is the location of a value within the documents. path is used
+// in changes, pointer in JSON Patches and key to look up list keys.
+type diffLocation struct {
+	path, pointer, key string
+}
+
+// property returns the location of a property of the object at l
+func (l diffLocation) property(name string) diffLocation {
+	return diffLocation{
+		path:    joinPath(l.path, name),
+		pointer: l.pointer + "/" + escapePointer(name),
+		key:     joinPath(l.key, name),
+	}
+}
+
+// index returns the location of an item of the list at l, pointerIndex is
+// the position of the item once earlier changes are applied
+func (l diffLocation) index(i, pointerIndex int) diffLocation {
+	return diffLocation{
+		path:    fmt.Sprintf("%s[%d]", l.path, i),
+		pointer: l.pointer + "/" + strconv.Itoa(pointerIndex),
+		key:     l.key + "[]",
+	}
+}
+
+// escapePointer escapes a property name for use in a JSON Pointer
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
+
+// differ collects the changes between two documents
+type differ struct {
+	options DiffOptions
+	changes []DocumentChange
+}
+
+func (d *differ) add(l diffLocation, kind DiffKind, from, to interface{}) {
+	d.changes = append(d.changes, DocumentChange{Path: l.path, Kind: kind, Old: from, New: to, pointer: l.pointer})
+}
+
+// diffObject compares two objects, s is the schema of the objects or nil if
+// they are not described by a schema
+func (d *differ) diffObject(s *Schema, l diffLocation, from, to map[string]interface{}) error {
+	properties := map[string]Property{}
+	if s != nil {
+		required, optional, err := s.resolveProperties()
+		if err != nil {
+			return err
+		}
+		properties = mergeProperties(required, optional)
+	}
+
+	names := map[string]bool{}
+	for name := range from {
+		names[name] = true
+	}
+	for name := range to {
+		names[name] = true
+	}
+	for _, name := range sortedKeys(names) {
+		a, inFrom := from[name]
+		b, inTo := to[name]
+		switch {
+		case !inFrom:
+			d.add(l.property(name), DiffAdded, nil, b)
+		case !inTo:
+			d.add(l.property(name), DiffRemoved, a, nil)
+		default:
+			var p *Property
+			if property, ok := properties[name]; ok {
+				p = &property
+			}
+			if err := d.diffValue(s, p, l.property(name), a, b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// diffValue compares two values of a property, p is nil when the value is not
+// described by the schema
+func (d *differ) diffValue(s *Schema, p *Property, l diffLocation, from, to interface{}) error {
+	if jsonKind(from) != jsonKind(to) {
+		d.add(l, DiffChanged, from, to)
+		return nil
+	}
+
+	switch from := from.(type) {
+	case map[string]interface{}:
+		var ref *Schema
+		if p != nil && p.Ref != "" && s != nil {
+			var err error
+			ref, err = s.lookup(p.Ref)
+			if err != nil {
+				return err
+			}
+		}
+		return d.diffObject(ref, l, from, to.(map[string]interface{}))
+
+	case []interface{}:
+		var items *Property
+		if p != nil {
+			items = p.Items
+		}
+		if p != nil && p.Type == "list" && !d.options.OrderedLists {
+			return d.diffSet(s, items, l, from, to.([]interface{}))
+		}
+		return d.diffOrdered(s, items, l, from, to.([]interface{}))
+	}
+
+	if !equal(from, to) {
+		d.add(l, DiffChanged, from, to)
+	}
+	return nil
+}
+
+// diffOrdered compares two lists by position
+func (d *differ) diffOrdered(s *Schema, items *Property, l diffLocation, from, to []interface{}) error {
+	for i := 0; i < len(from) && i < len(to); i++ {
+		if err := d.diffValue(s, items, l.index(i, i), from[i], to[i]); err != nil {
+			return err
+		}
+	}
+
+	// items are removed from the end so the positions of earlier items are
+	// unchanged
+	for i := len(from) - 1; i >= len(to); i-- {
+		d.add(l.index(i, i), DiffRemoved, from[i], nil)
+	}
+	for i := len(from); i < len(to); i++ {
+		d.add(l.index(i, i), DiffAdded, nil, to[i])
+	}
+	return nil
+}
+
+// diffSet compares two lists as sets. Removed items are removed first, from
+// the end of the list, then items with the same key are compared and finally
+// new items are added to the end of the list.
+func (d *differ) diffSet(s *Schema, items *Property, l diffLocation, from, to []interface{}) error {
+	key := d.options.ListKeys[l.key]
+	itemKey := func(item interface{}) (interface{}, bool) {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok := object[key]
+		return value, ok
+	}
+
+	// match every item of to with an item of from
+	matches := make([]int, len(to))
+	matched := make([]bool, len(from))
+	for j, b := range to {
+		matches[j] = -1
+		bKey, keyed := itemKey(b)
+		for i, a := range from {
+			if matched[i] {
+				continue
+			}
+			if aKey, ok := itemKey(a); key != "" && keyed && ok && equal(aKey, bKey) || equal(a, b) {
+				matches[j] = i
+				matched[i] = true
+				break
+			}
+		}
+	}
+
+	for i := len(from) - 1; i >= 0; i-- {
+		if !matched[i] {
+			d.changes = append(d.changes, DocumentChange{Path: l.path, Kind: DiffRemoved, Old: from[i], pointer: l.pointer + "/" + strconv.Itoa(i), item: true})
+		}
+	}
+
+	// the position of an item once the removed items are removed
+	positions := make([]int, len(from))
+	position := 0
+	for i := range from {
+		positions[i] = position
+		if matched[i] {
+			position++
+		}
+	}
+	order := make([]int, 0, len(to))
+	for j := range to {
+		if matches[j] >= 0 {
+			order = append(order, j)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return matches[order[a]] < matches[order[b]] })
+	for _, j := range order {
+		i := matches[j]
+		if err := d.diffValue(s, items, l.index(j, positions[i]), from[i], to[j]); err != nil {
+			return err
+		}
+	}
+
+	for j, b := range to {
+		if matches[j] < 0 {
+			d.changes = append(d.changes, DocumentChange{Path: l.path, Kind: DiffAdded, New: b, pointer: l.pointer + "/-", item: true})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package jsontype_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+// diffOrders diffs two documents of the Order schema def, any other
+// definitions are loaded first
+func diffOrders(t *testing.T, def, from, to string, options jsontype.DiffOptions, defs ...string) *jsontype.DocumentDiff {
+	t.Helper()
+	s := getSchema(t, loadSchemas(t, append(defs, def)...), "order")
+	diff, err := s.Diff([]byte(from), []byte(to), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return diff
+}
+
+func TestSchemaDiff(t *testing.T) {
+	diff := diffOrders(t,
+		`{"type": "Order", "properties": {"id": {"type": "string"}}, "optional_properties": {"note": {"type": "string"}, "coupon": {"type": "string"}}}`,
+		`{"id": "1", "note": "hi"}`,
+		`{"id": "2", "coupon": "new"}`,
+		jsontype.DiffOptions{},
+	)
+	expected := `coupon was added with "new"
+id changed from "1" to "2"
+note was removed, it was "hi"`
+	if diff.String() != expected {
+		t.Fatalf("unexpected diff\n%s", diff)
+	}
+}
+
+func TestSchemaDiffArray(t *testing.T) {
+	diff := diffOrders(t,
+		`{"type": "Order", "properties": {"steps": {"type": "array", "items": {"type": "string"}}}}`,
+		`{"steps": ["a", "b", "c"]}`,
+		`{"steps": ["a", "c"]}`,
+		jsontype.DiffOptions{},
+	)
+
+	// test arrays are compared by position
+	expected := `steps[1] changed from "b" to "c"
+steps[2] was removed, it was "c"`
+	if diff.String() != expected {
+		t.Fatalf("unexpected diff\n%s", diff)
+	}
+}
+
+func TestSchemaDiffList(t *testing.T) {
+	def := `{"type": "Order", "properties": {"tags": {"type": "list"}}}`
+	from, to := `{"tags": ["x", 1, "y"]}`, `{"tags": ["y", "x", "z"]}`
+
+	// test lists are compared as sets of values
+	diff := diffOrders(t, def, from, to, jsontype.DiffOptions{})
+	expected := `1 was removed from tags
+"z" was added to tags`
+	if diff.String() != expected {
+		t.Fatalf("unexpected diff\n%s", diff)
+	}
+
+	// test ordered lists are compared by position
+	diff = diffOrders(t, def, from, to, jsontype.DiffOptions{OrderedLists: true})
+	if len(diff.Changes) != 3 || diff.Changes[2].Path != "tags[2]" || diff.Changes[2].Kind != jsontype.DiffChanged || diff.Changes[2].New != "z" {
+		t.Fatalf("unexpected diff\n%s", diff)
+	}
+}
+
+func TestSchemaDiffListKeys(t *testing.T) {
+	diff := diffOrders(t,
+		`{"type": "Order", "properties": {"lines": {"type": "list", "items": {"type": "object", "ref": "Line"}}}}`,
+		`{"lines": [{"sku": "A", "quantity": 1}, {"sku": "B", "quantity": 2}]}`,
+		`{"lines": [{"sku": "B", "quantity": 3}, {"sku": "A", "quantity": 1}]}`,
+		jsontype.DiffOptions{ListKeys: map[string]string{"lines": "sku"}},
+		`{"type": "Line", "properties": {"sku": {"type": "string"}, "quantity": {"type": "number"}}}`,
+	)
+
+	// test items of keyed lists are matched by their key
+	if diff.String() != "lines[0].quantity changed from 2 to 3" {
+		t.Fatalf("unexpected diff\n%s", diff)
+	}
+	patch, err := json.Marshal(diff.Patch())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(patch) != `[{"op":"replace","path":"/lines/1/quantity","value":3}]` {
+		t.Fatalf("unexpected patch %s", patch)
+	}
+}
+
+func TestDocumentDiffPatch(t *testing.T) {
+	diff := diffOrders(t,
+		`{"type": "Order", "properties": {"id": {"type": "string"}, "tags": {"type": "list"}}, "optional_properties": {"note": {"type": "string"}, "a/b": {"type": "string"}}}`,
+		`{"id": "1", "note": "hi", "tags": ["x", 1]}`,
+		`{"id": "2", "a/b": "new", "tags": ["x", "z"]}`,
+		jsontype.DiffOptions{},
+	)
+	patch, err := json.Marshal(diff.Patch())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"op":"add","path":"/a~1b","value":"new"},` +
+		`{"op":"replace","path":"/id","value":"2"},` +
+		`{"op":"remove","path":"/note"},` +
+		`{"op":"remove","path":"/tags/1"},` +
+		`{"op":"add","path":"/tags/-","value":"z"}]`
+	if string(patch) != expected {
+		t.Fatalf("unexpected patch %s", patch)
+	}
+}
+
+func TestSchemaDiffArrayRoot(t *testing.T) {
+	s := getSchema(t, loadSchemas(t,
+		`{"type": "Line", "properties": {"sku": {"type": "string"}, "quantity": {"type": "number"}}}`,
+		`{"type": "Lines", "root": {"type": "list", "items": {"type": "object", "ref": "Line"}}}`,
+	), "lines")
+	diff, err := s.Diff(
+		[]byte(`[{"sku": "A", "quantity": 1}, {"sku": "B", "quantity": 2}]`),
+		[]byte(`[{"sku": "B", "quantity": 3}, {"sku": "C", "quantity": 1}]`),
+		jsontype.DiffOptions{ListKeys: map[string]string{"": "sku"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"quantity":1,"sku":"A"} was removed from document
+[0].quantity changed from 2 to 3
+{"quantity":1,"sku":"C"} was added to document`
+	if diff.String() != expected {
+		t.Fatalf("unexpected diff\n%s", diff)
+	}
+}
+
+func TestSchemaDiffNumberRoot(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, `{"type": "Price", "root": {"type": "number"}}`), "price")
+	diff, err := s.Diff([]byte(`1`), []byte(`2`), jsontype.DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := json.Marshal(diff.Patch())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.String() != "document changed from 1 to 2" || string(patch) != `[{"op":"replace","path":"","value":2}]` {
+		t.Fatalf("unexpected diff\n%s\n%s", diff, patch)
+	}
+}
+
+func TestSchemaDiffInvalid(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, `{"type": "Order", "properties": {"id": {"type": "string"}, "steps": {"type": "array"}}}`), "order")
+	_, err := s.Diff([]byte(`{"id": "1"}`), []byte(`{"id": "1", "steps": []}`), jsontype.DiffOptions{})
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+}
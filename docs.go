@@ -0,0 +1,481 @@
+package jsontype
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// DocsOptions configures the documentation written by WriteMarkdown and
+// WriteHTML
+type DocsOptions struct {
+	// Title is the heading of the documentation, it defaults to "Schemas"
+	Title string
+
+	// Generate configures the generator of example documents, the same Seed
+	// generates the same examples each time the documentation is written, and
+	// FilePath must be set to document schemas with the filepath format
+	Generate GenerateOptions
+}
+
+// title returns the heading of the documentation
+func (options DocsOptions) title() string {
+	if options.Title == "" {
+		return "Schemas"
+	}
+	return options.Title
+}
+
+// schemaDoc is the documentation of a schema, its fields are rendered in the
+// format being written
+type schemaDoc struct {
+	Name                     string
+	Anchor                   string
+	Version                  string
+	Description              string
+	Extends                  string
+	Root                     string
+	RootRules                []string
+	Properties               []propertyDoc
+	AllowUndefinedProperties bool
+	Example                  string
+}
+
+// propertyDoc is the documentation of a property
+type propertyDoc struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+	Rules       []string
+}
+
+// docsFormat renders the parts of the documentation that differ between
+// formats
+type docsFormat struct {
+	// text escapes text
+	text func(s string) string
+	// code renders a literal value, such as a rule argument
+	code func(s string) string
+	// link renders a link to the documentation of a schema
+	link func(text, anchor string) string
+}
+
+var markdownFormat = docsFormat{
+	text: func(s string) string {
+		return strings.NewReplacer("\\", "\\\\", "|", "\\|", "*", "\\*", "_", "\\_", "`", "\\`", "<", "&lt;", "\n", "<br>").Replace(s)
+	},
+	code: func(s string) string {
+		s = strings.ReplaceAll(s, "|", "\\|")
+		if strings.Contains(s, "`") {
+			return "`` " + s + " ``"
+		}
+		return "`" + s + "`"
+	},
+	link: func(text, anchor string) string {
+		return "[" + text + "](#" + anchor + ")"
+	},
+}
+
+var htmlFormat = docsFormat{
+	text: func(s string) string {
+		return strings.ReplaceAll(template.HTMLEscapeString(s), "\n", "<br>")
+	},
+	code: func(s string) string {
+		return "<code>" + template.HTMLEscapeString(s) + "</code>"
+	},
+	link: func(text, anchor string) string {
+		return `<a href="#` + anchor + `">` + text + "</a>"
+	},
+}
+
+// WriteMarkdown writes documentation for the latest version of every schema
+// in the SchemaManager to w as Markdown. Every property is listed with its
+// type, whether it is required, its description and its rules in plain
+// English, extended and referenced schemas are linked, and every schema has a
+// generated example document. Nothing is written if a schema's properties
+// cannot be resolved or its example cannot be generated, such as a schema
+// with the filepath format when Generate.FilePath is not set.
+func (sm *SchemaManager) WriteMarkdown(w io.Writer, options DocsOptions) error {
+	docs, err := sm.schemaDocs(markdownFormat, options)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n\n", markdownFormat.text(options.title()))
+	for _, doc := range docs {
+		fmt.Fprintf(bw, "- [%s](#%s)\n", doc.Name, doc.Anchor)
+	}
+
+	for _, doc := range docs {
+		fmt.Fprintf(bw, "\n## %s\n", doc.Name)
+		if doc.Version != "" {
+			fmt.Fprintf(bw, "\nVersion %s\n", doc.Version)
+		}
+		if doc.Description != "" {
+			fmt.Fprintf(bw, "\n%s\n", doc.Description)
+		}
+		if doc.Extends != "" {
+			fmt.Fprintf(bw, "\nExtends %s.\n", doc.Extends)
+		}
+
+		if doc.Root != "" {
+			fmt.Fprintf(bw, "\nDocuments are %s.\n", doc.Root)
+			if len(doc.RootRules) > 0 {
+				fmt.Fprintln(bw)
+			}
+			for _, rule := range doc.RootRules {
+				fmt.Fprintf(bw, "- %s\n", rule)
+			}
+		} else if len(doc.Properties) == 0 {
+			fmt.Fprintln(bw, "\nThis schema has no properties.")
+		} else {
+			fmt.Fprintln(bw, "\n| Property | Type | Required | Description | Rules |")
+			fmt.Fprintln(bw, "| --- | --- | --- | --- | --- |")
+			for _, p := range doc.Properties {
+				required := "no"
+				if p.Required {
+					required = "yes"
+				}
+				fmt.Fprintf(bw, "| %s | %s | %s | %s | %s |\n", p.Name, p.Type, required, p.Description, strings.Join(p.Rules, "<br>"))
+			}
+		}
+		if doc.AllowUndefinedProperties {
+			fmt.Fprintln(bw, "\nProperties that are not listed are allowed.")
+		}
+
+		if doc.Example != "" {
+			fmt.Fprintf(bw, "\nExample:\n\n```json\n%s\n```\n", doc.Example)
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteHTML writes the documentation written by WriteMarkdown to w as a
+// static HTML page
+func (sm *SchemaManager) WriteHTML(w io.Writer, options DocsOptions) error {
+	docs, err := sm.schemaDocs(htmlFormat, options)
+	if err != nil {
+		return err
+	}
+	return htmlDocs.Execute(w, struct {
+		Title   string
+		Schemas []schemaDoc
+	}{options.title(), docs})
+}
+
+// htmlDocs is the template of WriteHTML, the fields of a schemaDoc are
+// already escaped
+var htmlDocs = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"raw": func(s string) template.HTML {
+		return template.HTML(s)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.4em; text-align: left; vertical-align: top; }
+code, pre { background: #f4f4f4; }
+pre { padding: 1em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<ul>
+{{- range .Schemas}}
+<li><a href="#{{.Anchor}}">{{raw .Name}}</a></li>
+{{- end}}
+</ul>
+{{- range .Schemas}}
+<section id="{{.Anchor}}">
+<h2>{{raw .Name}}</h2>
+{{- if .Version}}
+<p>Version {{raw .Version}}</p>
+{{- end}}
+{{- if .Description}}
+<p>{{raw .Description}}</p>
+{{- end}}
+{{- if .Extends}}
+<p>Extends {{raw .Extends}}.</p>
+{{- end}}
+{{- if .Root}}
+<p>Documents are {{raw .Root}}.</p>
+{{- if .RootRules}}
+<ul>
+{{- range .RootRules}}
+<li>{{raw .}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- else if not .Properties}}
+<p>This schema has no properties.</p>
+{{- else}}
+<table>
+<tr><th>Property</th><th>Type</th><th>Required</th><th>Description</th><th>Rules</th></tr>
+{{- range .Properties}}
+<tr><td>{{raw .Name}}</td><td>{{raw .Type}}</td><td>{{if .Required}}yes{{else}}no{{end}}</td><td>{{raw .Description}}</td><td>{{range $i, $rule := .Rules}}{{if $i}}<br>{{end}}{{raw $rule}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .AllowUndefinedProperties}}
+<p>Properties that are not listed are allowed.</p>
+{{- end}}
+{{- if .Example}}
+<p>Example:</p>
+<pre><code>{{.Example}}</code></pre>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// schemaDocs documents the latest version of every schema, in order of type
+func (sm *SchemaManager) schemaDocs(f docsFormat, options DocsOptions) ([]schemaDoc, error) {
+	schemaTypes := sm.ListSchemas()
+	sort.Strings(schemaTypes)
+	docs := []schemaDoc{}
+	for _, schemaType := range schemaTypes {
+		s, err := sm.GetSchema(schemaType)
+		if err != nil {
+			continue
+		}
+		doc, err := sm.schemaDoc(f, s, options)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// schemaDoc documents a single schema, an error is returned if its properties
+// cannot be resolved or its example cannot be generated
+func (sm *SchemaManager) schemaDoc(f docsFormat, s *Schema, options DocsOptions) (schemaDoc, error) {
+	doc := schemaDoc{
+		Name:                     f.text(s.Type),
+		Anchor:                   docAnchor(s.Type),
+		Version:                  f.text(s.Version),
+		Description:              f.text(s.Description),
+		AllowUndefinedProperties: s.AllowUndefinedProperties,
+	}
+	if s.Extends != "" {
+		doc.Extends = sm.docLink(f, s.Extends)
+	}
+	if !s.hasObjectRoot() {
+		doc.Root = withArticle(sm.docType(f, *s.Root))
+		doc.RootRules = docRules(f, *s.Root)
+	}
+
+	properties, optionalProperties, err := s.resolveProperties()
+	if err != nil {
+		return doc, fmt.Errorf("schema %s could not be documented: %w", s.ref(), err)
+	}
+	for _, required := range []bool{true, false} {
+		defined := optionalProperties
+		if required {
+			defined = properties
+		}
+		for _, name := range sortedKeys(defined) {
+			p := defined[name]
+			description := f.text(p.Description)
+			if origin := s.origin(name); origin != s {
+				inherited := "Inherited from " + sm.docLink(f, origin.Type) + "."
+				description = strings.TrimSpace(inherited + " " + description)
+			}
+			doc.Properties = append(doc.Properties, propertyDoc{
+				Name:        f.code(name),
+				Type:        sm.docType(f, p),
+				Required:    required,
+				Description: description,
+				Rules:       docRules(f, p),
+			})
+		}
+	}
+
+	value, err := NewGenerator(s, options.Generate).GenerateValue()
+	if err != nil {
+		return doc, fmt.Errorf("an example of schema %s could not be generated: %w", s.ref(), err)
+	}
+	example, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return doc, err
+	}
+	doc.Example = string(example)
+	return doc, nil
+}
+
+// origin returns the schema that defines a property, the schema itself or one
+// of the schemas it extends
+func (s *Schema) origin(name string) *Schema {
+	for schema := s; ; {
+		_, required := schema.Properties[name]
+		_, optional := schema.OptionalProperties[name]
+		if required || optional || schema.Extends == "" {
+			return schema
+		}
+		parent, err := schema.lookup(schema.Extends)
+		if err != nil {
+			return schema
+		}
+		schema = parent
+	}
+}
+
+// docType describes the type of a property, linking to referenced schemas
+func (sm *SchemaManager) docType(f docsFormat, p Property) string {
+	switch {
+	case p.Type == "object" && p.Ref != "":
+		return sm.docLink(f, p.Ref)
+	case p.Type == "array" && p.Items != nil:
+		return "array of " + sm.docType(f, *p.Items)
+	}
+	return p.Type
+}
+
+// docLink links to the documentation of a referenced schema, using the casing
+// of the schema's type when it is loaded
+func (sm *SchemaManager) docLink(f docsFormat, ref string) string {
+	s, err := sm.GetSchema(ref)
+	if err != nil {
+		return f.text(ref)
+	}
+	return f.link(f.text(s.Type), docAnchor(s.Type))
+}
+
+// docAnchor returns the anchor of a schema's documentation, which matches the
+// anchor generated for its heading by GitHub
+func docAnchor(schemaType string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('0' <= r && r <= '9'):
+			return r
+		}
+		return -1
+	}, strings.ToLower(schemaType))
+}
+
+// docRules describes the rules of a property, and the rules of its items
+func docRules(f docsFormat, p Property) []string {
+	rules := []string{}
+	for _, rule := range sortedKeys(p.Rules) {
+		rules = append(rules, describeRule(f, p.Type, rule, p.Rules[rule]))
+	}
+	if p.Items != nil {
+		for _, rule := range docRules(f, *p.Items) {
+			rules = append(rules, "each item "+rule)
+		}
+	}
+	return rules
+}
+
+// formatDescriptions describe the values accepted by every format
+var formatDescriptions = map[string]string{
+	"alpha":       "must only contain letters",
+	"alphanum":    "must only contain letters and digits",
+	"alphadash":   "must only contain letters, digits, dashes and underscores",
+	"email":       "must be an email address",
+	"base64":      "must be base64 encoded",
+	"hexcolor":    "must be a hex color, such as #ff8800",
+	"hexadecimal": "must be a hexadecimal number",
+	"json":        "must be a JSON document",
+	"rgbcolor":    "must be an RGB color, such as rgb(255, 136, 0)",
+	"url":         "must be a URL",
+	"fullurl":     "must be a URL with a scheme, such as https://example.com",
+	"ip":          "must be an IP address",
+	"ipv4":        "must be an IPv4 address",
+	"ipv6":        "must be an IPv6 address",
+	"cidr":        "must be a CIDR block",
+	"cidrv4":      "must be an IPv4 CIDR block",
+	"cidrv6":      "must be an IPv6 CIDR block",
+	"uuid":        "must be a UUID",
+	"filepath":    "must be a file path",
+}
+
+// describeRule describes a rule of a property of a type in plain English
+func describeRule(f docsFormat, propertyType, rule string, arg interface{}) string {
+	literal := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return f.code(fmt.Sprint(v))
+		}
+		return f.code(string(b))
+	}
+	options := func() string {
+		list, ok := arg.([]interface{})
+		if !ok {
+			return literal(arg)
+		}
+		literals := make([]string, len(list))
+		for i, option := range list {
+			literals[i] = literal(option)
+		}
+		return strings.Join(literals, ", ")
+	}
+
+	switch rule {
+	case "min":
+		return "must be at least " + literal(arg)
+	case "max":
+		return "must be at most " + literal(arg)
+	case "min_length", "max_length":
+		bound := "at least"
+		if rule == "max_length" {
+			bound = "at most"
+		}
+		n, ok := toFloat(arg)
+		if !ok || n < 0 || n != math.Trunc(n) {
+			return fmt.Sprintf("must have a length of %s %s", bound, literal(arg))
+		}
+		unit := [2]string{"item", "items"}
+		switch propertyType {
+		case "string":
+			unit = [2]string{"character", "characters"}
+		case "object":
+			unit = [2]string{"property", "properties"}
+		}
+		name := unit[1]
+		if n == 1 {
+			name = unit[0]
+		}
+		if propertyType == "string" {
+			return fmt.Sprintf("must be %s %v %s", bound, n, name)
+		}
+		return fmt.Sprintf("must have %s %v %s", bound, n, name)
+	case "oneof":
+		return "must be one of " + options()
+	case "noneof":
+		return "must not contain any of " + options()
+	case "allof":
+		return "must only contain " + options()
+	case "anyof":
+		return "must contain at least one of " + options()
+	case "regex":
+		if pattern, ok := arg.(string); ok {
+			return "must match the regular expression " + f.code(pattern)
+		}
+	case "contains":
+		return "must contain " + literal(arg)
+	case "startswith":
+		return "must start with " + literal(arg)
+	case "format":
+		if format, ok := arg.(string); ok {
+			if description, ok := formatDescriptions[format]; ok {
+				return description
+			}
+		}
+	}
+	return fmt.Sprintf("must satisfy rule %s %s", f.text(rule), literal(arg))
+}
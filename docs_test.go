@@ -0,0 +1,127 @@
+package jsontype_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+var docsSchemas = []string{
+	`{"type": "Animal", "description": "An animal", "properties": {"name": {"type": "string", "description": "the name | of <the> animal", "rules": {"max_length": 5, "format": "alpha"}}}}`,
+	`{"type": "Person", "properties": {"email": {"type": "string", "rules": {"format": "email"}}}}`,
+	`{"type": "Dog", "version": "1.2.0", "extends": "animal", "allow_undefined_properties": true,
+		"properties": {
+			"owner": {"type": "object", "ref": "person"},
+			"tags": {"type": "array", "items": {"type": "string", "rules": {"min_length": 1}}, "rules": {"max_length": 3, "noneof": ["x"]}}
+		},
+		"optional_properties": {"code": {"type": "string", "rules": {"regex": "^[A-Z]{3}$"}}}
+	}`,
+	`{"type": "Dogs", "root": {"type": "array", "items": {"type": "object", "ref": "dog"}, "rules": {"max_length": 2}}}`,
+}
+
+// writeMarkdown writes the Markdown documentation of schema definitions
+func writeMarkdown(t *testing.T, options jsontype.DocsOptions, defs ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := loadSchemas(t, defs...).WriteMarkdown(&buf, options); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// writeHTML writes the HTML documentation of schema definitions
+func writeHTML(t *testing.T, options jsontype.DocsOptions, defs ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := loadSchemas(t, defs...).WriteHTML(&buf, options); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// expectContains fails the test unless text contains every expected string
+func expectContains(t *testing.T, text string, expected ...string) {
+	t.Helper()
+	for _, e := range expected {
+		if !strings.Contains(text, e) {
+			t.Fatalf("expected %q in\n%s", e, text)
+		}
+	}
+}
+
+func TestWriteMarkdownIndex(t *testing.T) {
+	markdown := writeMarkdown(t, jsontype.DocsOptions{Title: "Pets"}, docsSchemas...)
+	expectContains(t, markdown, "# Pets\n\n- [Animal](#animal)\n- [Dog](#dog)\n- [Dogs](#dogs)\n- [Person](#person)\n")
+}
+
+func TestWriteMarkdownExtends(t *testing.T) {
+	markdown := writeMarkdown(t, jsontype.DocsOptions{}, docsSchemas...)
+	expectContains(t, markdown,
+		"## Dog\n\nVersion 1.2.0\n\nExtends [Animal](#animal).\n",
+		"| `name` | string | yes | Inherited from [Animal](#animal). the name \\| of &lt;the> animal | must only contain letters<br>must be at most 5 characters |",
+	)
+}
+
+func TestWriteMarkdownProperties(t *testing.T) {
+	markdown := writeMarkdown(t, jsontype.DocsOptions{}, docsSchemas...)
+	expectContains(t, markdown,
+		"| `owner` | [Person](#person) | yes |  |  |",
+		"| `tags` | array of string | yes |  | must have at most 3 items<br>must not contain any of `\"x\"`<br>each item must be at least 1 character |",
+		"| `code` | string | no |  | must match the regular expression `^[A-Z]{3}$` |",
+		"Properties that are not listed are allowed.",
+	)
+}
+
+func TestWriteMarkdownArrayRoot(t *testing.T) {
+	markdown := writeMarkdown(t, jsontype.DocsOptions{}, docsSchemas...)
+	expectContains(t, markdown, "Documents are an array of [Dog](#dog).\n\n- must have at most 2 items\n")
+}
+
+func TestWriteMarkdownExample(t *testing.T) {
+	markdown := writeMarkdown(t, jsontype.DocsOptions{}, docsSchemas...)
+	expectContains(t, markdown, "Example:\n\n```json\n{\n  \"email\": ")
+
+	// test the same seed writes the same examples
+	if writeMarkdown(t, jsontype.DocsOptions{}, docsSchemas...) != markdown {
+		t.Fatal("expected the same documentation")
+	}
+
+	// test schemas whose documents cannot be generated are reported
+	upload := `{"type": "Upload", "properties": {"file": {"type": "string", "rules": {"format": "filepath"}}}}`
+	err := loadSchemas(t, upload).WriteMarkdown(&strings.Builder{}, jsontype.DocsOptions{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	markdown = writeMarkdown(t, jsontype.DocsOptions{Generate: jsontype.GenerateOptions{FilePath: "docs_test.go"}}, upload)
+	expectContains(t, markdown, `"file": "docs_test.go"`)
+
+	// test schemas whose properties cannot be resolved are reported
+	sm := loadSchemas(t, docsSchemas...)
+	if err := sm.DeleteSchema("animal"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.WriteHTML(&strings.Builder{}, jsontype.DocsOptions{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	html := writeHTML(t, jsontype.DocsOptions{}, docsSchemas...)
+	expectContains(t, html,
+		"<title>Schemas</title>",
+		`<section id="dog">`,
+		`<td><code>owner</code></td><td><a href="#person">Person</a></td><td>yes</td>`,
+		"<li>must have at most 2 items</li>",
+	)
+}
+
+func TestWriteHTMLEscaping(t *testing.T) {
+	html := writeHTML(t, jsontype.DocsOptions{Title: "<Pets>"}, docsSchemas...)
+	expectContains(t, html,
+		"<title>&lt;Pets&gt;</title>",
+		"the name | of &lt;the&gt; animal",
+		"<pre><code>{\n  &#34;email&#34;: ",
+	)
+}
@@ -0,0 +1,120 @@
+package jsontype
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorKind identifies why a document failed validation
+type ErrorKind string
+
+const (
+	// ErrorMissingProperty is reported when a required property is missing
+	ErrorMissingProperty ErrorKind = "missing_property"
+	// ErrorUndefinedProperty is reported when a property is not defined in the
+	// schema and the schema does not allow undefined properties
+	ErrorUndefinedProperty ErrorKind = "undefined_property"
+	// ErrorInvalidType is reported when a value is not of the expected type
+	ErrorInvalidType ErrorKind = "invalid_type"
+	// ErrorRuleViolation is reported when a value does not satisfy a rule
+	ErrorRuleViolation ErrorKind = "rule_violation"
+)
+
+// A ValidationError describes a single way in which a document does not
+// satisfy a schema
+type ValidationError struct {
+	// Path is the location of the invalid value within the document, such as
+	// address.zip or tags[0]. It is empty for the document itself.
+	Path string `json:"path"`
+	// Kind identifies why the value is invalid
+	Kind ErrorKind `json:"kind"`
+	// Rule is the name of the rule that was violated, for rule violations
+	Rule string `json:"rule,omitempty"`
+	// Expected is the type the value should have been for invalid types, and
+	// the rule's argument for rule violations
+	Expected interface{} `json:"expected,omitempty"`
+	// Actual is the kind of the value for invalid types, and the value itself
+	// for rule violations
+	Actual interface{} `json:"actual,omitempty"`
+	// Message is a human readable description of the error, rendered from a
+	// message template
+	Message string `json:"message"`
+
+	// key names the template of the message when it is not named by Kind,
+	// such as the rule of a rule violation
+	key string
+	// catalog finds the templates the message can be rendered from, it is
+	// nil for a message without a template
+	catalog *catalog
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors is returned when a document does not satisfy a schema, it
+// holds every error that was found in the document
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// A LoadError describes a schema file that could not be loaded
+type LoadError struct {
+	File string
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return e.File + ": " + e.Err.Error()
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// LoadErrors is returned when schema files cannot be loaded, it holds an
+// error for every file that failed
+type LoadErrors []*LoadError
+
+func (e LoadErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// A PatchError describes a patch operation that cannot be applied to a
+// document, or that would produce a document the schema does not allow
+type PatchError struct {
+	// Index is the position of the operation within a JSON Patch, it is 0 for
+	// a Merge Patch
+	Index int `json:"index"`
+	// Op is the operation, such as add or remove, or merge for a Merge Patch
+	Op string `json:"op"`
+	// Path is the JSON Pointer the operation applies to
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %s", e.Index, e.Op, e.Path, e.Message)
+}
+
+// PatchErrors is returned when a patch cannot be applied, it holds an error
+// for every operation that failed
+type PatchErrors []*PatchError
+
+func (e PatchErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,815 @@
+package jsontype
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// generateAttempts is how many values are generated for a property before
+// giving up on satisfying its rules
+const generateAttempts = 100
+
+// generateBudget is how many values are generated for a whole document before
+// giving up, so retries of nested objects cannot multiply with their depth
+const generateBudget = 10000
+
+// words are used to build strings that read like text
+var words = []string{
+	"amber", "bridge", "cedar", "delta", "ember", "falcon", "garnet", "harbor",
+	"indigo", "juniper", "kestrel", "lantern", "meadow", "nectar", "orchid",
+	"pepper", "quartz", "raven", "saffron", "timber", "umber", "velvet",
+	"willow", "yarrow", "zephyr",
+}
+
+// GenerateOptions configures a Generator
+type GenerateOptions struct {
+	// Seed seeds the random numbers used by the generator, generators with the
+	// same seed and schema generate the same documents
+	Seed int64
+	// MaxItems is the largest number of items generated for an array, list or
+	// object property without a max_length rule, it defaults to 3
+	MaxItems int
+	// MaxDepth is how deeply referenced schemas are nested before optional
+	// properties are left out, it defaults to 4
+	MaxDepth int
+	// FilePath is the path generated for the filepath format, which is only
+	// satisfied by a file that exists. Without it, properties with the
+	// filepath format cannot be generated.
+	FilePath string
+}
+
+// A Generator generates random documents that satisfy a schema, for tests,
+// documentation and load generation. Values respect the type and rules of
+// every property, including formats and regular expressions, and objects
+// that reference other schemas satisfy those schemas.
+type Generator struct {
+	schema  *Schema
+	options GenerateOptions
+	rand    *rand.Rand
+	// budget is how many more values can be generated for the document being
+	// generated
+	budget int
+}
+
+// NewGenerator creates a Generator for a schema, the schema must be loaded
+// into a SchemaManager if it extends or references other schemas
+func NewGenerator(s *Schema, options GenerateOptions) *Generator {
+	if options.MaxItems <= 0 {
+		options.MaxItems = 3
+	}
+	if options.MaxDepth <= 0 {
+		options.MaxDepth = 4
+	}
+	return &Generator{schema: s, options: options, rand: rand.New(rand.NewSource(options.Seed))}
+}
+
+// Generate returns a random JSON document that satisfies the schema
+func (g *Generator) Generate() ([]byte, error) {
+	document, err := g.GenerateValue()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// GenerateValue returns a random decoded document that satisfies the schema,
+// numbers within it are float64 values. The document is a
+// map[string]interface{} unless the schema has a root of another type.
+func (g *Generator) GenerateValue() (interface{}, error) {
+	g.budget = generateBudget
+	if !g.schema.hasObjectRoot() {
+		return g.value(g.schema, "", g.schema.root(), 0)
+	}
+
+	// the rules of an object root, such as max_length, may reject objects
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		object, err := g.object(g.schema, "", 0)
+		if err != nil {
+			return nil, err
+		}
+		var errs ValidationErrors
+		err = g.schema.validateProperty("", g.schema.root(), object, &errs)
+		if err != nil {
+			return nil, err
+		}
+		if len(errs) == 0 {
+			return object, nil
+		}
+	}
+	return nil, fmt.Errorf("could not generate a document that satisfies the rules of schema %s", g.schema.ref())
+}
+
+// NearMiss returns a random JSON document that fails validation with exactly
+// one error, and that error. The document is a valid document with a single
+// change, such as a rule violation, a value of the wrong type, a missing
+// required property or an undefined property.
+func (g *Generator) NearMiss() ([]byte, *ValidationError, error) {
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		document, err := g.GenerateValue()
+		if err != nil {
+			return nil, nil, err
+		}
+		var targets []nearMiss
+		if object, ok := document.(map[string]interface{}); ok && g.schema.hasObjectRoot() {
+			targets = g.targets(g.schema, "", object)
+		} else {
+			targets = g.valueTargets(g.schema, "", g.schema.root(), document, func(value interface{}) {
+				document = value
+			})
+		}
+		if len(targets) == 0 {
+			break
+		}
+		t := targets[g.rand.Intn(len(targets))]
+		if !t.mutate() {
+			continue
+		}
+
+		b, err := json.Marshal(document)
+		if err != nil {
+			return nil, nil, err
+		}
+		var errs ValidationErrors
+		if errors.As(g.schema.Validate(b), &errs) && len(errs) == 1 {
+			e := errs[0]
+			if e.Path == t.path && e.Kind == t.kind && e.Rule == t.rule {
+				return b, e, nil
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("could not generate a near miss for schema %s", g.schema.ref())
+}
+
+// object generates an object that satisfies a schema, depth is the number of
+// referenced schemas the object is nested within
+func (g *Generator) object(s *Schema, path string, depth int) (map[string]interface{}, error) {
+	properties, optionalProperties, err := s.resolveProperties()
+	if err != nil {
+		return nil, err
+	}
+	object := make(map[string]interface{}, len(properties))
+	for _, name := range sortedKeys(properties) {
+		value, err := g.value(s, joinPath(path, name), properties[name], depth)
+		if err != nil {
+			return nil, err
+		}
+		object[name] = value
+	}
+	for _, name := range sortedKeys(optionalProperties) {
+		if depth >= g.options.MaxDepth || g.rand.Intn(2) == 0 {
+			continue
+		}
+		value, err := g.value(s, joinPath(path, name), optionalProperties[name], depth)
+		if err != nil {
+			return nil, err
+		}
+		object[name] = value
+	}
+	return object, nil
+}
+
+// value generates values for a property until one satisfies its rules
+func (g *Generator) value(s *Schema, path string, p Property, depth int) (interface{}, error) {
+	for attempt := 0; attempt < generateAttempts && g.budget > 0; attempt++ {
+		g.budget--
+		value, err := g.candidate(s, path, p, depth)
+		if err != nil {
+			return nil, err
+		}
+		var errs ValidationErrors
+		err = s.validateProperty(path, p, value, &errs)
+		if err != nil {
+			return nil, err
+		}
+		if len(errs) == 0 {
+			return value, nil
+		}
+	}
+	if path == "" {
+		return nil, fmt.Errorf("could not generate a document that satisfies the rules of schema %s", s.ref())
+	}
+	return nil, fmt.Errorf("could not generate a value for %s that satisfies its rules", path)
+}
+
+// candidate generates a value of a property's type that is likely to satisfy
+// its rules
+func (g *Generator) candidate(s *Schema, path string, p Property, depth int) (interface{}, error) {
+	if options, ok := p.Rules["oneof"].([]interface{}); ok {
+		if option, ok := g.pick(options, p.Type); ok {
+			return option, nil
+		}
+	}
+
+	switch p.Type {
+	case "string":
+		return g.string(path, p)
+	case "number":
+		return g.number(p), nil
+	case "bool":
+		return g.rand.Intn(2) == 0, nil
+	case "object":
+		if p.Ref != "" {
+			if depth >= g.options.MaxDepth*2 {
+				return nil, fmt.Errorf("%s nests schema %s too deeply to generate", path, p.Ref)
+			}
+			ref, err := s.lookup(p.Ref)
+			if err != nil {
+				return nil, err
+			}
+			return g.object(ref, path, depth+1)
+		}
+		n, err := g.length(p, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		object := map[string]interface{}{}
+		for i := 0; i < n; i++ {
+			object[fmt.Sprintf("key%d", i+1)] = g.primitive(g.kind())
+		}
+		return object, nil
+	case "array", "list":
+		return g.items(s, path, p, depth)
+	}
+	return nil, fmt.Errorf("%s has unknown type %s", path, p.Type)
+}
+
+// items generates the items of an array or list property
+func (g *Generator) items(s *Schema, path string, p Property, depth int) (interface{}, error) {
+	n, err := g.length(p, 1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	allof, _ := p.Rules["allof"].([]interface{})
+	kind := g.kind()
+	items := []interface{}{}
+	for i := 0; i < n; i++ {
+		switch {
+		case len(allof) > 0:
+			item, _ := g.pick(allof, "")
+			items = append(items, item)
+		case p.Items != nil:
+			item, err := g.value(s, fmt.Sprintf("%s[%d]", path, i), *p.Items, depth)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		case p.Type == "list":
+			items = append(items, g.primitive(g.kind()))
+		default:
+			items = append(items, g.primitive(kind))
+		}
+	}
+
+	// anyof and contains need one matching item
+	for _, rule := range []string{"anyof", "contains"} {
+		required, ok := p.Rules[rule]
+		if !ok {
+			continue
+		}
+		if options, ok := required.([]interface{}); ok && rule == "anyof" {
+			required, _ = g.pick(options, "")
+		}
+		if len(items) == 0 {
+			items = append(items, required)
+		} else {
+			items[g.rand.Intn(len(items))] = required
+		}
+	}
+	return items, nil
+}
+
+// string generates a string for a property, from its regex or format rule
+// when it has one
+func (g *Generator) string(path string, p Property) (string, error) {
+	if pattern, ok := p.Rules["regex"].(string); ok {
+		re, err := syntax.Parse(pattern, syntax.Perl)
+		if err != nil {
+			return "", fmt.Errorf("regex rule %q is invalid: %w", pattern, err)
+		}
+		b := &strings.Builder{}
+		g.regex(re.Simplify(), b)
+		return b.String(), nil
+	}
+	if format, ok := p.Rules["format"].(string); ok {
+		return g.format(format)
+	}
+
+	prefix, _ := p.Rules["startswith"].(string)
+	contains, _ := p.Rules["contains"].(string)
+	n, err := g.length(p, 3)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	if _, ok := p.Rules["max_length"]; !ok {
+		n += 6 + g.rand.Intn(10)
+	}
+	text := prefix + contains + g.text(n)
+	if len(text) > n && n >= len(prefix)+len(contains) {
+		text = text[:n]
+	}
+	return text, nil
+}
+
+// text returns n characters of text built from words
+func (g *Generator) text(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	b := &strings.Builder{}
+	for b.Len() < n {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(g.word())
+	}
+	// text does not end with a space
+	text := []byte(b.String()[:n])
+	if n > 0 && text[n-1] == ' ' {
+		text[n-1] = 's'
+	}
+	return string(text)
+}
+
+// word returns a random word
+func (g *Generator) word() string {
+	return words[g.rand.Intn(len(words))]
+}
+
+// number generates a number between the min and max rules of a property,
+// whole numbers are generated when the range includes any
+func (g *Generator) number(p Property) float64 {
+	min, hasMin := toFloat(p.Rules["min"])
+	max, hasMax := toFloat(p.Rules["max"])
+	switch {
+	case !hasMin && !hasMax:
+		min, max = 0, 100
+	case !hasMax:
+		max = min + 100
+	case !hasMin:
+		min = max - 100
+	}
+	if low, high := math.Ceil(min), math.Floor(max); low <= high && high-low < 1<<53 {
+		return low + float64(g.rand.Int63n(int64(high-low)+1))
+	}
+	return min + g.rand.Float64()*(max-min)
+}
+
+// length returns a random length between the min_length and max_length rules
+// of a property, at least low unless max_length is lower
+func (g *Generator) length(p Property, low int) (int, error) {
+	max := low + g.options.MaxItems
+	if arg, ok := p.Rules["min_length"]; ok {
+		n, ok := lengthArg(arg)
+		if !ok {
+			return 0, fmt.Errorf("min_length %v is not a length that can be generated", arg)
+		}
+		low, max = n, n+g.options.MaxItems
+	}
+	if n, ok := lengthArg(p.Rules["max_length"]); ok {
+		max = n
+		if low > max {
+			low = max
+		}
+	}
+	if max <= low {
+		return low, nil
+	}
+	return low + g.rand.Intn(max-low+1), nil
+}
+
+// maxGenerateLength is the longest string, array or object generated
+const maxGenerateLength = 1 << 12
+
+// lengthArg returns the argument of a length rule as an int, and whether it
+// is a length that can be generated
+func lengthArg(arg interface{}) (int, bool) {
+	n, ok := toFloat(arg)
+	if !ok || math.IsNaN(n) || n < 0 || n > maxGenerateLength {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// kind returns a random primitive JSON type
+func (g *Generator) kind() string {
+	return []string{"string", "number", "bool"}[g.rand.Intn(3)]
+}
+
+// primitive generates a random value of a primitive JSON type
+func (g *Generator) primitive(kind string) interface{} {
+	switch kind {
+	case "number":
+		return float64(g.rand.Intn(100))
+	case "bool":
+		return g.rand.Intn(2) == 0
+	}
+	return g.word()
+}
+
+// pick returns a random option of a type, or of any type if kind is empty
+func (g *Generator) pick(options []interface{}, kind string) (interface{}, bool) {
+	matching := []interface{}{}
+	for _, option := range options {
+		if kind == "" || IsType(option, kind) {
+			matching = append(matching, option)
+		}
+	}
+	if len(matching) == 0 {
+		return nil, false
+	}
+	return matching[g.rand.Intn(len(matching))], true
+}
+
+// regex writes a random string matching a parsed regular expression
+func (g *Generator) regex(re *syntax.Regexp, b *strings.Builder) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 && g.rand.Intn(2) == 0 {
+				r = []rune(strings.ToUpper(string(r)))[0]
+			}
+			b.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		b.WriteRune(g.classRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune(g.classRune([]rune{'a', 'z', '0', '9'}))
+	case syntax.OpCapture:
+		g.regex(re.Sub[0], b)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			g.regex(sub, b)
+		}
+	case syntax.OpAlternate:
+		g.regex(re.Sub[g.rand.Intn(len(re.Sub))], b)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 {
+			max = min + g.options.MaxItems
+		}
+		for i, n := 0, min+g.rand.Intn(max-min+1); i < n; i++ {
+			g.regex(re.Sub[0], b)
+		}
+	}
+}
+
+// classRune returns a random rune from a character class given as ranges,
+// printable ASCII runes are preferred
+func (g *Generator) classRune(ranges []rune) rune {
+	printable := []rune{}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1] && r <= '~'; r++ {
+			if r >= ' ' {
+				printable = append(printable, r)
+			}
+		}
+	}
+	if len(printable) > 0 {
+		return printable[g.rand.Intn(len(printable))]
+	}
+	if len(ranges) < 2 {
+		return 'a'
+	}
+	return ranges[0] + rune(g.rand.Intn(int(ranges[1]-ranges[0])+1))
+}
+
+// format generates a string in one of the formats of the format rule
+func (g *Generator) format(format string) (string, error) {
+	hex := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = "0123456789abcdef"[g.rand.Intn(16)]
+		}
+		return string(b)
+	}
+	ipv4 := func() string {
+		return fmt.Sprintf("%d.%d.%d.%d", 10+g.rand.Intn(200), g.rand.Intn(256), g.rand.Intn(256), 1+g.rand.Intn(254))
+	}
+	ipv6 := func() string {
+		return fmt.Sprintf("2001:db8:%s:%s::%s", hex(4), hex(4), hex(4))
+	}
+
+	switch format {
+	case "alpha":
+		return g.word(), nil
+	case "alphanum":
+		return fmt.Sprintf("%s%d", g.word(), g.rand.Intn(1000)), nil
+	case "alphadash":
+		return g.word() + "-" + g.word() + "_" + g.word(), nil
+	case "email":
+		return g.word() + "." + g.word() + "@example.com", nil
+	case "base64":
+		b := make([]byte, 3+g.rand.Intn(12))
+		g.rand.Read(b)
+		return base64.StdEncoding.EncodeToString(b), nil
+	case "hexcolor":
+		return "#" + hex(6), nil
+	case "hexadecimal":
+		return hex(8), nil
+	case "json":
+		b, err := json.Marshal(map[string]interface{}{"name": g.word(), "count": g.rand.Intn(100)})
+		return string(b), err
+	case "rgbcolor":
+		return fmt.Sprintf("rgb(%d, %d, %d)", g.rand.Intn(256), g.rand.Intn(256), g.rand.Intn(256)), nil
+	case "url", "fullurl":
+		return fmt.Sprintf("https://%s.example.com/%s", g.word(), g.word()), nil
+	case "ip":
+		if g.rand.Intn(2) == 0 {
+			return ipv6(), nil
+		}
+		return ipv4(), nil
+	case "ipv4":
+		return ipv4(), nil
+	case "ipv6":
+		return ipv6(), nil
+	case "cidr":
+		if g.rand.Intn(2) == 0 {
+			return fmt.Sprintf("2001:db8:%s::/48", hex(4)), nil
+		}
+		return fmt.Sprintf("10.%d.%d.0/24", g.rand.Intn(256), g.rand.Intn(256)), nil
+	case "cidrv4":
+		return fmt.Sprintf("10.%d.%d.0/24", g.rand.Intn(256), g.rand.Intn(256)), nil
+	case "cidrv6":
+		return fmt.Sprintf("2001:db8:%s::/48", hex(4)), nil
+	case "uuid":
+		return fmt.Sprintf("%s-%s-4%s-%c%s-%s", hex(8), hex(4), hex(3), "89ab"[g.rand.Intn(4)], hex(3), hex(12)), nil
+	case "filepath":
+		if g.options.FilePath == "" {
+			return "", errors.New("format filepath cannot be generated without GenerateOptions.FilePath, it is only satisfied by a file that exists")
+		}
+		return g.options.FilePath, nil
+	}
+	return "", fmt.Errorf("unknown format %s", format)
+}
+
+// A nearMiss is a change to a valid document that should make it fail
+// validation with a single error
+type nearMiss struct {
+	path   string
+	kind   ErrorKind
+	rule   string
+	mutate func() bool
+}
+
+// targets returns every change that can be made to an object, and the values
+// within it, that should make it fail validation against a schema
+func (g *Generator) targets(s *Schema, path string, object map[string]interface{}) []nearMiss {
+	properties, optionalProperties, err := s.resolveProperties()
+	if err != nil {
+		return nil
+	}
+	targets := []nearMiss{}
+	for _, name := range sortedKeys(properties) {
+		name := name
+		if _, ok := object[name]; !ok {
+			continue
+		}
+		targets = append(targets, nearMiss{path: joinPath(path, name), kind: ErrorMissingProperty, mutate: func() bool {
+			delete(object, name)
+			return true
+		}})
+	}
+	for _, name := range sortedKeys(object) {
+		name := name
+		p, ok := properties[name]
+		if !ok {
+			p, ok = optionalProperties[name]
+		}
+		if ok {
+			targets = append(targets, g.valueTargets(s, joinPath(path, name), p, object[name], func(value interface{}) {
+				object[name] = value
+			})...)
+		}
+	}
+	if !s.AllowUndefinedProperties {
+		name := "undefined"
+		for _, ok := object[name]; ok; _, ok = object[name] {
+			name += "_"
+		}
+		targets = append(targets, nearMiss{path: joinPath(path, name), kind: ErrorUndefinedProperty, mutate: func() bool {
+			object[name] = g.word()
+			return true
+		}})
+	}
+	return targets
+}
+
+// valueTargets returns every change that can be made to a value, or the
+// values within it, that should make it fail validation against a property.
+// set replaces the value.
+func (g *Generator) valueTargets(s *Schema, path string, p Property, value interface{}, set func(interface{})) []nearMiss {
+	targets := []nearMiss{{path: path, kind: ErrorInvalidType, mutate: func() bool {
+		set(wrongType(p.Type))
+		return true
+	}}}
+	for _, rule := range sortedKeys(p.Rules) {
+		rule := rule
+		targets = append(targets, nearMiss{path: path, kind: ErrorRuleViolation, rule: rule, mutate: func() bool {
+			violation, ok := g.violate(path, p, rule, value)
+			if ok {
+				set(violation)
+			}
+			return ok
+		}})
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if p.Type == "object" && p.Ref != "" {
+			if ref, err := s.lookup(p.Ref); err == nil {
+				targets = append(targets, g.targets(ref, path, value)...)
+			}
+		}
+	case []interface{}:
+		if p.Type == "array" && p.Items != nil {
+			for i := range value {
+				i := i
+				targets = append(targets, g.valueTargets(s, fmt.Sprintf("%s[%d]", path, i), *p.Items, value[i], func(item interface{}) {
+					value[i] = item
+				})...)
+			}
+		}
+	}
+	return targets
+}
+
+// wrongType returns a value that is not of a type
+func wrongType(kind string) interface{} {
+	switch kind {
+	case "string":
+		return float64(1)
+	case "number", "bool":
+		return "1"
+	case "object":
+		return []interface{}{}
+	}
+	return map[string]interface{}{}
+}
+
+// violate returns a value of the property's type that violates a single rule
+// of the property and satisfies its other rules
+func (g *Generator) violate(path string, p Property, rule string, value interface{}) (interface{}, bool) {
+	arg := p.Rules[rule]
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		candidate, ok := g.violation(rule, arg, value)
+		if !ok {
+			return nil, false
+		}
+		if !IsType(candidate, p.Type) || Evaluate(path, rule, arg, candidate) == nil {
+			continue
+		}
+		satisfied := true
+		for other, otherArg := range p.Rules {
+			if other != rule && Evaluate(path, other, otherArg, candidate) != nil {
+				satisfied = false
+			}
+		}
+		if satisfied {
+			return candidate, true
+		}
+	}
+	return nil, false
+}
+
+// violation returns a change to a value that is likely to violate a rule
+func (g *Generator) violation(rule string, arg, value interface{}) (interface{}, bool) {
+	n, isLength := lengthArg(arg)
+	if (rule == "min_length" || rule == "max_length") && !isLength {
+		return nil, false
+	}
+	options, _ := arg.([]interface{})
+	switch value := value.(type) {
+	case float64:
+		limit, ok := toFloat(arg)
+		switch rule {
+		case "min":
+			return limit - 1 - float64(g.rand.Intn(10)), ok
+		case "max":
+			return limit + 1 + float64(g.rand.Intn(10)), ok
+		case "oneof":
+			max := 0.0
+			for _, option := range options {
+				if f, ok := toFloat(option); ok && f > max {
+					max = f
+				}
+			}
+			return max + 1 + float64(g.rand.Intn(10)), true
+		}
+
+	case bool:
+		return !value, rule == "oneof"
+
+	case string:
+		switch rule {
+		case "min_length":
+			if n < 1 {
+				return nil, false
+			}
+			return value[:g.rand.Intn(n)], len(value) >= n
+		case "max_length":
+			return value + g.text(n+1-len(value)+g.rand.Intn(5)), true
+		case "oneof", "regex":
+			return g.text(1 + g.rand.Intn(12)), true
+		case "contains":
+			sub, _ := arg.(string)
+			return strings.ReplaceAll(value, sub, ""), sub != ""
+		case "startswith":
+			prefix, _ := arg.(string)
+			return "~" + strings.TrimPrefix(value, prefix), true
+		case "format":
+			return []string{"not valid", "%zz", "!!", "/nonexistent/" + g.word()}[g.rand.Intn(4)], true
+		}
+
+	case []interface{}:
+		switch rule {
+		case "min_length":
+			if n < 1 || len(value) < n {
+				return nil, false
+			}
+			return append([]interface{}{}, value[:g.rand.Intn(n)]...), true
+		case "max_length":
+			if len(value) == 0 {
+				return nil, false
+			}
+			items := append([]interface{}{}, value...)
+			for len(items) <= n {
+				items = append(items, value[g.rand.Intn(len(value))])
+			}
+			return items, true
+		case "noneof":
+			option, ok := g.pick(options, "")
+			items := append([]interface{}{}, value...)
+			if len(items) == 0 {
+				return append(items, option), ok
+			}
+			items[g.rand.Intn(len(items))] = option
+			return items, ok
+		case "allof", "anyof", "contains":
+			if rule == "contains" {
+				options = []interface{}{arg}
+			}
+			items := []interface{}{}
+			for _, item := range value {
+				if rule == "allof" || !containsValue(options, item) {
+					items = append(items, item)
+				}
+			}
+			if rule == "allof" || len(items) == 0 {
+				kind := "string"
+				if len(value) > 0 {
+					kind = jsonKind(value[0])
+				}
+				items = append(items, g.primitive(kind))
+			}
+			return items, true
+		}
+
+	case map[string]interface{}:
+		switch rule {
+		case "min_length":
+			if n < 1 || len(value) < n {
+				return nil, false
+			}
+			object := map[string]interface{}{}
+			for _, key := range sortedKeys(value)[:g.rand.Intn(n)] {
+				object[key] = value[key]
+			}
+			return object, true
+		case "max_length":
+			object := map[string]interface{}{}
+			for key, item := range value {
+				object[key] = item
+			}
+			for i := 1; len(object) <= n; i++ {
+				object[fmt.Sprintf("key%d", len(value)+i)] = g.word()
+			}
+			return object, true
+		}
+	}
+	return nil, false
+}
+
+// containsValue reports whether a value equals any of the options
+func containsValue(options []interface{}, value interface{}) bool {
+	for _, option := range options {
+		if equal(option, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package jsontype_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+var generateSchemas = []string{
+	`{"type": "Address", "properties": {
+		"city": {"type": "string", "rules": {"min_length": 2, "max_length": 20}},
+		"zip": {"type": "string", "rules": {"regex": "^[A-Z]{2}[0-9]{1,2} ?[0-9][A-Z]{2}$"}}
+	}}`,
+	`{"type": "Customer", "properties": {
+		"id": {"type": "string", "rules": {"format": "uuid"}},
+		"email": {"type": "string", "rules": {"format": "email"}},
+		"sku": {"type": "string", "rules": {"startswith": "SKU-", "max_length": 12}},
+		"status": {"type": "string", "rules": {"oneof": ["active", "closed"]}},
+		"age": {"type": "number", "rules": {"min": 18, "max": 120}},
+		"score": {"type": "number", "rules": {"min": 0.25, "max": 0.75}},
+		"verified": {"type": "bool"},
+		"address": {"type": "object", "ref": "Address"},
+		"tags": {"type": "array", "items": {"type": "string", "rules": {"format": "alpha"}}, "rules": {"min_length": 1, "max_length": 4, "noneof": ["spam"]}},
+		"roles": {"type": "array", "rules": {"allof": ["admin", "editor", "viewer"], "anyof": ["admin"]}},
+		"history": {"type": "list", "rules": {"contains": "created"}},
+		"meta": {"type": "object", "rules": {"max_length": 2}}
+	}, "optional_properties": {
+		"color": {"type": "string", "rules": {"format": "hexcolor"}},
+		"network": {"type": "string", "rules": {"format": "cidrv6"}},
+		"homepage": {"type": "string", "rules": {"format": "fullurl"}},
+		"payload": {"type": "string", "rules": {"format": "json"}},
+		"secret": {"type": "string", "rules": {"format": "base64"}},
+		"rgb": {"type": "string", "rules": {"format": "rgbcolor"}},
+		"previous": {"type": "object", "ref": "Address"}
+	}}`,
+}
+
+// generate generates a document from a schema and validates it
+func generate(t *testing.T, s *jsontype.Schema, options jsontype.GenerateOptions) []byte {
+	t.Helper()
+	document, err := jsontype.NewGenerator(s, options).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(document); err != nil {
+		t.Fatalf("%s: %v", document, err)
+	}
+	return document
+}
+
+func TestGenerator(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, generateSchemas...), "customer")
+	for seed := int64(0); seed < 50; seed++ {
+		generate(t, s, jsontype.GenerateOptions{Seed: seed})
+	}
+}
+
+func TestGeneratorSeed(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, generateSchemas...), "customer")
+	a := generate(t, s, jsontype.GenerateOptions{Seed: 7})
+	b := generate(t, s, jsontype.GenerateOptions{Seed: 7})
+	if string(a) != string(b) {
+		t.Fatalf("expected the same documents but got %s and %s", a, b)
+	}
+}
+
+func TestGeneratorFormats(t *testing.T) {
+	for _, format := range []string{"alpha", "alphanum", "alphadash", "email", "base64", "hexcolor", "hexadecimal", "json", "rgbcolor", "url", "fullurl", "ip", "ipv4", "ipv6", "cidr", "cidrv4", "cidrv6", "uuid"} {
+		s := getSchema(t, loadSchemas(t, `{"type": "Format", "properties": {"value": {"type": "string", "rules": {"format": "`+format+`"}}}}`), "format")
+		for seed := int64(0); seed < 10; seed++ {
+			generate(t, s, jsontype.GenerateOptions{Seed: seed})
+		}
+	}
+}
+
+func TestGeneratorFilePath(t *testing.T) {
+	// test file paths are not generated without an existing file to name
+	s := getSchema(t, loadSchemas(t, `{"type": "Format", "properties": {"value": {"type": "string", "rules": {"format": "filepath"}}}}`), "format")
+	if _, err := jsontype.NewGenerator(s, jsontype.GenerateOptions{}).Generate(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test the configured file path is generated
+	generate(t, s, jsontype.GenerateOptions{FilePath: "generate_test.go"})
+
+	// test a file path that does not exist fails
+	if _, err := jsontype.NewGenerator(s, jsontype.GenerateOptions{FilePath: "missing.go"}).Generate(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestGeneratorBudget(t *testing.T) {
+	// test a deeply nested value that cannot be generated gives up quickly,
+	// rather than retrying every level of nesting
+	s := getSchema(t, loadSchemas(t,
+		`{"type": "D", "properties": {"n": {"type": "number", "rules": {"min": 10, "max": 1}}}}`,
+		`{"type": "C", "properties": {"d": {"type": "object", "ref": "D"}}}`,
+		`{"type": "B", "properties": {"c": {"type": "object", "ref": "C"}}}`,
+		`{"type": "A", "properties": {"b": {"type": "object", "ref": "B"}}}`,
+	), "a")
+	withTimeout(t, func() {
+		if _, err := jsontype.NewGenerator(s, jsontype.GenerateOptions{}).Generate(); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestGeneratorArrayRoot(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, append(generateSchemas, `{"type": "Addresses", "root": {"type": "array", "items": {"type": "object", "ref": "Address"}, "rules": {"min_length": 1}}}`)...), "addresses")
+	for seed := int64(0); seed < 10; seed++ {
+		generate(t, s, jsontype.GenerateOptions{Seed: seed})
+	}
+}
+
+func TestGeneratorUnsatisfiable(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, `{"type": "Broken", "properties": {"n": {"type": "number", "rules": {"min": 10, "max": 1}}}}`), "broken")
+	if _, err := jsontype.NewGenerator(s, jsontype.GenerateOptions{}).Generate(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestGeneratorNearMiss(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, generateSchemas...), "customer")
+	g := jsontype.NewGenerator(s, jsontype.GenerateOptions{Seed: 1})
+	kinds := map[jsontype.ErrorKind]bool{}
+	for i := 0; i < 100; i++ {
+		document, expected, err := g.NearMiss()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var errs jsontype.ValidationErrors
+		if err := s.Validate(document); !errors.As(err, &errs) || len(errs) != 1 || errs[0].Path != expected.Path || errs[0].Rule != expected.Rule {
+			t.Fatalf("%s: expected %v but got %v", document, expected, err)
+		}
+		kinds[expected.Kind] = true
+	}
+	if len(kinds) != 4 {
+		t.Fatalf("expected every kind of error but got %v", kinds)
+	}
+}
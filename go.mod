@@ -3,7 +3,8 @@ module github.com/apageadev/jsontype
 go 1.18
 
 require (
-	github.com/goccy/go-json v0.10.0
+	github.com/fsnotify/fsnotify v1.6.0
+	github.com/goccy/go-json v0.10.2
 	github.com/goccy/go-reflect v1.2.0
 	github.com/gookit/validate v1.4.5
 )
@@ -13,7 +14,6 @@ require (
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/dgraph-io/ristretto v0.1.1 // indirect
 	github.com/dustin/go-humanize v1.0.0 // indirect
-	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/golang/glog v1.0.0 // indirect
 	github.com/google/uuid v1.3.0 // indirect
 	github.com/gookit/filter v1.1.4 // indirect
@@ -0,0 +1,216 @@
+package jsontype
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// DefaultMaxBodySize is the largest request body the middleware reads when
+// MiddlewareOptions.MaxBodySize is not set
+const DefaultMaxBodySize = 1 << 20
+
+// A Route selects the schema that validates the bodies of matching requests
+type Route struct {
+	// Method is the request method, an empty method matches the methods with
+	// a body, POST, PUT and PATCH
+	Method string
+	// Path is a path.Match pattern for the request path, such as /orders or
+	// /orders/*
+	Path string
+	// ContentType is the media type of the body, an empty content type
+	// matches application/json and every media type with a +json suffix
+	ContentType string
+	// Schema names the schema, it may name a version such as order@^2
+	Schema string
+}
+
+// matches reports whether the route matches the request method and path
+func (route Route) matches(r *http.Request) bool {
+	if route.Method == "" && r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch {
+		return false
+	}
+	return matchRequest(route.Method, route.Path, r)
+}
+
+// matchRequest reports whether a request matches a method and path pattern,
+// an empty method matches every method
+func matchRequest(method, pattern string, r *http.Request) bool {
+	if method != "" && !strings.EqualFold(method, r.Method) {
+		return false
+	}
+	matched, err := path.Match(pattern, r.URL.Path)
+	return err == nil && matched
+}
+
+// accepts reports whether the route accepts a media type
+func (route Route) accepts(mediaType string) bool {
+	if route.ContentType == "" {
+		return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	}
+	return strings.EqualFold(route.ContentType, mediaType)
+}
+
+// A Problem is an RFC 7807 problem details response
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors lists every way in which the body does not satisfy the schema
+	Errors ValidationErrors `json:"errors,omitempty"`
+}
+
+// MiddlewareOptions configures the middleware returned by
+// SchemaManager.Middleware
+type MiddlewareOptions struct {
+	// Routes select the schema for each request, the first route that matches
+	// is used. Requests that match no route are not validated, and requests
+	// that only match routes for other content types are rejected.
+	Routes []Route
+
+	// MaxBodySize is the largest body in bytes that is read, larger bodies are
+	// rejected. It defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+
+	// ProblemType is the type URI of problem responses, it defaults to
+	// about:blank
+	ProblemType string
+
+	// WriteProblem writes the response for a rejected request, by default the
+	// problem is written as application/problem+json
+	WriteProblem func(w http.ResponseWriter, r *http.Request, problem *Problem)
+}
+
+// documentKey is the context key of a validated request body
+type documentKey struct{}
+
+// Document returns the validated body of a request passed on by the
+// middleware, when the body is an object. Numbers within the document are
+// json.Number values.
+func Document(ctx context.Context) (map[string]interface{}, bool) {
+	document, ok := ctx.Value(documentKey{}).(map[string]interface{})
+	return document, ok
+}
+
+// DocumentValue returns the validated body of a request passed on by the
+// middleware whatever its root, such as an array for a schema with an array
+// root
+func DocumentValue(ctx context.Context) (interface{}, bool) {
+	document := ctx.Value(documentKey{})
+	return document, document != nil
+}
+
+// Middleware returns net/http middleware that validates request bodies
+// against the schema of the route they match. Valid requests are passed to
+// the next handler with the parsed body available from Document, and the
+// body can still be read from the request. Invalid requests are rejected with
+// a problem response listing the validation errors.
+func (sm *SchemaManager) Middleware(options MiddlewareOptions) func(http.Handler) http.Handler {
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = DefaultMaxBodySize
+	}
+	if options.ProblemType == "" {
+		options.ProblemType = "about:blank"
+	}
+	if options.WriteProblem == nil {
+		options.WriteProblem = WriteProblem
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			problem := func(status int, detail string, errs ValidationErrors) {
+				options.WriteProblem(w, r, &Problem{
+					Type:     options.ProblemType,
+					Title:    http.StatusText(status),
+					Status:   status,
+					Detail:   detail,
+					Instance: r.URL.Path,
+					Errors:   errs,
+				})
+			}
+
+			route, matched, ok := options.route(r)
+			if !matched {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !ok {
+				problem(http.StatusUnsupportedMediaType, fmt.Sprintf("content type %q is not supported", r.Header.Get("Content-Type")), nil)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, options.MaxBodySize+1))
+			if err != nil {
+				problem(http.StatusBadRequest, "the request body could not be read", nil)
+				return
+			}
+			if int64(len(body)) > options.MaxBodySize {
+				problem(http.StatusRequestEntityTooLarge, fmt.Sprintf("the request body is larger than %d bytes", options.MaxBodySize), nil)
+				return
+			}
+
+			s, err := sm.GetSchema(route.Schema)
+			if err != nil {
+				problem(http.StatusInternalServerError, "", nil)
+				return
+			}
+			data, err := decodeDocument(body)
+			if err != nil {
+				problem(http.StatusBadRequest, "the request body is not valid JSON", nil)
+				return
+			}
+			err = s.validateDocument(data)
+			if errs, ok := err.(ValidationErrors); ok {
+				problem(http.StatusBadRequest, "the request body does not satisfy schema "+s.ref(), errs)
+				return
+			}
+			if err != nil {
+				problem(http.StatusInternalServerError, "", nil)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), documentKey{}, data)))
+		})
+	}
+}
+
+// route returns the route for a request, matched reports whether any route
+// matches the method and path and ok whether one accepts the content type
+func (options MiddlewareOptions) route(r *http.Request) (route Route, matched, ok bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+	for _, candidate := range options.Routes {
+		if !candidate.matches(r) {
+			continue
+		}
+		matched = true
+		if mediaType != "" && candidate.accepts(strings.ToLower(mediaType)) {
+			return candidate, true, true
+		}
+	}
+	return Route{}, matched, false
+}
+
+// WriteProblem writes a problem as an application/problem+json response
+func WriteProblem(w http.ResponseWriter, r *http.Request, problem *Problem) {
+	b, err := json.Marshal(problem)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	w.Write(b)
+}
@@ -0,0 +1,128 @@
+package jsontype_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+func newMiddlewareHandler(t *testing.T, options jsontype.MiddlewareOptions) http.Handler {
+	sm := loadSchemas(t,
+		`{"type": "Order", "properties": {"id": {"type": "string", "rules": {"max_length": 5}}, "total": {"type": "number"}}}`,
+	)
+	return sm.Middleware(options)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		document, ok := jsontype.Document(r.Context())
+		if ok {
+			w.Write([]byte(document["total"].(json.Number).String() + " " + string(body)))
+			return
+		}
+		w.Write([]byte("not validated"))
+	}))
+}
+
+func serve(handler http.Handler, method, path, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	handler := newMiddlewareHandler(t, jsontype.MiddlewareOptions{
+		Routes:      []jsontype.Route{{Method: "POST", Path: "/orders/*", Schema: "order"}},
+		MaxBodySize: 64,
+	})
+
+	// test a valid body is passed on with the parsed document
+	body := `{"id": "a1", "total": 9.99}`
+	w := serve(handler, "POST", "/orders/1", "application/json; charset=utf-8", body)
+	if w.Code != http.StatusOK || w.Body.String() != "9.99 "+body {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body)
+	}
+
+	// test requests that match no route are not validated
+	w = serve(handler, "GET", "/orders/1", "", "")
+	if w.Code != http.StatusOK || w.Body.String() != "not validated" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body)
+	}
+
+	// test invalid bodies are rejected with a problem
+	w = serve(handler, "POST", "/orders/1", "application/json", `{"id": "123456"}`)
+	problem := jsontype.Problem{}
+	err := json.Unmarshal(w.Body.Bytes(), &problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/problem+json" ||
+		problem.Type != "about:blank" || problem.Instance != "/orders/1" || len(problem.Errors) != 2 {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body)
+	}
+
+	for _, test := range []struct {
+		contentType, body string
+		status            int
+	}{
+		{"text/plain", body, http.StatusUnsupportedMediaType},
+		{"application/json", `{"id": `, http.StatusBadRequest},
+		{"application/json", `{"id": "a1", "total": 1, "padding": "` + strings.Repeat("x", 64) + `"}`, http.StatusRequestEntityTooLarge},
+	} {
+		w = serve(handler, "POST", "/orders/1", test.contentType, test.body)
+		if w.Code != test.status {
+			t.Fatalf("expected status %d but got %d %s", test.status, w.Code, w.Body)
+		}
+	}
+}
+
+func TestMiddlewareWriteProblem(t *testing.T) {
+	handler := newMiddlewareHandler(t, jsontype.MiddlewareOptions{
+		Routes:      []jsontype.Route{{Path: "/orders", ContentType: "application/vnd.order", Schema: "order"}},
+		ProblemType: "https://example.com/problems/invalid-order",
+		WriteProblem: func(w http.ResponseWriter, r *http.Request, problem *jsontype.Problem) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(problem.Type + " " + problem.Errors.Error()))
+		},
+	})
+
+	w := serve(handler, "PUT", "/orders", "application/vnd.order", `{"id": "a1"}`)
+	if w.Code != http.StatusUnprocessableEntity || w.Body.String() != "https://example.com/problems/invalid-order required property total is missing" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body)
+	}
+
+	// test a route without a method only matches methods with a body
+	w = serve(handler, "GET", "/orders", "", "")
+	if w.Code != http.StatusOK || w.Body.String() != "not validated" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body)
+	}
+}
+
+func TestMiddlewareArrayRoot(t *testing.T) {
+	sm := loadSchemas(t, `{"type": "Orders", "root": {"type": "array", "items": {"type": "string"}}}`)
+	handler := sm.Middleware(jsontype.MiddlewareOptions{
+		Routes: []jsontype.Route{{Path: "/orders", Schema: "orders"}},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, isObject := jsontype.Document(r.Context())
+		document, ok := jsontype.DocumentValue(r.Context())
+		if isObject || !ok {
+			t.Fatal("expected an array document")
+		}
+		w.Write([]byte(document.([]interface{})[1].(string)))
+	}))
+
+	w := serve(handler, "POST", "/orders", "application/json", `["a1", "a2"]`)
+	if w.Code != http.StatusOK || w.Body.String() != "a2" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body)
+	}
+	w = serve(handler, "POST", "/orders", "application/json", `[1]`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body)
+	}
+}
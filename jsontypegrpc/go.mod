@@ -0,0 +1,23 @@
+module github.com/apageadev/jsontype/jsontypegrpc
+
+go 1.18
+
+require (
+	github.com/apageadev/jsontype v0.0.0-20261019181559-d0c951e0d8e0
+	google.golang.org/genproto v0.0.0-20230306155012-7f2fa6fef1f4
+	google.golang.org/grpc v1.55.0
+	google.golang.org/protobuf v1.30.0
+)
+
+require (
+	github.com/fsnotify/fsnotify v1.6.0 // indirect
+	github.com/goccy/go-json v0.10.2 // indirect
+	github.com/goccy/go-reflect v1.2.0 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/gookit/filter v1.1.4 // indirect
+	github.com/gookit/goutil v0.5.15 // indirect
+	github.com/gookit/validate v1.4.5 // indirect
+	golang.org/x/net v0.8.0 // indirect
+	golang.org/x/sys v0.6.0 // indirect
+	golang.org/x/text v0.8.0 // indirect
+)
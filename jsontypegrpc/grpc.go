@@ -0,0 +1,178 @@
+// Package jsontypegrpc provides gRPC server interceptors that validate the
+// JSON mapping of request messages, or JSON payloads carried within them,
+// against jsontype schemas.
+package jsontypegrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/apageadev/jsontype"
+)
+
+// Options configures how the interceptors find the schema and payload of a
+// request message
+type Options struct {
+	// Methods maps full method names, such as /orders.Orders/Create, to the
+	// schema that validates their requests
+	Methods map[string]string
+
+	// SchemaField names a string field of request messages that holds the
+	// name of their schema, it is used for methods that are not in Methods
+	SchemaField string
+
+	// PayloadField names the field of request messages that holds the
+	// payload, a message such as google.protobuf.Struct that is validated as
+	// its JSON mapping, or a string or bytes field holding a JSON document.
+	// Without it the JSON mapping of the whole message is validated.
+	PayloadField string
+
+	// UseProtoNames maps message fields to JSON with their proto names rather
+	// than their lowerCamelCase JSON names
+	UseProtoNames bool
+}
+
+// UnaryServerInterceptor returns an interceptor that validates the requests
+// of unary methods. Invalid requests fail with an InvalidArgument status that
+// has a BadRequest detail with a field violation for every validation error.
+// Requests whose schema cannot be found from the options are not validated. A
+// schema named by Methods that is not loaded fails with FailedPrecondition,
+// and one named by the SchemaField of a request fails with InvalidArgument.
+func UnaryServerInterceptor(sm *jsontype.SchemaManager, options Options) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := options.validate(sm, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that validates every message
+// a streaming method receives, see UnaryServerInterceptor
+func StreamServerInterceptor(sm *jsontype.SchemaManager, options Options) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: ss, sm: sm, options: options, method: info.FullMethod})
+	}
+}
+
+// validatingStream validates the messages received from a stream
+type validatingStream struct {
+	grpc.ServerStream
+	sm      *jsontype.SchemaManager
+	options Options
+	method  string
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.options.validate(s.sm, s.method, m)
+}
+
+// validate validates a request message and returns a status error if it is
+// invalid
+func (options Options) validate(sm *jsontype.SchemaManager, method string, req interface{}) error {
+	message, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	m := message.ProtoReflect()
+
+	schemaType, configured := options.Methods[method]
+	if !configured && options.SchemaField != "" {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(options.SchemaField))
+		if fd != nil && fd.Kind() == protoreflect.StringKind {
+			schemaType = m.Get(fd).String()
+		}
+	}
+	if schemaType == "" {
+		return nil
+	}
+
+	// a schema configured for the method that is missing is a problem with
+	// the server, while one named by the client is a problem with the request
+	s, err := sm.GetSchema(schemaType)
+	if err != nil && configured {
+		return status.Errorf(codes.FailedPrecondition, "schema %s is not loaded", schemaType)
+	}
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%s names schema %s which is not loaded", options.SchemaField, schemaType)
+	}
+
+	payload, err := options.payload(m)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = s.Validate(payload)
+	if err == nil {
+		return nil
+	}
+
+	var errs jsontype.ValidationErrors
+	if !errors.As(err, &errs) {
+		if !json.Valid(payload) {
+			return status.Errorf(codes.InvalidArgument, "%s is not valid JSON", options.fieldName(""))
+		}
+		return status.Errorf(codes.Internal, "request could not be validated against schema %s: %v", schemaType, err)
+	}
+	badRequest := &errdetails.BadRequest{}
+	for _, e := range errs {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       options.fieldName(e.Path),
+			Description: e.Message,
+		})
+	}
+	st, detailsErr := status.New(codes.InvalidArgument, fmt.Sprintf("request does not satisfy schema %s: %v", schemaType, err)).WithDetails(badRequest)
+	if detailsErr != nil {
+		return status.Errorf(codes.InvalidArgument, "request does not satisfy schema %s: %v", schemaType, err)
+	}
+	return st.Err()
+}
+
+// payload returns the JSON document to validate from a request message
+func (options Options) payload(m protoreflect.Message) ([]byte, error) {
+	marshal := protojson.MarshalOptions{UseProtoNames: options.UseProtoNames}
+	if options.PayloadField == "" {
+		return marshal.Marshal(m.Interface())
+	}
+
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(options.PayloadField))
+	if fd == nil || fd.IsList() || fd.IsMap() {
+		return nil, fmt.Errorf("message %s has no payload field %s", m.Descriptor().FullName(), options.PayloadField)
+	}
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return []byte(m.Get(fd).String()), nil
+	case protoreflect.BytesKind:
+		return m.Get(fd).Bytes(), nil
+	case protoreflect.MessageKind:
+		if !m.Has(fd) {
+			return nil, fmt.Errorf("payload field %s is missing", options.PayloadField)
+		}
+		return marshal.Marshal(m.Get(fd).Message().Interface())
+	}
+	return nil, fmt.Errorf("payload field %s must be a message, string or bytes", options.PayloadField)
+}
+
+// fieldName returns the field of a request message that a validation error
+// path refers to
+func (options Options) fieldName(path string) string {
+	switch {
+	case options.PayloadField == "":
+		return path
+	case path == "":
+		return options.PayloadField
+	}
+	return options.PayloadField + "." + path
+}
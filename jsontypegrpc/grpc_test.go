@@ -0,0 +1,196 @@
+package jsontypegrpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/apipb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/apageadev/jsontype"
+	"github.com/apageadev/jsontype/jsontypegrpc"
+)
+
+// echoService is a service without generated code, its methods return the
+// request they receive
+var echoService = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Struct", Handler: unaryHandler("Struct", func() interface{} { return &structpb.Struct{} })},
+		{MethodName: "Mixin", Handler: unaryHandler("Mixin", func() interface{} { return &apipb.Mixin{} })},
+	},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Stream",
+		ClientStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			count := 0
+			for {
+				if err := stream.RecvMsg(&structpb.Struct{}); err != nil {
+					if err == io.EOF {
+						return stream.SendMsg(structpb.NewNumberValue(float64(count)))
+					}
+					return err
+				}
+				count++
+			}
+		},
+	}},
+}
+
+func unaryHandler(method string, newRequest func() interface{}) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := newRequest()
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Echo/" + method}
+		return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return req, nil
+		})
+	}
+}
+
+func dial(t *testing.T, options jsontypegrpc.Options) *grpc.ClientConn {
+	sm := jsontype.NewSchemaManager()
+	for _, def := range []string{
+		`{"type": "Order", "properties": {"id": {"type": "string", "rules": {"max_length": 5}}, "total": {"type": "number"}}}`,
+		`{"type": "Mixin", "properties": {"name": {"type": "string"}, "root": {"type": "string"}}}`,
+		`{"type": "Invoice", "properties": {"order": {"type": "object", "ref": "Missing"}}}`,
+	} {
+		if err := sm.LoadSchema([]byte(def)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(jsontypegrpc.UnaryServerInterceptor(sm, options)),
+		grpc.StreamInterceptor(jsontypegrpc.StreamServerInterceptor(sm, options)),
+	)
+	server.RegisterService(&echoService, nil)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func order(t *testing.T, fields map[string]interface{}) *structpb.Struct {
+	s, err := structpb.NewStruct(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	conn := dial(t, jsontypegrpc.Options{Methods: map[string]string{"/test.Echo/Struct": "order"}})
+	ctx := context.Background()
+
+	err := conn.Invoke(ctx, "/test.Echo/Struct", order(t, map[string]interface{}{"id": "a1", "total": 10}), &structpb.Struct{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = conn.Invoke(ctx, "/test.Echo/Struct", order(t, map[string]interface{}{"id": "123456"}), &structpb.Struct{})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument || len(st.Details()) != 1 {
+		t.Fatalf("unexpected status %v", st)
+	}
+	badRequest := st.Details()[0].(*errdetails.BadRequest)
+	violations := map[string]string{}
+	for _, v := range badRequest.FieldViolations {
+		violations[v.Field] = v.Description
+	}
+	if len(violations) != 2 || violations["total"] != "required property total is missing" || violations["id"] == "" {
+		t.Fatalf("unexpected violations %v", badRequest.FieldViolations)
+	}
+
+	// test methods without a schema are not validated
+	err = conn.Invoke(ctx, "/test.Echo/Mixin", &apipb.Mixin{Name: "anything"}, &apipb.Mixin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnaryServerInterceptorPayloadField(t *testing.T) {
+	conn := dial(t, jsontypegrpc.Options{SchemaField: "name", PayloadField: "root"})
+	ctx := context.Background()
+
+	err := conn.Invoke(ctx, "/test.Echo/Mixin", &apipb.Mixin{Name: "order", Root: `{"id": "a1", "total": 1}`}, &apipb.Mixin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = conn.Invoke(ctx, "/test.Echo/Mixin", &apipb.Mixin{Name: "order", Root: `{"id": "a1", "total": "1"}`}, &apipb.Mixin{})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument || st.Details()[0].(*errdetails.BadRequest).FieldViolations[0].Field != "root.total" {
+		t.Fatalf("unexpected status %v", st)
+	}
+
+	for _, test := range []struct {
+		request *apipb.Mixin
+		code    codes.Code
+	}{
+		{&apipb.Mixin{Name: "order", Root: `{"id": `}, codes.InvalidArgument},
+		{&apipb.Mixin{Name: "customer", Root: `{}`}, codes.InvalidArgument},
+		{&apipb.Mixin{Name: "invoice", Root: `{"order": {}}`}, codes.Internal},
+	} {
+		err = conn.Invoke(ctx, "/test.Echo/Mixin", test.request, &apipb.Mixin{})
+		if status.Code(err) != test.code {
+			t.Fatalf("expected %s but got %v", test.code, err)
+		}
+	}
+}
+
+func TestUnaryServerInterceptorMissingSchema(t *testing.T) {
+	conn := dial(t, jsontypegrpc.Options{Methods: map[string]string{"/test.Echo/Mixin": "customer"}})
+
+	// test a schema configured for a method that is not loaded
+	err := conn.Invoke(context.Background(), "/test.Echo/Mixin", &apipb.Mixin{Name: "order"}, &apipb.Mixin{})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition but got %v", err)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	conn := dial(t, jsontypegrpc.Options{Methods: map[string]string{"/test.Echo/Stream": "order"}})
+
+	send := func(orders ...*structpb.Struct) error {
+		stream, err := conn.NewStream(context.Background(), &echoService.Streams[0], "/test.Echo/Stream")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, o := range orders {
+			if err := stream.SendMsg(o); err != nil {
+				break
+			}
+		}
+		stream.CloseSend()
+		return stream.RecvMsg(&structpb.Value{})
+	}
+
+	valid := order(t, map[string]interface{}{"id": "a1", "total": 1})
+	if err := send(valid, valid); status.Code(err) == codes.InvalidArgument {
+		t.Fatal(err)
+	}
+	if err := send(valid, order(t, map[string]interface{}{"id": "a1"})); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument but got %v", err)
+	}
+}
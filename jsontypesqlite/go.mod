@@ -0,0 +1,19 @@
+module github.com/apageadev/jsontype/jsontypesqlite
+
+go 1.18
+
+require (
+	github.com/apageadev/jsontype v0.0.0-20261019181559-d0c951e0d8e0
+	github.com/mattn/go-sqlite3 v1.14.17
+)
+
+require (
+	github.com/fsnotify/fsnotify v1.6.0 // indirect
+	github.com/goccy/go-json v0.10.2 // indirect
+	github.com/goccy/go-reflect v1.2.0 // indirect
+	github.com/gookit/filter v1.1.4 // indirect
+	github.com/gookit/goutil v0.5.15 // indirect
+	github.com/gookit/validate v1.4.5 // indirect
+	golang.org/x/sys v0.3.0 // indirect
+	golang.org/x/text v0.5.0 // indirect
+)
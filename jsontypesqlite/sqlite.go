@@ -0,0 +1,147 @@
+// Package jsontypesqlite provides a jsontype.Store that keeps schemas in a
+// SQLite database, which several processes may share.
+package jsontypesqlite
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strconv"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/apageadev/jsontype"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS jsontype_schemas (
+	key        TEXT PRIMARY KEY,
+	definition BLOB NOT NULL
+);
+CREATE TABLE IF NOT EXISTS jsontype_revision (
+	id       INTEGER PRIMARY KEY CHECK (id = 0),
+	revision INTEGER NOT NULL
+);
+INSERT OR IGNORE INTO jsontype_revision (id, revision) VALUES (0, 0);
+`
+
+// Store is a jsontype.RevisionStore backed by a SQLite database. Every put
+// and delete increments the revision of the database, so processes that
+// share it notice each other's changes.
+type Store struct {
+	db *sql.DB
+	// owned reports whether the database was opened by Open
+	owned bool
+}
+
+// Open opens the SQLite database file at path, creating it if it does not
+// exist. The database uses write-ahead logging, and waits for locks held by
+// other processes.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", "file:"+url.PathEscape(path)+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.owned = true
+	return s, nil
+}
+
+// New creates a Store within an open SQLite database, creating its tables if
+// they do not exist
+func New(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database if it was opened by Open
+func (s *Store) Close() error {
+	if !s.owned {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *Store) List(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT key FROM jsontype_schemas ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	var def []byte
+	err := s.db.QueryRowContext(ctx, `SELECT definition FROM jsontype_schemas WHERE key = ?`, key).Scan(&def)
+	if err == sql.ErrNoRows {
+		return nil, jsontype.ErrSchemaNotFound
+	}
+	return def, err
+}
+
+func (s *Store) Put(ctx context.Context, key string, definition []byte) error {
+	return s.update(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO jsontype_schemas (key, definition) VALUES (?, ?)
+			ON CONFLICT (key) DO UPDATE SET definition = excluded.definition`, key, definition)
+		return err
+	})
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	return s.update(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM jsontype_schemas WHERE key = ?`, key)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil || n == 0 {
+			if err == nil {
+				err = jsontype.ErrSchemaNotFound
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// Revision returns the number of changes made to the database
+func (s *Store) Revision(ctx context.Context) (string, error) {
+	var revision int64
+	err := s.db.QueryRowContext(ctx, `SELECT revision FROM jsontype_revision WHERE id = 0`).Scan(&revision)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(revision, 10), nil
+}
+
+// update makes a change and increments the revision within a transaction
+func (s *Store) update(ctx context.Context, change func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	err = change(tx)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `UPDATE jsontype_revision SET revision = revision + 1 WHERE id = 0`)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,93 @@
+package jsontypesqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apageadev/jsontype"
+	"github.com/apageadev/jsontype/jsontypesqlite"
+)
+
+func open(t *testing.T, path string) *jsontypesqlite.Store {
+	store, err := jsontypesqlite.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := open(t, filepath.Join(t.TempDir(), "schemas.db"))
+
+	if err := store.Put(ctx, "order@1.0.0", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "order@1.0.0", []byte(`{"type": "Order"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if def, err := store.Get(ctx, "order@1.0.0"); err != nil || string(def) != `{"type": "Order"}` {
+		t.Fatalf("unexpected definition %s %v", def, err)
+	}
+	if keys, err := store.List(ctx); err != nil || len(keys) != 1 {
+		t.Fatalf("unexpected keys %v %v", keys, err)
+	}
+	if revision, err := store.Revision(ctx); err != nil || revision != "2" {
+		t.Fatalf("unexpected revision %s %v", revision, err)
+	}
+	if err := store.Delete(ctx, "order@1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "order@1.0.0"); err != jsontype.ErrSchemaNotFound {
+		t.Fatalf("expected ErrSchemaNotFound but got %v", err)
+	}
+	if _, err := store.Get(ctx, "order@1.0.0"); err != jsontype.ErrSchemaNotFound {
+		t.Fatalf("expected ErrSchemaNotFound but got %v", err)
+	}
+}
+
+func TestSharedStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schemas.db")
+
+	// test two schema managers sharing a database see each other's changes
+	producer := jsontype.NewSchemaManager()
+	ps, err := producer.UseStore(open(t, path), jsontype.StoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ps.Close()
+	consumer := jsontype.NewSchemaManager()
+	cs, err := consumer.UseStore(open(t, path), jsontype.StoreOptions{PollInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	events := make(chan jsontype.WatchEvent, 16)
+	cs.Subscribe(func(event jsontype.WatchEvent) {
+		events <- event
+	})
+
+	err = producer.LoadSchema([]byte(`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := consumer.GetSchema("order@1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = producer.DeleteSchema("order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(5 * time.Second)
+	for consumer.SchemaCount() > 0 {
+		select {
+		case <-events:
+		case <-timeout:
+			t.Fatal("timed out waiting for the schema to be deleted")
+		}
+	}
+}
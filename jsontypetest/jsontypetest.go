@@ -0,0 +1,198 @@
+// Package jsontypetest provides property-based and fuzz tests for jsontype
+// schemas, for use with the testing package.
+//
+//	func TestOrderSchema(t *testing.T) {
+//		jsontypetest.Check(t, order, jsontypetest.Options{})
+//	}
+//
+//	func FuzzOrderSchema(f *testing.F) {
+//		jsontypetest.FuzzValidate(f, order, jsontypetest.Options{})
+//	}
+package jsontypetest
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+// Options configures the documents generated for a schema
+type Options struct {
+	// Generate configures the generator, its Seed makes failures reproducible
+	Generate jsontype.GenerateOptions
+	// Documents is how many valid documents, and how many near misses, are
+	// generated. It defaults to 100.
+	Documents int
+}
+
+// generator returns a generator for a schema
+func (options Options) generator(s *jsontype.Schema) *jsontype.Generator {
+	return jsontype.NewGenerator(s, options.Generate)
+}
+
+// documents returns the number of documents to generate
+func (options Options) documents() int {
+	if options.Documents <= 0 {
+		return 100
+	}
+	return options.Documents
+}
+
+// Check generates documents for a schema and fails the test unless every
+// valid document passes Schema.Validate, and every near miss fails it with
+// the single ValidationError the generator expected
+func Check(t testing.TB, s *jsontype.Schema, options Options) {
+	t.Helper()
+	g := options.generator(s)
+	for i := 0; i < options.documents(); i++ {
+		document, err := g.Generate()
+		if err != nil {
+			t.Fatalf("generating a document: %v", err)
+		}
+		if err := s.Validate(document); err != nil {
+			t.Errorf("generated document %s is invalid: %v", document, err)
+		}
+	}
+
+	for i := 0; i < options.documents(); i++ {
+		document, expected, err := g.NearMiss()
+		if err != nil {
+			t.Fatalf("generating a near miss: %v", err)
+		}
+		err = s.Validate(document)
+		var errs jsontype.ValidationErrors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Kind != expected.Kind || errs[0].Path != expected.Path || errs[0].Rule != expected.Rule {
+			t.Errorf("near miss %s: expected %s error at %q but got %v", document, expected.Kind, expected.Path, err)
+		}
+	}
+}
+
+// FuzzValidate fuzzes Schema.Validate, using generated documents and near
+// misses as the seed corpus. The fuzz test fails if validation panics, or if
+// a document encoding/json can decode fails with an error other than
+// ValidationErrors. JSON such as 1E700 cannot be decoded, as its number is out
+// of range.
+func FuzzValidate(f *testing.F, s *jsontype.Schema, options Options) {
+	g := options.generator(s)
+	for i := 0; i < options.documents(); i++ {
+		if document, err := g.Generate(); err == nil {
+			f.Add(document)
+		}
+		if document, _, err := g.NearMiss(); err == nil {
+			f.Add(document)
+		}
+	}
+	for _, document := range []string{`{}`, `[]`, `"x"`, `42`, `null`, `{"a": [1, "b", null, {}]}`} {
+		f.Add([]byte(document))
+	}
+
+	f.Fuzz(func(t *testing.T, document []byte) {
+		err := s.Validate(document)
+		var errs jsontype.ValidationErrors
+		var v interface{}
+		if err != nil && json.Unmarshal(document, &v) == nil && !errors.As(err, &errs) {
+			t.Fatalf("document %s failed with %v", document, err)
+		}
+	})
+}
+
+// seedSchemas are the seed corpus of FuzzLoadSchema
+var seedSchemas = []string{
+	`{"type": "Empty", "properties": {}}`,
+	`{"type": "Person", "version": "1.0.0", "properties": {"name": {"type": "string", "rules": {"min_length": 1, "max_length": 10}}, "age": {"type": "number", "rules": {"min": 0}}}, "optional_properties": {"email": {"type": "string", "rules": {"format": "email"}}}}`,
+	`{"type": "Tags", "properties": {"tags": {"type": "array", "items": {"type": "string"}, "rules": {"noneof": ["x"], "contains": "a"}}, "any": {"type": "list"}}, "allow_undefined_properties": true}`,
+	`{"type": "Code", "properties": {"code": {"type": "string", "rules": {"regex": "^[A-Z]{3}$", "startswith": "A"}}, "kind": {"type": "string", "rules": {"oneof": ["a", "b"]}}}}`,
+	`{"type": "Node", "properties": {"value": {"type": "number"}}, "optional_properties": {"next": {"type": "object", "ref": "Node"}}}`,
+	`{"type": "Child", "extends": "Person", "properties": {}}`,
+	`{"type": "People", "root": {"type": "array", "items": {"type": "object", "ref": "Person"}, "rules": {"max_length": 3}}}`,
+	`{"type": "Price", "root": {"type": "number", "rules": {"min": 0}}}`,
+	`{"type": "Bad", "version": "one", "properties": {"x": {"type": "integer"}}}`,
+	`{"properties": {}}`,
+	`[]`,
+}
+
+// seedDocuments are validated against every schema loaded by FuzzLoadSchema
+var seedDocuments = []string{`{}`, `[]`, `"x"`, `42`, `null`, `{"name": "Ada", "age": 36, "tags": ["a"]}`}
+
+// FuzzLoadSchema fuzzes SchemaManager.LoadSchema with schema definitions, the
+// seed corpus is a set of example schemas and any given seeds. Every schema
+// that loads is used to validate a few documents and to generate documents,
+// and the fuzz test fails if anything panics or a generated document is
+// invalid.
+func FuzzLoadSchema(f *testing.F, seeds ...[]byte) {
+	for _, def := range seedSchemas {
+		f.Add([]byte(def))
+	}
+	for _, def := range seeds {
+		f.Add(def)
+	}
+
+	f.Fuzz(func(t *testing.T, def []byte) {
+		sm := jsontype.NewSchemaManager()
+		if err := sm.LoadSchema([]byte(seedSchemas[1])); err != nil {
+			t.Fatal(err)
+		}
+		schema := &jsontype.Schema{}
+		if sm.LoadSchema(def) != nil || json.Unmarshal(def, schema) != nil {
+			return
+		}
+		s, err := sm.GetSchema(schema.Type + "@" + schema.Version)
+		if err != nil {
+			s, err = sm.GetSchema(schema.Type)
+		}
+		if err != nil {
+			t.Fatalf("loaded schema %s not found: %v", schema.Type, err)
+		}
+
+		for _, document := range seedDocuments {
+			s.Validate([]byte(document))
+		}
+		document, err := jsontype.NewGenerator(s, jsontype.GenerateOptions{MaxItems: 2, MaxDepth: 2}).Generate()
+		if err != nil {
+			return
+		}
+		if err := s.Validate(document); err != nil {
+			t.Fatalf("generated document %s is invalid: %v", document, err)
+		}
+	})
+}
+
+// FuzzEvaluate fuzzes Evaluate with every rule, and arbitrary rule arguments
+// and values given as JSON. The fuzz test fails if a rule panics.
+func FuzzEvaluate(f *testing.F) {
+	for _, seed := range [][3]string{
+		{"min", `1`, `2`},
+		{"max", `1`, `"2"`},
+		{"min_length", `2`, `"abc"`},
+		{"max_length", `"2"`, `[1, 2, 3]`},
+		{"oneof", `["a", 1]`, `null`},
+		{"noneof", `["a"]`, `null`},
+		{"allof", `["a"]`, `["a", "b"]`},
+		{"anyof", `null`, `["a"]`},
+		{"regex", `"^a+$"`, `"aaa"`},
+		{"regex", `"("`, `1`},
+		{"contains", `"a"`, `{"a": 1}`},
+		{"startswith", `"a"`, `null`},
+		{"format", `"email"`, `"a@example.com"`},
+		{"format", `"uuid"`, `null`},
+		{"unknown", `null`, `null`},
+	} {
+		f.Add(seed[0], []byte(seed[1]), []byte(seed[2]))
+	}
+
+	f.Fuzz(func(t *testing.T, rule string, arg, value []byte) {
+		jsontype.Evaluate("value", rule, decode(arg), decode(value))
+	})
+}
+
+// decode decodes a JSON value, or returns the input as a string if it is not
+// JSON
+func decode(b []byte) interface{} {
+	var v interface{}
+	if json.Unmarshal(b, &v) != nil {
+		return string(b)
+	}
+	return v
+}
@@ -0,0 +1,47 @@
+package jsontypetest_test
+
+import (
+	"testing"
+
+	"github.com/apageadev/jsontype"
+	"github.com/apageadev/jsontype/jsontypetest"
+)
+
+func loadSchema(t testing.TB) *jsontype.Schema {
+	sm := jsontype.NewSchemaManager()
+	for _, def := range []string{
+		`{"type": "Address", "properties": {"city": {"type": "string", "rules": {"min_length": 2}}}, "optional_properties": {"zip": {"type": "string", "rules": {"regex": "^[0-9]{5}$"}}}}`,
+		`{"type": "Order", "properties": {
+			"id": {"type": "string", "rules": {"format": "uuid"}},
+			"total": {"type": "number", "rules": {"min": 0, "max": 10000}},
+			"status": {"type": "string", "rules": {"oneof": ["open", "paid"]}},
+			"address": {"type": "object", "ref": "Address"},
+			"lines": {"type": "array", "items": {"type": "string"}, "rules": {"min_length": 1, "max_length": 5}}
+		}, "optional_properties": {"note": {"type": "string", "rules": {"max_length": 20}}}}`,
+	} {
+		if err := sm.LoadSchema([]byte(def)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := sm.GetSchema("order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestCheck(t *testing.T) {
+	jsontypetest.Check(t, loadSchema(t), jsontypetest.Options{Generate: jsontype.GenerateOptions{Seed: 1}})
+}
+
+func FuzzValidate(f *testing.F) {
+	jsontypetest.FuzzValidate(f, loadSchema(f), jsontypetest.Options{Documents: 10})
+}
+
+func FuzzLoadSchema(f *testing.F) {
+	jsontypetest.FuzzLoadSchema(f)
+}
+
+func FuzzEvaluate(f *testing.F) {
+	jsontypetest.FuzzEvaluate(f)
+}
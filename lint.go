@@ -0,0 +1,738 @@
+package jsontype
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity is the severity of a lint Diagnostic
+type Severity string
+
+const (
+	// SeverityError is reported for problems that make a schema fail to load,
+	// or behave differently to how it reads
+	SeverityError Severity = "error"
+	// SeverityWarning is reported for definitions that have no effect
+	SeverityWarning Severity = "warning"
+)
+
+// A Diagnostic is a problem found in a schema file by LintSchemas
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+
+	// Fix is set when the problem can be fixed mechanically
+	Fix *Fix `json:"fix,omitempty"`
+}
+
+// String formats the diagnostic as file:line:column: severity: message
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// A Fix replaces Length bytes at Offset within a schema file with Replacement.
+// Fixes only touch the offending token, so the rest of the file keeps its
+// formatting.
+type Fix struct {
+	Offset      int    `json:"offset"`
+	Length      int    `json:"length"`
+	Replacement string `json:"replacement"`
+}
+
+// schemaFields and propertyFields are the fields of a schema and property
+// definition, as they are named in JSON
+var (
+	schemaFields   = []string{"type", "version", "description", "extends", "properties", "optional_properties", "allow_undefined_properties", "root", "messages"}
+	propertyFields = []string{"type", "description", "rules", "ref", "items", "messages"}
+)
+
+// propertyTypes are the supported property types, and propertyTypeAliases are
+// names commonly used for them by other schema languages
+var (
+	propertyTypes       = []string{"number", "string", "list", "array", "bool", "object"}
+	propertyTypeAliases = map[string]string{
+		"boolean": "bool",
+		"integer": "number",
+		"int":     "number",
+		"float":   "number",
+		"double":  "number",
+		"map":     "object",
+	}
+)
+
+// LintSchemas checks schema files for problems that LoadSchema either rejects
+// or silently accepts, such as unknown rules and formats, rule arguments of
+// the wrong type, rules that do not apply to a property's type, properties
+// that are both required and optional, broken extends chains and references
+// to schemas that are not defined in any of the files. files maps file names
+// to their contents, diagnostics are returned sorted by file and position.
+func LintSchemas(files map[string][]byte) []Diagnostic {
+	l := &linter{schemas: make(map[string]*lintSchema)}
+	for _, name := range sortedKeys(files) {
+		l.lintFile(&lintFile{name: name, src: files[name]})
+	}
+	l.lintReferences()
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.diagnostics
+}
+
+// ApplyFixes applies the fixes of diagnostics to the contents of a single
+// schema file, diagnostics must only be those reported for that file. Fixes
+// that overlap an already applied fix are skipped.
+func ApplyFixes(def []byte, diagnostics []Diagnostic) []byte {
+	fixes := []*Fix{}
+	for _, d := range diagnostics {
+		if d.Fix != nil {
+			fixes = append(fixes, d.Fix)
+		}
+	}
+	sort.SliceStable(fixes, func(i, j int) bool {
+		return fixes[i].Offset > fixes[j].Offset
+	})
+
+	fixed := append([]byte{}, def...)
+	end := len(fixed)
+	for _, fix := range fixes {
+		if fix.Offset < 0 || fix.Offset+fix.Length > end {
+			continue
+		}
+		tail := append([]byte(fix.Replacement), fixed[fix.Offset+fix.Length:]...)
+		fixed = append(fixed[:fix.Offset], tail...)
+		end = fix.Offset
+	}
+	return fixed
+}
+
+// linter collects diagnostics, and the schemas defined by every file so
+// extends chains and references can be checked across files
+type linter struct {
+	diagnostics []Diagnostic
+	schemas     map[string]*lintSchema
+	order       []*lintSchema
+}
+
+// lintFile is a schema file being linted
+type lintFile struct {
+	name string
+	src  []byte
+}
+
+// lintSchema is a schema defined by a file, with the positions of the values
+// that name other schemas
+type lintSchema struct {
+	file        *lintFile
+	schemaType  string
+	version     string
+	extends     string
+	extendsNode *jsonNode
+	refs        []lintRef
+}
+
+// lintRef is a property that references another schema
+type lintRef struct {
+	path string
+	node *jsonNode
+}
+
+// report adds a diagnostic for the given offset within a file
+func (l *linter) report(f *lintFile, offset int, severity Severity, fix *Fix, format string, args ...interface{}) {
+	line, column := position(f.src, offset)
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		File:     f.name,
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		Fix:      fix,
+	})
+}
+
+func (l *linter) lintFile(f *lintFile) {
+	root, err := parseNode(f.src)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		var trailingErr *trailingDataError
+		offset := len(f.src)
+		switch {
+		case errors.As(err, &syntaxErr):
+			offset = int(syntaxErr.Offset)
+		case errors.As(err, &trailingErr):
+			offset = trailingErr.offset
+		}
+		l.report(f, offset, SeverityError, nil, "%v", err)
+		return
+	}
+	if root.kind != "object" {
+		l.report(f, root.offset, SeverityError, nil, "schema must be an object but got %s", root.kind)
+		return
+	}
+
+	s := &lintSchema{file: f}
+	l.lintFields(f, root, "schema", schemaFields)
+
+	typeNode := root.member("type")
+	switch {
+	case typeNode == nil:
+		l.report(f, root.offset, SeverityError, nil, "schema is missing its type")
+	case typeNode.kind != "string" || typeNode.value == "":
+		l.report(f, typeNode.offset, SeverityError, nil, "schema type must be a non-empty string")
+	default:
+		s.schemaType = typeNode.value.(string)
+	}
+
+	if n := root.member("version"); n != nil {
+		if n.kind != "string" {
+			l.report(f, n.offset, SeverityError, nil, "version must be a string but got %s", n.kind)
+		} else if _, err := parseVersion(n.value.(string)); err != nil {
+			l.report(f, n.offset, SeverityError, nil, "schema has an %v", err)
+		} else {
+			s.version = n.value.(string)
+		}
+	}
+
+	if s.schemaType != "" {
+		key := (&Schema{Type: s.schemaType, Version: s.version}).key()
+		if first, ok := l.schemas[key]; ok {
+			l.report(f, typeNode.offset, SeverityError, nil, "schema %s is already defined in %s", s.ref(), first.file.name)
+		} else {
+			l.schemas[key] = s
+			l.order = append(l.order, s)
+		}
+	}
+
+	if n := root.member("description"); n != nil && n.kind != "string" {
+		l.report(f, n.offset, SeverityError, nil, "description must be a string but got %s", n.kind)
+	}
+	if n := root.member("allow_undefined_properties"); n != nil && n.kind != "bool" {
+		l.report(f, n.offset, SeverityError, nil, "allow_undefined_properties must be a bool but got %s", n.kind)
+	}
+	if n := root.member("messages"); n != nil {
+		l.lintMessages(f, n, "schema")
+	}
+	if n := root.member("extends"); n != nil {
+		if n.kind == "string" {
+			s.extends = n.value.(string)
+			s.extendsNode = n
+		} else {
+			l.report(f, n.offset, SeverityError, nil, "extends must be a string but got %s", n.kind)
+		}
+	}
+
+	properties := root.member("properties")
+	optionalProperties := root.member("optional_properties")
+	for _, field := range []string{"properties", "optional_properties"} {
+		n := root.member(field)
+		if n == nil {
+			continue
+		}
+		if n.kind != "object" {
+			l.report(f, n.offset, SeverityError, nil, "%s must be an object but got %s", field, n.kind)
+			continue
+		}
+		for _, m := range n.members {
+			l.lintProperty(f, s, m.key, m.value)
+		}
+	}
+
+	// a schema whose documents are not objects with its properties, such as
+	// an array of another schema, cannot have properties of its own
+	if n := root.member("root"); n != nil {
+		l.lintProperty(f, s, "root", n)
+		if n.kind == "object" && !lintObjectRoot(n) {
+			for _, field := range []*jsonNode{properties, optionalProperties, root.member("extends")} {
+				if field != nil && (field.kind != "object" || len(field.members) > 0) {
+					l.report(f, field.offset, SeverityError, nil, "schema has a root that is not an object with its properties, so it cannot define or extend properties")
+				}
+			}
+		}
+	}
+
+	if properties != nil && optionalProperties != nil && properties.kind == "object" && optionalProperties.kind == "object" {
+		for _, m := range optionalProperties.members {
+			if properties.member(m.key) != nil {
+				l.report(f, m.offset, SeverityError, nil, "property %s is defined in both properties and optional_properties", m.key)
+			}
+		}
+	}
+}
+
+// lintObjectRoot reports whether a root definition describes objects with the
+// schema's own properties
+func lintObjectRoot(n *jsonNode) bool {
+	typeNode, ref := n.member("type"), n.member("ref")
+	return typeNode != nil && typeNode.value == "object" && ref == nil
+}
+
+// lintMessages checks the message templates of a schema or property, what
+// names it in diagnostics. Unknown keys that only differ from a known key by
+// case or separators are fixable.
+func (l *linter) lintMessages(f *lintFile, n *jsonNode, what string) {
+	if n.kind != "object" {
+		l.report(f, n.offset, SeverityError, nil, "%s messages must be an object but got %s", what, n.kind)
+		return
+	}
+	for _, locale := range n.members {
+		if locale.value.kind != "object" {
+			l.report(f, locale.value.offset, SeverityError, nil, "%s messages %s must be an object but got %s", what, locale.key, locale.value.kind)
+			continue
+		}
+		for _, m := range locale.value.members {
+			switch {
+			case m.value.kind != "string":
+				l.report(f, m.value.offset, SeverityError, nil, "%s message %s must be a string but got %s", what, m.key, m.value.kind)
+			case contains(messageKeys, m.key):
+			case normalizeName(m.key, messageKeys) != "":
+				key := normalizeName(m.key, messageKeys)
+				l.report(f, m.offset, SeverityError, m.renameFix(key), "%s has an unknown message %s, did you mean %s", what, m.key, key)
+			default:
+				l.report(f, m.offset, SeverityError, nil, "%s has an unknown message %s", what, m.key)
+			}
+		}
+	}
+}
+
+// lintFields reports duplicate and unknown fields of an object, unknown fields
+// that only differ from a known field by case or separators are fixable
+func (l *linter) lintFields(f *lintFile, n *jsonNode, what string, fields []string) {
+	seen := make(map[string]bool, len(n.members))
+	for _, m := range n.members {
+		if seen[m.key] {
+			l.report(f, m.offset, SeverityError, nil, "%s has a duplicate %s field, only the last one is used", what, m.key)
+		}
+		seen[m.key] = true
+
+		if contains(fields, m.key) {
+			continue
+		}
+		if field := normalizeName(m.key, fields); field != "" {
+			l.report(f, m.offset, SeverityError, m.renameFix(field), "%s has an unknown field %s, did you mean %s", what, m.key, field)
+			continue
+		}
+		l.report(f, m.offset, SeverityError, nil, "%s has an unknown field %s", what, m.key)
+	}
+}
+
+// lintProperty checks a property definition, path names the property in
+// diagnostics
+func (l *linter) lintProperty(f *lintFile, s *lintSchema, path string, n *jsonNode) {
+	if n.kind != "object" {
+		l.report(f, n.offset, SeverityError, nil, "property %s must be an object but got %s", path, n.kind)
+		return
+	}
+	l.lintFields(f, n, "property "+path, propertyFields)
+
+	// the property's type is only known when it is valid, or fixed
+	propertyType := ""
+	typeNode := n.member("type")
+	switch {
+	case typeNode == nil:
+		l.report(f, n.offset, SeverityError, nil, "property %s is missing its type", path)
+	case typeNode.kind != "string":
+		l.report(f, typeNode.offset, SeverityError, nil, "property %s type must be a string but got %s", path, typeNode.kind)
+	case contains(propertyTypes, typeNode.value.(string)):
+		propertyType = typeNode.value.(string)
+	default:
+		name := typeNode.value.(string)
+		propertyType = normalizeName(name, propertyTypes)
+		if propertyType == "" {
+			propertyType = propertyTypeAliases[strings.ToLower(name)]
+		}
+		if propertyType == "" {
+			l.report(f, typeNode.offset, SeverityError, nil, "property %s has an unknown type %s, it must be one of %s", path, name, strings.Join(propertyTypes, ", "))
+			break
+		}
+		l.report(f, typeNode.offset, SeverityError, typeNode.replaceFix(strconv.Quote(propertyType)), "property %s has an unknown type %s, did you mean %s", path, name, propertyType)
+	}
+
+	if d := n.member("description"); d != nil && d.kind != "string" {
+		l.report(f, d.offset, SeverityError, nil, "property %s description must be a string but got %s", path, d.kind)
+	}
+
+	if messages := n.member("messages"); messages != nil {
+		l.lintMessages(f, messages, "property "+path)
+	}
+
+	if ref := n.member("ref"); ref != nil {
+		switch {
+		case ref.kind != "string":
+			l.report(f, ref.offset, SeverityError, nil, "property %s ref must be a string but got %s", path, ref.kind)
+		case propertyType != "" && propertyType != "object":
+			l.report(f, ref.offset, SeverityWarning, nil, "property %s ref is ignored as the property is not an object", path)
+		default:
+			s.refs = append(s.refs, lintRef{path: path, node: ref})
+		}
+	}
+
+	if items := n.member("items"); items != nil {
+		if propertyType != "" && propertyType != "array" {
+			l.report(f, items.offset, SeverityWarning, nil, "property %s items are ignored as the property is not an array", path)
+		}
+		l.lintProperty(f, s, path+"[]", items)
+	}
+
+	if rules := n.member("rules"); rules != nil {
+		if rules.kind != "object" {
+			l.report(f, rules.offset, SeverityError, nil, "property %s rules must be an object but got %s", path, rules.kind)
+			return
+		}
+		l.lintRules(f, path, propertyType, rules)
+	}
+}
+
+// lintRules checks the rules of a property
+func (l *linter) lintRules(f *lintFile, path, propertyType string, rules *jsonNode) {
+	ruleNames := sortedKeys(ruleSpecs)
+	numbers := map[string]float64{}
+	for _, m := range rules.members {
+		rule := m.key
+		spec, ok := ruleSpecs[rule]
+		if !ok {
+			rule = normalizeName(m.key, ruleNames)
+			if rule == "" {
+				l.report(f, m.offset, SeverityError, nil, "property %s has an unknown rule %s", path, m.key)
+				continue
+			}
+			spec = ruleSpecs[rule]
+			l.report(f, m.offset, SeverityError, m.renameFix(rule), "property %s has an unknown rule %s, did you mean %s", path, m.key, rule)
+		}
+
+		if propertyType != "" && !contains(spec.types, propertyType) {
+			l.report(f, m.offset, SeverityError, nil, "property %s rule %s does not apply to %s properties", path, rule, propertyType)
+		}
+
+		arg := m.value
+		switch spec.arg {
+		case "number", "length":
+			n, ok := arg.number()
+			if !ok {
+				l.report(f, arg.offset, SeverityError, nil, "property %s rule %s must be a number but got %s", path, rule, arg.kind)
+				continue
+			}
+			if arg.kind == "string" {
+				l.report(f, arg.offset, SeverityError, arg.replaceFix(arg.value.(string)), "property %s rule %s must be a number but got a string", path, rule)
+			}
+			if spec.arg == "length" && (n < 0 || n != float64(int(n))) {
+				l.report(f, arg.offset, SeverityError, nil, "property %s rule %s must be a non-negative integer but got %v", path, rule, n)
+			}
+			numbers[rule] = n
+
+		case "options":
+			if arg.kind != "list" {
+				l.report(f, arg.offset, SeverityError, nil, "property %s rule %s must be an array but got %s", path, rule, arg.kind)
+			}
+
+		case "string", "regex", "format":
+			if arg.kind != "string" {
+				l.report(f, arg.offset, SeverityError, nil, "property %s rule %s must be a string but got %s", path, rule, arg.kind)
+				continue
+			}
+			value := arg.value.(string)
+			if spec.arg == "regex" {
+				if _, err := regexp.Compile(value); err != nil {
+					l.report(f, arg.offset, SeverityError, nil, "property %s rule %s is not a valid regular expression: %v", path, rule, err)
+				}
+			}
+			if spec.arg == "format" && !contains(formats, value) {
+				if format := normalizeName(value, formats); format != "" {
+					l.report(f, arg.offset, SeverityError, arg.replaceFix(strconv.Quote(format)), "property %s has an unknown format %s, did you mean %s", path, value, format)
+				} else {
+					l.report(f, arg.offset, SeverityError, nil, "property %s has an unknown format %s", path, value)
+				}
+			}
+		}
+	}
+
+	for _, bounds := range [][2]string{{"min", "max"}, {"min_length", "max_length"}} {
+		min, hasMin := numbers[bounds[0]]
+		max, hasMax := numbers[bounds[1]]
+		if hasMin && hasMax && min > max {
+			l.report(f, rules.offset, SeverityError, nil, "property %s rule %s %v is greater than %s %v, no value can satisfy both", path, bounds[0], min, bounds[1], max)
+		}
+	}
+}
+
+// lintReferences checks that the schemas named by extends and ref are defined,
+// and that extends chains do not loop
+func (l *linter) lintReferences() {
+	for _, s := range l.order {
+		for _, ref := range s.refs {
+			name := ref.node.value.(string)
+			if l.resolve(name) == nil {
+				l.report(s.file, ref.node.offset, SeverityError, nil, "property %s references unknown schema %s", ref.path, name)
+			}
+		}
+
+		if s.extends == "" {
+			continue
+		}
+		parent := l.resolve(s.extends)
+		if parent == nil {
+			l.report(s.file, s.extendsNode.offset, SeverityError, nil, "schema %s extends unknown schema %s", s.ref(), s.extends)
+			continue
+		}
+		chain := []string{s.ref()}
+		seen := map[*lintSchema]bool{s: true}
+		for ; parent != nil; parent = l.resolve(parent.extends) {
+			chain = append(chain, parent.ref())
+			if parent == s {
+				l.report(s.file, s.extendsNode.offset, SeverityError, nil, "schema %s has a circular extends chain %s", s.ref(), strings.Join(chain, " -> "))
+				break
+			}
+			// a loop that does not include s is reported for the schemas within it
+			if seen[parent] || parent.extends == "" {
+				break
+			}
+			seen[parent] = true
+		}
+	}
+}
+
+// resolve returns the schema named by a reference such as order or
+// order@^1.2, the same way as SchemaManager.GetSchema, or nil if there is none
+func (l *linter) resolve(ref string) *lintSchema {
+	schemaType, constraint, hasVersion := strings.Cut(ref, "@")
+	var c versionConstraint
+	if hasVersion && constraint != "latest" {
+		var err error
+		if c, err = parseConstraint(constraint); err != nil {
+			return nil
+		}
+	}
+
+	// without a version, pre-releases are only used when there is no release
+	var latest, latestRelease *lintSchema
+	for _, s := range l.order {
+		if !strings.EqualFold(s.schemaType, schemaType) {
+			continue
+		}
+		v, err := parseVersion(s.version)
+		if c != nil && (err != nil || !c.match(v)) {
+			continue
+		}
+		if latest == nil || compareSchemaVersions(s.version, latest.version) > 0 {
+			latest = s
+		}
+		release := err != nil || len(v.pre) == 0
+		if release && (latestRelease == nil || compareSchemaVersions(s.version, latestRelease.version) > 0) {
+			latestRelease = s
+		}
+	}
+	if c == nil && latestRelease != nil {
+		return latestRelease
+	}
+	return latest
+}
+
+// ref returns a reference to the schema, such as Order@1.2.0
+func (s *lintSchema) ref() string {
+	return (&Schema{Type: s.schemaType, Version: s.version}).ref()
+}
+
+// normalizeName returns the name within names that name matches when case,
+// underscores and dashes are ignored, or "" if there is none
+func normalizeName(name string, names []string) string {
+	normalize := strings.NewReplacer("_", "", "-", "", " ", "")
+	key := strings.ToLower(normalize.Replace(name))
+	for _, candidate := range names {
+		if strings.ToLower(normalize.Replace(candidate)) == key {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// position returns the 1-based line and column of a byte offset
+func position(src []byte, offset int) (int, int) {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	line := 1 + bytes.Count(src[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(src[:offset], '\n')
+	return line, column
+}
+
+// jsonNode is a parsed JSON value along with its position in the source,
+// kind is one of object, list, string, number, bool or null
+type jsonNode struct {
+	kind    string
+	offset  int
+	end     int
+	value   interface{}
+	members []jsonMember
+	items   []*jsonNode
+}
+
+// jsonMember is a member of a JSON object, offset and end are the position of
+// its key
+type jsonMember struct {
+	key    string
+	offset int
+	end    int
+	value  *jsonNode
+}
+
+// member returns the value of the last member named key, as that is the one
+// used when a document is decoded
+func (n *jsonNode) member(key string) *jsonNode {
+	for i := len(n.members) - 1; i >= 0; i-- {
+		if n.members[i].key == key {
+			return n.members[i].value
+		}
+	}
+	return nil
+}
+
+// number returns the value of a number, or of a string holding a number
+func (n *jsonNode) number() (float64, bool) {
+	var s string
+	switch v := n.value.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+	default:
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil && (n.kind == "number" || json.Valid([]byte(s)))
+}
+
+// replaceFix replaces the node with replacement
+func (n *jsonNode) replaceFix(replacement string) *Fix {
+	return &Fix{Offset: n.offset, Length: n.end - n.offset, Replacement: replacement}
+}
+
+// renameFix replaces the key of the member with name
+func (m jsonMember) renameFix(name string) *Fix {
+	return &Fix{Offset: m.offset, Length: m.end - m.offset, Replacement: strconv.Quote(name)}
+}
+
+// parseNode parses a JSON document into a tree of nodes. Unlike decoding into
+// a map, duplicate object keys are kept, in order.
+func parseNode(src []byte) (*jsonNode, error) {
+	p := &nodeParser{src: src, dec: json.NewDecoder(bytes.NewReader(src))}
+	p.dec.UseNumber()
+	tok, offset, err := p.next()
+	if err != nil {
+		if err == io.EOF {
+			err = errors.New("schema file is empty")
+		}
+		return nil, err
+	}
+	n, err := p.parse(tok, offset)
+	if err != nil {
+		return nil, err
+	}
+	if _, offset, err := p.next(); err != io.EOF {
+		if err == nil {
+			err = &trailingDataError{offset: offset}
+		}
+		return nil, err
+	}
+	return n, nil
+}
+
+// trailingDataError is returned by parseNode when the document is followed
+// by more data
+type trailingDataError struct {
+	offset int
+}
+
+func (e *trailingDataError) Error() string {
+	return "unexpected data after the schema"
+}
+
+// nodeParser reads tokens along with the offset they start at
+type nodeParser struct {
+	src []byte
+	dec *json.Decoder
+}
+
+// next returns the next token and its offset, the decoder's offset is the end
+// of the previous token so separators and whitespace are skipped to find it
+func (p *nodeParser) next() (json.Token, int, error) {
+	offset := int(p.dec.InputOffset())
+	for offset < len(p.src) && strings.IndexByte(" \t\r\n,:", p.src[offset]) >= 0 {
+		offset++
+	}
+	tok, err := p.dec.Token()
+	return tok, offset, err
+}
+
+func (p *nodeParser) parse(tok json.Token, offset int) (*jsonNode, error) {
+	n := &jsonNode{offset: offset, value: tok}
+	switch tok {
+	case json.Delim('{'):
+		n.kind, n.value = "object", nil
+		for p.dec.More() {
+			key, keyOffset, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			m := jsonMember{key: key.(string), offset: keyOffset, end: int(p.dec.InputOffset())}
+			tok, offset, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			m.value, err = p.parse(tok, offset)
+			if err != nil {
+				return nil, err
+			}
+			n.members = append(n.members, m)
+		}
+		if _, _, err := p.next(); err != nil {
+			return nil, err
+		}
+
+	case json.Delim('['):
+		n.kind, n.value = "list", nil
+		for p.dec.More() {
+			tok, offset, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			item, err := p.parse(tok, offset)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		}
+		if _, _, err := p.next(); err != nil {
+			return nil, err
+		}
+
+	default:
+		n.kind = jsonKind(tok)
+	}
+	n.end = int(p.dec.InputOffset())
+	return n, nil
+}
@@ -0,0 +1,125 @@
+package jsontype_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+var lintFiles = map[string][]byte{
+	"animal.json": []byte(`{
+  "type": "Animal",
+  "properties": {
+    "name": {"type": "string", "rules": {"maxLength": "5", "format": "Alpha"}},
+    "age": {"type": "integer", "rules": {"min": 10, "max": 1}},
+    "tags": {"type": "array", "items": {"type": "string"}, "rules": {"regex": "["}},
+    "owner": {"type": "object", "ref": "Person"}
+  },
+  "optional_properties": {
+    "name": {"type": "string", "rules": {"startswith": "L", "size": 3}}
+  },
+  "allowUndefinedProperties": true
+}`),
+	"dog.json":  []byte(`{"type": "Dog", "extends": "Cat", "properties": {"good": {"type": "bool", "rules": {"max_length": 1}}}}`),
+	"cat.json":  []byte(`{"type": "Cat", "extends": "Dog", "properties": {}}`),
+	"bird.json": []byte(`{"type": "Bird", "properties": {"wings": {"type": "number", "messages": {"en": {"Min": "{property} too low"}}}}}`),
+	"cats.json": []byte(`{"type": "Cats", "root": {"type": "array", "items": {"type": "object", "ref": "Cat"}}, "properties": {"n": {"type": "number"}}}`),
+}
+
+// lintMessages returns the messages of diagnostics, one per line
+func lintMessages(diagnostics []jsontype.Diagnostic) string {
+	messages := []string{}
+	for _, d := range diagnostics {
+		messages = append(messages, d.String())
+	}
+	return strings.Join(messages, "\n")
+}
+
+func TestLintSchemas(t *testing.T) {
+	diagnostics := jsontype.LintSchemas(lintFiles)
+	messages := lintMessages(diagnostics)
+
+	for _, expected := range []string{
+		"animal.json:4:42: error: property name has an unknown rule maxLength, did you mean max_length",
+		"animal.json:4:55: error: property name rule max_length must be a number but got a string",
+		"animal.json:4:70: error: property name has an unknown format Alpha, did you mean alpha",
+		"animal.json:5:21: error: property age has an unknown type integer, did you mean number",
+		"animal.json:5:41: error: property age rule min 10 is greater than max 1, no value can satisfy both",
+		"animal.json:6:70: error: property tags rule regex does not apply to array properties",
+		"animal.json:6:79: error: property tags rule regex is not a valid regular expression",
+		"animal.json:7:40: error: property owner references unknown schema Person",
+		"animal.json:10:5: error: property name is defined in both properties and optional_properties",
+		"animal.json:10:61: error: property name has an unknown rule size",
+		"animal.json:12:3: error: schema has an unknown field allowUndefinedProperties, did you mean allow_undefined_properties",
+		"bird.json:1:81: error: property wings has an unknown message Min, did you mean min",
+		"cat.json:1:28: error: schema Cat has a circular extends chain Cat -> Dog -> Cat",
+		"cats.json:1:102: error: schema has a root that is not an object with its properties, so it cannot define or extend properties",
+		"dog.json:1:28: error: schema Dog has a circular extends chain Dog -> Cat -> Dog",
+		"dog.json:1:85: error: property good rule max_length does not apply to bool properties",
+	} {
+		if !strings.Contains(messages, expected) {
+			t.Fatalf("expected %q in\n%s", expected, messages)
+		}
+	}
+	if len(diagnostics) != 16 {
+		t.Fatalf("expected 16 diagnostics but got %d\n%s", len(diagnostics), messages)
+	}
+}
+
+func TestLintSchemasValid(t *testing.T) {
+	diagnostics := jsontype.LintSchemas(map[string][]byte{
+		"person.json": []byte(`{"type": "Person", "properties": {"name": {"type": "string", "rules": {"max_length": 10}, "messages": {"de": {"max_length": "{property} ist zu lang"}}}}}`),
+		"owner.json":  []byte(`{"type": "Owner", "extends": "Person", "properties": {"pet": {"type": "object", "ref": "person"}}}`),
+	})
+	if len(diagnostics) != 0 {
+		t.Fatalf("expected no diagnostics but got\n%s", lintMessages(diagnostics))
+	}
+
+	// test syntax errors and duplicate schemas
+	diagnostics = jsontype.LintSchemas(map[string][]byte{
+		"a.json": []byte(`{"type": "Person", "properties": {}}`),
+		"b.json": []byte(`{"type": "person", "properties": {}}`),
+		"c.json": []byte("{\n  \"type\": \"Bad\",\n}"),
+		"d.json": []byte(`{"type": "Extra", "properties": {}} {}`),
+	})
+	messages := lintMessages(diagnostics)
+	for _, expected := range []string{
+		"b.json:1:10: error: schema person is already defined in a.json",
+		"c.json:2:17: error: invalid character ','",
+		"d.json:1:37: error: unexpected data after the schema",
+	} {
+		if !strings.Contains(messages, expected) {
+			t.Fatalf("expected %q in\n%s", expected, messages)
+		}
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	diagnostics := jsontype.LintSchemas(lintFiles)
+	animal := []jsontype.Diagnostic{}
+	for _, d := range diagnostics {
+		if d.File == "animal.json" {
+			animal = append(animal, d)
+		}
+	}
+	fixed := jsontype.ApplyFixes(lintFiles["animal.json"], animal)
+
+	// fixes only replace the offending tokens
+	for _, expected := range []string{
+		`"name": {"type": "string", "rules": {"max_length": 5, "format": "alpha"}},`,
+		`"age": {"type": "number", "rules": {"min": 10, "max": 1}},`,
+		`  "allow_undefined_properties": true`,
+	} {
+		if !strings.Contains(string(fixed), expected) {
+			t.Fatalf("expected %q in\n%s", expected, fixed)
+		}
+	}
+
+	// test errors without a fix remain, so the schema still fails to load
+	sm := jsontype.NewSchemaManager()
+	err := sm.LoadSchema(fixed)
+	if err == nil || err.Error() != "schema Animal defines property name in both properties and optional_properties" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
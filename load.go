@@ -0,0 +1,273 @@
+package jsontype
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LoadFS loads every schema file within fsys that matches pattern, such as
+// "schemas/*.json", using the syntax of fs.Glob. An empty pattern loads every
+// .json file within fsys and its subdirectories. fsys may be an embed.FS or
+// the result of os.DirFS. See LoadSchemas for how the files are loaded.
+func (sm *SchemaManager) LoadFS(fsys fs.FS, pattern string, options ...LoadOption) error {
+	names, err := schemaFileNames(fsys, pattern)
+	if err != nil {
+		return err
+	}
+
+	errs := LoadErrors{}
+	files := make(map[string][]byte, len(names))
+	for _, name := range names {
+		def, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			errs = append(errs, &LoadError{File: name, Err: err})
+			continue
+		}
+		files[name] = def
+	}
+
+	errs = append(errs, sm.load(files, hasOption(options, Replace))...)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// LoadDir loads every schema file within a directory that matches pattern,
+// it is the same as calling LoadFS with os.DirFS(dir), except that file names
+// in errors include the directory
+func (sm *SchemaManager) LoadDir(dir, pattern string, options ...LoadOption) error {
+	err := sm.LoadFS(os.DirFS(dir), pattern, options...)
+	if errs, ok := err.(LoadErrors); ok {
+		for _, e := range errs {
+			e.File = filepath.Join(dir, filepath.FromSlash(e.File))
+		}
+	}
+	return err
+}
+
+// LoadSchemas loads a set of schema definitions keyed by file name. Unlike
+// calling LoadSchema for each one, the schemas may be given in any order, as
+// every schema is loaded after the schemas it extends or references. Files
+// that fail to load do not stop the others from loading, a LoadErrors listing
+// every file that failed is returned. A schema fails to load if it extends or
+// references a schema that is neither in the set nor already loaded, or one
+// that failed to load.
+func (sm *SchemaManager) LoadSchemas(files map[string][]byte, options ...LoadOption) error {
+	errs := sm.load(files, hasOption(options, Replace))
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// load loads a set of schema definitions, writing them through to the store
+// in use if there is one
+func (sm *SchemaManager) load(files map[string][]byte, replace bool) LoadErrors {
+	if ss := sm.storeSync(); ss != nil {
+		return ss.load(files, replace)
+	}
+	return sm.loadSchemas(files, replace)
+}
+
+func (sm *SchemaManager) loadSchemas(files map[string][]byte, replace bool) LoadErrors {
+	l := &schemaLoader{
+		sm:      sm,
+		replace: replace,
+		schemas: make(map[string]*Schema, len(files)),
+		files:   make(map[string]string, len(files)),
+		types:   make(map[string][]string, len(files)),
+		state:   make(map[string]loadState, len(files)),
+		errs:    make(map[string]error),
+	}
+
+	errs := LoadErrors{}
+	for _, name := range sortedKeys(files) {
+		s, err := decodeSchema(files[name])
+		if err != nil {
+			errs = append(errs, &LoadError{File: name, Err: err})
+			continue
+		}
+		key := s.key()
+		if other, ok := l.files[key]; ok {
+			errs = append(errs, &LoadError{File: name, Err: fmt.Errorf("schema %s is already defined in %s", s.ref(), other)})
+			continue
+		}
+		l.schemas[key] = s
+		l.files[key] = name
+		l.types[strings.ToLower(s.Type)] = append(l.types[strings.ToLower(s.Type)], key)
+	}
+
+	for _, key := range sortedKeys(l.schemas) {
+		l.load(key)
+	}
+	for _, key := range sortedKeys(l.errs) {
+		errs = append(errs, &LoadError{File: l.files[key], Err: l.errs[key]})
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].File < errs[j].File
+	})
+	return errs
+}
+
+// loadState tracks the progress of loading a schema within a set
+type loadState int
+
+const (
+	unloaded loadState = iota
+	loading
+	loaded
+)
+
+// schemaLoader loads a set of schemas keyed by their lower case type and
+// version, the schemas a schema depends on are loaded first
+type schemaLoader struct {
+	sm      *SchemaManager
+	replace bool
+	schemas map[string]*Schema
+	files   map[string]string
+	// types maps the lower case types of the schemas to their keys
+	types map[string][]string
+	state map[string]loadState
+	errs  map[string]error
+}
+
+func (l *schemaLoader) load(key string) {
+	if l.state[key] != unloaded {
+		return
+	}
+	l.state[key] = loading
+	defer func() { l.state[key] = loaded }()
+	s := l.schemas[key]
+
+	if s.Extends != "" {
+		if err := l.dependency(s.Extends); err != nil {
+			l.errs[key] = fmt.Errorf("schema %s extends schema %s which %v", s.ref(), s.Extends, err)
+			return
+		}
+	}
+
+	// references are resolved lazily, so schemas may reference each other
+	for _, ref := range s.references() {
+		if err := l.dependency(ref); err != nil && err != errLoading {
+			l.errs[key] = fmt.Errorf("schema %s references schema %s which %v", s.ref(), ref, err)
+			return
+		}
+	}
+
+	if err := l.sm.addSchema(s, l.replace); err != nil {
+		l.errs[key] = err
+	}
+}
+
+// errLoading is returned by dependency when the schema depended on is being
+// loaded, which is a cycle
+var errLoading = errors.New("is part of a circular extends chain")
+
+// dependency loads the schemas of the set that ref may name, and returns why
+// the schema named by ref is not available if it cannot be loaded
+func (l *schemaLoader) dependency(ref string) error {
+	schemaType, _, _ := strings.Cut(ref, "@")
+	keys := l.types[strings.ToLower(schemaType)]
+	inProgress, failed := false, ""
+	for _, key := range keys {
+		if l.state[key] == loading {
+			inProgress = true
+			continue
+		}
+		l.load(key)
+		if _, ok := l.errs[key]; ok && failed == "" {
+			failed = l.files[key]
+		}
+	}
+
+	// the store is not read through, as a StoreSync loads while it holds its
+	// lock
+	l.sm.mu.RLock()
+	_, err := l.sm.resolve(ref)
+	l.sm.mu.RUnlock()
+	if err == nil {
+		return nil
+	}
+	switch {
+	case inProgress:
+		return errLoading
+	case failed != "":
+		return fmt.Errorf("failed to load from %s", failed)
+	}
+	return fmt.Errorf("is not defined")
+}
+
+// key returns the lower case type and version of the schema, which identify
+// it within a SchemaManager
+func (s *Schema) key() string {
+	return strings.ToLower(s.Type) + "@" + s.Version
+}
+
+// ref returns a reference to the schema, such as Order@1.2.0
+func (s *Schema) ref() string {
+	if s.Version == "" {
+		return s.Type
+	}
+	return s.Type + "@" + s.Version
+}
+
+// references returns the types of the schemas referenced by the schema's root
+// and properties, in the order they are defined
+func (s *Schema) references() []string {
+	refs := []string{}
+	seen := map[string]bool{}
+	var add func(p Property)
+	add = func(p Property) {
+		if p.Ref != "" && !seen[strings.ToLower(p.Ref)] {
+			seen[strings.ToLower(p.Ref)] = true
+			refs = append(refs, p.Ref)
+		}
+		if p.Items != nil {
+			add(*p.Items)
+		}
+	}
+	if s.Root != nil {
+		add(*s.Root)
+	}
+	for _, name := range sortedKeys(s.Properties) {
+		add(s.Properties[name])
+	}
+	for _, name := range sortedKeys(s.OptionalProperties) {
+		add(s.OptionalProperties[name])
+	}
+	return refs
+}
+
+// schemaFileNames returns the names of the schema files within fsys that
+// match pattern, or every .json file if pattern is empty
+func schemaFileNames(fsys fs.FS, pattern string) ([]string, error) {
+	if pattern != "" {
+		names, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no schema files match %s", pattern)
+		}
+		return names, nil
+	}
+
+	names := []string{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(path.Ext(name), ".json") {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
@@ -0,0 +1,114 @@
+package jsontype_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/apageadev/jsontype"
+)
+
+// schemas are named so that alphabetical order is not dependency order
+var loadFiles = fstest.MapFS{
+	"schemas/a_dog.json":      {Data: []byte(`{"type": "Dog", "extends": "Animal", "properties": {"owner": {"type": "object", "ref": "Person"}}}`)},
+	"schemas/b_person.json":   {Data: []byte(`{"type": "Person", "properties": {"pets": {"type": "array", "items": {"type": "object", "ref": "Dog"}}}}`)},
+	"schemas/c_animal.json":   {Data: []byte(`{"type": "Animal", "properties": {"name": {"type": "string"}}}`)},
+	"schemas/nested/cat.json": {Data: []byte(`{"type": "Cat", "extends": "Animal", "properties": {}}`)},
+	"README.md":               {Data: []byte(`not a schema`)},
+}
+
+func TestSchemaManagerLoadFS(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	err := sm.LoadFS(loadFiles, "schemas/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm.SchemaCount() != 3 {
+		t.Fatalf("expected 3 schemas but got %d", sm.SchemaCount())
+	}
+
+	// test an empty pattern loads every .json file
+	sm = jsontype.NewSchemaManager()
+	err = sm.LoadFS(loadFiles, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm.SchemaCount() != 4 {
+		t.Fatalf("expected 4 schemas but got %d", sm.SchemaCount())
+	}
+
+	dog, _ := sm.GetSchema("dog")
+	err = dog.Validate([]byte(`{"name": "Luna", "owner": {"pets": [{"name": "Luna", "owner": {"pets": []}}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test no matches
+	err = sm.LoadFS(loadFiles, "*.yaml")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSchemaManagerLoadFSErrors(t *testing.T) {
+	files := fstest.MapFS{
+		"animal.json": {Data: []byte(`{"type": "Animal", "properties": {"name": {"type": "string"}}}`)},
+		"bad.json":    {Data: []byte(`{`)},
+		"dog.json":    {Data: []byte(`{"type": "Dog", "extends": "Wolf", "properties": {}}`)},
+		"puppy.json":  {Data: []byte(`{"type": "Puppy", "extends": "Dog", "properties": {}}`)},
+		"owner.json":  {Data: []byte(`{"type": "Owner", "properties": {"cat": {"type": "object", "ref": "Cat"}}}`)},
+		"x.json":      {Data: []byte(`{"type": "X", "extends": "Y", "properties": {}}`)},
+		"y.json":      {Data: []byte(`{"type": "Y", "extends": "X", "properties": {}}`)},
+	}
+
+	sm := jsontype.NewSchemaManager()
+	err := sm.LoadFS(files, "*.json")
+	var errs jsontype.LoadErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected LoadErrors but got %v", err)
+	}
+
+	failed := []string{}
+	for _, e := range errs {
+		failed = append(failed, e.File)
+	}
+	if strings.Join(failed, ",") != "bad.json,dog.json,owner.json,puppy.json,x.json,y.json" {
+		t.Fatalf("unexpected failed files %v: %v", failed, err)
+	}
+
+	// files that did not fail are still loaded
+	if sm.SchemaCount() != 1 {
+		t.Fatalf("expected 1 schema but got %d", sm.SchemaCount())
+	}
+}
+
+func TestSchemaManagerLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, file := range loadFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, file.Data, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.WriteFile(filepath.Join(dir, "schemas", "bad.json"), []byte(`{`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sm := jsontype.NewSchemaManager()
+	err = sm.LoadDir(dir, "")
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(dir, "schemas", "bad.json")) {
+		t.Fatalf("expected an error naming the bad file but got %v", err)
+	}
+	if sm.SchemaCount() != 4 {
+		t.Fatalf("expected 4 schemas but got %d", sm.SchemaCount())
+	}
+}
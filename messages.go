@@ -0,0 +1,232 @@
+package jsontype
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Messages is a catalog of error message templates. Templates are keyed by
+// error kind, such as missing_property, or by rule name, such as max_length.
+// A format rule is keyed by format, such as format.email, and falls back to
+// format. A template may use the parameters {property}, {expected} and
+// {actual}, which are replaced with the property's path, the type or rule
+// argument the value was expected to satisfy, and the value or its kind.
+// {a_expected} is {expected} with its English indefinite article, such as an
+// array.
+type Messages map[string]string
+
+// defaultLocale is the locale of the built-in messages
+const defaultLocale = "en"
+
+// englishMessages are the built-in messages
+var englishMessages = Messages{
+	// document names the document itself in {property}
+	"document": "document",
+
+	"missing_property":      "required property {property} is missing",
+	"undefined_property":    "property {property} is not defined in the schema",
+	"invalid_type":          "property {property} is not of type {expected}",
+	"invalid_document_type": "document must be {a_expected} but got {actual}",
+
+	"min":        "{property} must be greater than {expected} but got {actual}",
+	"max":        "{property} must be less than {expected} but got {actual}",
+	"min_length": "{property} length must be greater than {expected} but got {actual}",
+	"max_length": "{property} length must be less than {expected} but got {actual}",
+	"oneof":      "{property} must be one of {expected} but got {actual}",
+	"noneof":     "{property} must not be one of {expected} but got {actual}",
+	"allof":      "{property} must be all of {expected} but got {actual}",
+	"anyof":      "{property} must be any of {expected} but got {actual}",
+	"regex":      "{property} must match {expected} but got {actual}",
+	"contains":   "{property} must contain {expected} but got {actual}",
+	"startswith": "{property} must start with {expected} but got {actual}",
+
+	// rules that only apply to strings or arrays report other values with
+	// not_string and not_array
+	"not_string": "{property} must be a string but got {actual}",
+	"not_array":  "{property} must be an array but got {actual}",
+
+	"format":           "{property} must be {expected} but got {actual}",
+	"format.alphanum":  "{property} must be alpha numeric but got {actual}",
+	"format.alphadash": "{property} must be alpha dash but got {actual}",
+	"format.hexcolor":  "{property} must be hex color but got {actual}",
+	"format.json":      "{property} must be JSON but got {actual}",
+	"format.rgbcolor":  "{property} must be RGB color but got {actual}",
+	"format.url":       "{property} must be URL but got {actual}",
+	"format.fullurl":   "{property} must be URL but got {actual}",
+	"format.ip":        "{property} must be IP but got {actual}",
+	"format.ipv4":      "{property} must be IPv4 but got {actual}",
+	"format.ipv6":      "{property} must be IPv6 but got {actual}",
+	"format.cidr":      "{property} must be CIDR but got {actual}",
+	"format.cidrv4":    "{property} must be CIDRv4 but got {actual}",
+	"format.cidrv6":    "{property} must be CIDRv6 but got {actual}",
+	"format.uuid":      "{property} must be UUID but got {actual}",
+	"format.filepath":  "{property} must be a file path but got {actual}",
+}
+
+// messageKeys are the keys of every message template
+var messageKeys = func() []string {
+	keys := sortedKeys(englishMessages)
+	for _, format := range formats {
+		if _, ok := englishMessages["format."+format]; !ok {
+			keys = append(keys, "format."+format)
+		}
+	}
+	return keys
+}()
+
+// RegisterMessages registers message templates for a locale, such as de or
+// pt-BR, replacing any registered templates with the same keys. Templates
+// missing from a locale fall back to its language, such as pt for pt-BR, and
+// then to the built-in English messages.
+func (sm *SchemaManager) RegisterMessages(locale string, messages Messages) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.messages == nil {
+		sm.messages = make(map[string]Messages)
+	}
+	locale = strings.ToLower(locale)
+	if sm.messages[locale] == nil {
+		sm.messages[locale] = Messages{}
+	}
+	for key, template := range messages {
+		sm.messages[locale][key] = template
+	}
+}
+
+// SetLocale sets the locale of the messages of validation errors, which is
+// English by default
+func (sm *SchemaManager) SetLocale(locale string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.locale = strings.ToLower(locale)
+}
+
+// Localize returns a copy of the errors with their messages in a locale, the
+// structured data of the errors is unchanged
+func (e ValidationErrors) Localize(locale string) ValidationErrors {
+	localized := make(ValidationErrors, len(e))
+	for i, err := range e {
+		localized[i] = err.Localize(locale)
+	}
+	return localized
+}
+
+// Localize returns a copy of the error with its message in a locale
+func (e *ValidationError) Localize(locale string) *ValidationError {
+	localized := *e
+	if e.catalog != nil {
+		localized.Message = e.catalog.render(e, strings.ToLower(locale))
+	}
+	return &localized
+}
+
+// A catalog finds the templates of an error's messages. The overrides of the
+// property and its schema come before the messages registered with the
+// manager, in every locale.
+type catalog struct {
+	manager   *SchemaManager
+	overrides []map[string]Messages
+}
+
+// withMessage renders the message of an error in the manager's locale, from
+// the templates of the property, if any, and the schema
+func (s *Schema) withMessage(e *ValidationError, p *Property) *ValidationError {
+	c := &catalog{manager: s.manager}
+	if p != nil && p.Messages != nil {
+		c.overrides = append(c.overrides, p.Messages)
+	}
+	if s.Messages != nil {
+		c.overrides = append(c.overrides, s.Messages)
+	}
+	e.catalog = c
+
+	locale := defaultLocale
+	if s.manager != nil {
+		s.manager.mu.RLock()
+		if s.manager.locale != "" {
+			locale = s.manager.locale
+		}
+		s.manager.mu.RUnlock()
+	}
+	e.Message = c.render(e, locale)
+	return e
+}
+
+// messageKey returns the key of the template of an error's message
+func (e *ValidationError) messageKey() string {
+	switch {
+	case e.key != "":
+		return e.key
+	case e.Kind == ErrorInvalidType && e.Path == "":
+		return "invalid_document_type"
+	}
+	return string(e.Kind)
+}
+
+// render renders the message of an error in a locale
+func (c *catalog) render(e *ValidationError, locale string) string {
+	property := e.Path
+	if property == "" {
+		property = c.template("document", locale)
+	}
+	return strings.NewReplacer(
+		"{property}", property,
+		"{expected}", fmt.Sprint(e.Expected),
+		"{a_expected}", withArticle(fmt.Sprint(e.Expected)),
+		"{actual}", fmt.Sprint(e.Actual),
+	).Replace(c.template(e.messageKey(), locale))
+}
+
+// template returns the template with a key, searching the locale, then its
+// language, then English
+func (c *catalog) template(key string, locale string) string {
+	keys := []string{key}
+	if format, _, ok := strings.Cut(key, "."); ok {
+		keys = append(keys, format)
+	}
+
+	locales := []string{locale}
+	if language, _, ok := strings.Cut(locale, "-"); ok {
+		locales = append(locales, language)
+	}
+	locales = append(locales, defaultLocale)
+
+	for _, locale := range locales {
+		for _, key := range keys {
+			if template, ok := c.find(key, locale); ok {
+				return template
+			}
+		}
+	}
+	return key
+}
+
+// find returns the template with a key in a single locale
+func (c *catalog) find(key, locale string) (string, bool) {
+	for _, overrides := range c.overrides {
+		for name, messages := range overrides {
+			if template, ok := messages[key]; ok && strings.EqualFold(name, locale) {
+				return template, true
+			}
+		}
+	}
+	if c.manager != nil {
+		c.manager.mu.RLock()
+		template, ok := c.manager.messages[locale][key]
+		c.manager.mu.RUnlock()
+		if ok {
+			return template, true
+		}
+	}
+	if locale == defaultLocale {
+		template, ok := englishMessages[key]
+		return template, ok
+	}
+	return "", false
+}
+
+// renderEnglish renders a template of the built-in messages
+func renderEnglish(key, property string, expected, actual interface{}) string {
+	c := &catalog{}
+	return c.render(&ValidationError{Path: property, key: key, Expected: expected, Actual: actual}, defaultLocale)
+}
@@ -0,0 +1,102 @@
+package jsontype_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+var messagesSchema = []byte(`{
+	"type": "Pet",
+	"properties": {
+		"name": {"type": "string", "rules": {"max_length": 5}, "messages": {"en": {"max_length": "a name has at most {expected} letters"}}},
+		"tags": {"type": "array", "items": {"type": "string"}, "rules": {"noneof": ["bad"]}},
+		"age": {"type": "number", "rules": {"min": 0}}
+	},
+	"messages": {"de": {"missing_property": "{property} fehlt"}}
+}`)
+
+// validationErrors validates a document and returns its ValidationErrors
+func validationErrors(t *testing.T, s *jsontype.Schema, document string) jsontype.ValidationErrors {
+	t.Helper()
+	var errs jsontype.ValidationErrors
+	if err := s.Validate([]byte(document)); !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors but got %v", err)
+	}
+	return errs
+}
+
+func TestValidationErrorMessages(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	if err := sm.LoadSchema(messagesSchema); err != nil {
+		t.Fatal(err)
+	}
+	s, err := sm.GetSchema("Pet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test the built-in English messages and the property's override
+	errs := validationErrors(t, s, `{"name": "Rexford", "tags": ["good", "bad"]}`)
+	if errs.Error() != "required property age is missing; a name has at most 5 letters; tags must not be one of [bad] but got [good bad]" {
+		t.Fatalf("unexpected messages %q", errs.Error())
+	}
+	if errs[2].Rule != "noneof" || errs[2].Expected.([]interface{})[0] != "bad" || len(errs[2].Actual.([]interface{})) != 2 {
+		t.Fatalf("unexpected structured error %+v", errs[2])
+	}
+
+	// test a registered locale, its language fallback, and the fallback to
+	// English for missing templates
+	sm.RegisterMessages("de", jsontype.Messages{
+		"min":      "{property} muss größer als {expected} sein, ist aber {actual}",
+		"format":   "{property} muss {expected} sein",
+		"document": "Dokument",
+	})
+	errs = validationErrors(t, s, `{"name": "Rex", "tags": [], "age": -1}`).Localize("de-AT")
+	if errs.Error() != "age muss größer als 0 sein, ist aber -1" {
+		t.Fatalf("unexpected messages %q", errs.Error())
+	}
+	errs = validationErrors(t, s, `{"name": "Rexford", "tags": []}`).Localize("de")
+	if errs.Error() != "age fehlt; a name has at most 5 letters" {
+		t.Fatalf("unexpected messages %q", errs.Error())
+	}
+
+	// test the manager's locale
+	sm.SetLocale("de")
+	errs = validationErrors(t, s, `{"name": "Rex", "tags": [], "age": -1}`)
+	if errs.Error() != "age muss größer als 0 sein, ist aber -1" || errs[0].Localize("en").Message != "age must be greater than 0 but got -1" {
+		t.Fatalf("unexpected messages %q", errs.Error())
+	}
+	err = s.ValidateStream(strings.NewReader(`{"name": "Rex", "tags": []}`))
+	if err == nil || err.Error() != "age fehlt" {
+		t.Fatalf("unexpected stream error %v", err)
+	}
+
+	// test the document name and a fallback from a format to the format rule
+	sm.LoadSchema([]byte(`{"type": "Email", "root": {"type": "string", "rules": {"format": "email"}}}`))
+	email, err := sm.GetSchema("Email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs = validationErrors(t, email, `"nobody"`)
+	if errs.Error() != "Dokument muss email sein" || errs.Localize("en").Error() != "document must be email but got nobody" {
+		t.Fatalf("unexpected messages %q", errs.Error())
+	}
+}
+
+func TestInvalidDocumentTypeMessage(t *testing.T) {
+	sm := loadSchemas(t, `{"type": "Tags", "root": {"type": "array"}}`)
+	s := getSchema(t, sm, "tags")
+
+	// test the expected type of the document is named with its article
+	errs := validationErrors(t, s, `"a"`)
+	if errs.Error() != "document must be an array but got string" || errs[0].Expected != "array" {
+		t.Fatalf("unexpected messages %q", errs.Error())
+	}
+	sm.RegisterMessages("de", jsontype.Messages{"invalid_document_type": "Dokument muss vom Typ {expected} sein"})
+	if errs.Localize("de").Error() != "Dokument muss vom Typ array sein" {
+		t.Fatalf("unexpected messages %q", errs.Localize("de").Error())
+	}
+}
@@ -0,0 +1,300 @@
+package jsontype
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// A MigrationStep changes a document written against one version of a schema
+// into the next version. Steps are applied to the decoded document, where
+// numbers are json.Number values. Any function can be used as a step, for
+// changes that the built in steps cannot describe.
+type MigrationStep func(document map[string]interface{}) error
+
+// migration upgrades documents from one version of a schema type to another
+type migration struct {
+	from, to string
+	steps    []MigrationStep
+}
+
+// RegisterMigration declares how documents are migrated from one version of a
+// schema type to another. The steps are applied in order, and the schema
+// versions don't need to be loaded until documents are migrated. Only object
+// documents can be migrated, so a version that is loaded must have an object
+// root.
+func (sm *SchemaManager) RegisterMigration(schemaType, from, to string, steps ...MigrationStep) error {
+	for _, v := range []string{from, to} {
+		if _, err := parseVersion(v); err != nil {
+			return fmt.Errorf("migration of schema %s has an %v", schemaType, err)
+		}
+	}
+	if from == to {
+		return fmt.Errorf("migration of schema %s from %s to itself", schemaType, from)
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for _, v := range []string{from, to} {
+		if s := sm.exactVersion(schemaType, v); s != nil {
+			if err := s.checkMigratable(); err != nil {
+				return err
+			}
+		}
+	}
+	schemaType = strings.ToLower(schemaType)
+	for _, m := range sm.migrations[schemaType] {
+		if m.from == from && m.to == to {
+			return fmt.Errorf("migration of schema %s from %s to %s is already registered", schemaType, from, to)
+		}
+	}
+	if sm.migrations == nil {
+		sm.migrations = make(map[string][]*migration)
+	}
+	sm.migrations[schemaType] = append(sm.migrations[schemaType], &migration{from: from, to: to, steps: steps})
+	return nil
+}
+
+// Migrate migrates a document written against version from of a schema type
+// to version to, which may be a range such as ^2 or empty for the latest
+// version. The shortest chain of registered migrations is applied and the
+// migrated document is validated against the target schema, ValidationErrors
+// are returned if it does not satisfy the schema.
+func (sm *SchemaManager) Migrate(document []byte, schemaType, from, to string) ([]byte, error) {
+	ref := schemaType
+	if to != "" {
+		ref += "@" + to
+	}
+
+	sm.mu.RLock()
+	target, err := sm.resolve(ref)
+	var chain []*migration
+	if err == nil {
+		err = target.checkMigratable()
+	}
+	if err == nil {
+		chain, err = sm.migrationChain(schemaType, from, target.Version)
+	}
+	sm.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// numbers are kept as json.Number so they are not changed by migrating
+	data, err := decodeDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("document must be an object but got %s", jsonKind(data))
+	}
+
+	for _, m := range chain {
+		for _, step := range m.steps {
+			if err := step(object); err != nil {
+				return nil, fmt.Errorf("migrating %s from %s to %s: %w", target.Type, m.from, m.to, err)
+			}
+		}
+	}
+
+	err = target.validateDocument(object)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
+
+// checkMigratable returns an error if the schema's documents are not objects,
+// as migration steps only change objects
+func (s *Schema) checkMigratable() error {
+	if s.hasObjectRoot() {
+		return nil
+	}
+	return fmt.Errorf("schema %s has %s root, only schemas with object documents can be migrated", s.ref(), withArticle(s.root().Type))
+}
+
+// migrationChain returns the shortest chain of migrations from one version of
+// a schema type to another. The caller must hold sm.mu.
+func (sm *SchemaManager) migrationChain(schemaType, from, to string) ([]*migration, error) {
+	if from == to {
+		return nil, nil
+	}
+
+	// breadth first search from the source version, chains holds the chain
+	// that reaches each version
+	migrations := sm.migrations[strings.ToLower(schemaType)]
+	chains := map[string][]*migration{from: {}}
+	queue := []string{from}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, m := range migrations {
+			if m.from != v {
+				continue
+			}
+			if _, ok := chains[m.to]; ok {
+				continue
+			}
+			chain := append(append([]*migration{}, chains[v]...), m)
+			if m.to == to {
+				return chain, nil
+			}
+			chains[m.to] = chain
+			queue = append(queue, m.to)
+		}
+	}
+	return nil, fmt.Errorf("no migration of schema %s from %s to %s", schemaType, from, to)
+}
+
+// RenameProperty renames the property at path, such as address.zip, within
+// the object that holds it
+func RenameProperty(path, name string) MigrationStep {
+	parts := strings.Split(path, ".")
+	parts[len(parts)-1] = name
+	return MoveProperty(path, strings.Join(parts, "."))
+}
+
+// MoveProperty moves the property at path from to path to, such as moving
+// street into address.street. Objects are created along path to when they
+// don't exist. Documents without the property are left unchanged.
+func MoveProperty(from, to string) MigrationStep {
+	return func(document map[string]interface{}) error {
+		value, ok, err := removePath(document, from)
+		if err != nil || !ok {
+			return err
+		}
+		return setPath(document, to, value)
+	}
+}
+
+// RemoveProperty removes the property at path
+func RemoveProperty(path string) MigrationStep {
+	return func(document map[string]interface{}) error {
+		_, _, err := removePath(document, path)
+		return err
+	}
+}
+
+// SetDefault sets the property at path to value when the document does not
+// have the property
+func SetDefault(path string, value interface{}) MigrationStep {
+	return func(document map[string]interface{}) error {
+		if _, ok, err := getPath(document, path); err != nil || ok {
+			return err
+		}
+		v, err := toJSONValue(value)
+		if err != nil {
+			return err
+		}
+		return setPath(document, path, v)
+	}
+}
+
+// SplitProperty splits the string property at path from on separator, and
+// sets each part to the properties named by to in order. The last property
+// receives the rest of the string, and properties without a part are not set.
+func SplitProperty(from, separator string, to ...string) MigrationStep {
+	return func(document map[string]interface{}) error {
+		value, ok, err := removePath(document, from)
+		if err != nil || !ok {
+			return err
+		}
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("property %s must be a string to be split but got %s", from, jsonKind(value))
+		}
+		for i, part := range strings.SplitN(s, separator, len(to)) {
+			if err := setPath(document, to[i], part); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// MergeProperties joins the properties named by from with separator into the
+// string property at path to. Properties that are missing are skipped, and
+// the document is left unchanged when all of them are missing.
+func MergeProperties(to, separator string, from ...string) MigrationStep {
+	return func(document map[string]interface{}) error {
+		parts := []string{}
+		for _, path := range from {
+			value, ok, err := removePath(document, path)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if IsObject(value) || IsList(value) {
+				return fmt.Errorf("property %s must be a string to be merged but got %s", path, jsonKind(value))
+			}
+			parts = append(parts, fmt.Sprint(value))
+		}
+		if len(parts) == 0 {
+			return nil
+		}
+		return setPath(document, to, strings.Join(parts, separator))
+	}
+}
+
+// getPath returns the value of the property at a dotted path within a
+// document, and reports whether the property exists
+func getPath(document map[string]interface{}, path string) (interface{}, bool, error) {
+	parts := strings.Split(path, ".")
+	object, err := objectAt(document, parts[:len(parts)-1], false)
+	if err != nil || object == nil {
+		return nil, false, err
+	}
+	value, ok := object[parts[len(parts)-1]]
+	return value, ok, nil
+}
+
+// setPath sets the property at a dotted path within a document, creating the
+// objects that hold it
+func setPath(document map[string]interface{}, path string, value interface{}) error {
+	parts := strings.Split(path, ".")
+	object, err := objectAt(document, parts[:len(parts)-1], true)
+	if err != nil {
+		return err
+	}
+	object[parts[len(parts)-1]] = value
+	return nil
+}
+
+// removePath removes the property at a dotted path within a document, and
+// returns its value
+func removePath(document map[string]interface{}, path string) (interface{}, bool, error) {
+	parts := strings.Split(path, ".")
+	object, err := objectAt(document, parts[:len(parts)-1], false)
+	if err != nil || object == nil {
+		return nil, false, err
+	}
+	value, ok := object[parts[len(parts)-1]]
+	delete(object, parts[len(parts)-1])
+	return value, ok, nil
+}
+
+// objectAt returns the object at a path within a document. Missing objects
+// are created when create is true, otherwise nil is returned.
+func objectAt(document map[string]interface{}, path []string, create bool) (map[string]interface{}, error) {
+	object := document
+	for i, name := range path {
+		value, ok := object[name]
+		if !ok || value == nil {
+			if !create {
+				return nil, nil
+			}
+			value = map[string]interface{}{}
+			object[name] = value
+		}
+		next, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("property %s must be an object but got %s", strings.Join(path[:i+1], "."), jsonKind(value))
+		}
+		object = next
+	}
+	return object, nil
+}
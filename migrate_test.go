@@ -0,0 +1,235 @@
+package jsontype_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+// migrateLabel migrates a document from version 1.0.0 of the Label schema to
+// version 2.0.0 with the steps
+func migrateLabel(t *testing.T, v1, v2, document string, steps ...jsontype.MigrationStep) (string, error) {
+	t.Helper()
+	sm := loadSchemas(t, v1, v2)
+	if err := sm.RegisterMigration("label", "1.0.0", "2.0.0", steps...); err != nil {
+		t.Fatal(err)
+	}
+	migrated, err := sm.Migrate([]byte(document), "label", "1.0.0", "2.0.0")
+	return string(migrated), err
+}
+
+func TestRenameProperty(t *testing.T) {
+	migrated, err := migrateLabel(t,
+		`{"type": "Label", "version": "1.0.0", "properties": {"name": {"type": "string"}}}`,
+		`{"type": "Label", "version": "2.0.0", "properties": {"full_name": {"type": "string"}}}`,
+		`{"name": "Ada Lovelace"}`,
+		jsontype.RenameProperty("name", "full_name"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != `{"full_name":"Ada Lovelace"}` {
+		t.Fatalf("unexpected document %s", migrated)
+	}
+}
+
+func TestMoveProperty(t *testing.T) {
+	migrated, err := migrateLabel(t,
+		`{"type": "Label", "version": "1.0.0", "properties": {"city": {"type": "string"}}}`,
+		`{"type": "Label", "version": "2.0.0", "properties": {"address": {"type": "object"}}}`,
+		`{"city": "London"}`,
+		jsontype.MoveProperty("city", "address.city"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != `{"address":{"city":"London"}}` {
+		t.Fatalf("unexpected document %s", migrated)
+	}
+}
+
+func TestRemoveProperty(t *testing.T) {
+	migrated, err := migrateLabel(t,
+		`{"type": "Label", "version": "1.0.0", "properties": {"name": {"type": "string"}, "legacy": {"type": "bool"}}}`,
+		`{"type": "Label", "version": "2.0.0", "properties": {"name": {"type": "string"}}}`,
+		`{"name": "Ada", "legacy": true}`,
+		jsontype.RemoveProperty("legacy"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != `{"name":"Ada"}` {
+		t.Fatalf("unexpected document %s", migrated)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	migrated, err := migrateLabel(t,
+		`{"type": "Label", "version": "1.0.0", "properties": {}}`,
+		`{"type": "Label", "version": "2.0.0", "properties": {"tier": {"type": "string"}}}`,
+		`{}`,
+		jsontype.SetDefault("tier", "bronze"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != `{"tier":"bronze"}` {
+		t.Fatalf("unexpected document %s", migrated)
+	}
+}
+
+func TestSplitProperty(t *testing.T) {
+	v1 := `{"type": "Label", "version": "1.0.0", "properties": {"name": {"type": "string"}}}`
+	v2 := `{"type": "Label", "version": "2.0.0", "properties": {"first_name": {"type": "string"}, "last_name": {"type": "string"}}}`
+	migrated, err := migrateLabel(t, v1, v2, `{"name": "Ada Lovelace"}`, jsontype.SplitProperty("name", " ", "first_name", "last_name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != `{"first_name":"Ada","last_name":"Lovelace"}` {
+		t.Fatalf("unexpected document %s", migrated)
+	}
+
+	// test steps that fail stop the migration
+	_, err = migrateLabel(t, v1, v2, `{"name": 42}`, jsontype.SplitProperty("name", " ", "first_name", "last_name"))
+	if err == nil || !strings.Contains(err.Error(), "property name must be a string to be split but got number") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestMergeProperties(t *testing.T) {
+	migrated, err := migrateLabel(t,
+		`{"type": "Label", "version": "1.0.0", "properties": {"number": {"type": "number"}, "street": {"type": "string"}}}`,
+		`{"type": "Label", "version": "2.0.0", "properties": {"line": {"type": "string"}}, "optional_properties": {"street": {"type": "string"}}}`,
+		`{"number": 12, "street": "Main St"}`,
+		jsontype.MergeProperties("line", " ", "number", "street"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != `{"line":"12 Main St"}` {
+		t.Fatalf("unexpected document %s", migrated)
+	}
+}
+
+func TestSchemaManagerMigrateChain(t *testing.T) {
+	sm := loadSchemas(t,
+		`{"type": "Label", "version": "1.0.0", "properties": {"name": {"type": "string"}}}`,
+		`{"type": "Label", "version": "2.0.0", "properties": {"full_name": {"type": "string"}}}`,
+		`{"type": "Label", "version": "3.0.0", "properties": {"first_name": {"type": "string"}, "last_name": {"type": "string"}}}`,
+	)
+	if err := sm.RegisterMigration("label", "1.0.0", "2.0.0", jsontype.RenameProperty("name", "full_name")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.RegisterMigration("label", "2.0.0", "3.0.0", jsontype.SplitProperty("full_name", " ", "first_name", "last_name")); err != nil {
+		t.Fatal(err)
+	}
+
+	// test migrating to the latest version
+	migrated, err := sm.Migrate([]byte(`{"name": "Ada Lovelace"}`), "label", "1.0.0", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(migrated) != `{"first_name":"Ada","last_name":"Lovelace"}` {
+		t.Fatalf("unexpected document %s", migrated)
+	}
+
+	// test migrating to a range of versions
+	migrated, err = sm.Migrate([]byte(`{"name": "Ada Lovelace"}`), "label", "1.0.0", "^2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(migrated) != `{"full_name":"Ada Lovelace"}` {
+		t.Fatalf("unexpected document %s", migrated)
+	}
+
+	// test there is no migration back to an earlier version
+	_, err = sm.Migrate([]byte(`{}`), "label", "2.0.0", "1.0.0")
+	if err == nil || err.Error() != "no migration of schema label from 2.0.0 to 1.0.0" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestSchemaManagerMigrateNumbers(t *testing.T) {
+	migrated, err := migrateLabel(t,
+		`{"type": "Label", "version": "1.0.0", "properties": {"points": {"type": "number"}}}`,
+		`{"type": "Label", "version": "2.0.0", "properties": {"score": {"type": "number"}}}`,
+		`{"points": 12345678901234567890}`,
+		jsontype.RenameProperty("points", "score"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test numbers are not changed by migrating
+	if migrated != `{"score":12345678901234567890}` {
+		t.Fatalf("unexpected document %s", migrated)
+	}
+}
+
+func TestSchemaManagerMigrateValidates(t *testing.T) {
+	_, err := migrateLabel(t,
+		`{"type": "Label", "version": "1.0.0", "properties": {"tier": {"type": "string"}}}`,
+		`{"type": "Label", "version": "2.0.0", "properties": {"tier": {"type": "string", "rules": {"oneof": ["bronze", "gold"]}}}}`,
+		`{"tier": "silver"}`,
+		func(document map[string]interface{}) error {
+			if document["tier"] == "silver" {
+				document["tier"] = "platinum"
+			}
+			return nil
+		},
+	)
+
+	// test the migrated document is validated against the target schema
+	var errs jsontype.ValidationErrors
+	if !errors.As(err, &errs) || errs[0].Path != "tier" {
+		t.Fatalf("expected a validation error but got %v", err)
+	}
+}
+
+func TestRegisterMigration(t *testing.T) {
+	sm := loadSchemas(t,
+		`{"type": "Label", "version": "1.0.0", "properties": {}}`,
+		`{"type": "Label", "version": "2.0.0", "properties": {}}`,
+	)
+	if err := sm.RegisterMigration("label", "1.0.0", "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.RegisterMigration("label", "1.0.0", "2.0.0"); err == nil {
+		t.Fatal("expected duplicate migration error")
+	}
+	if err := sm.RegisterMigration("label", "1", "2.0.0"); err == nil {
+		t.Fatal("expected invalid version error")
+	}
+	if err := sm.RegisterMigration("label", "2.0.0", "2.0.0"); err == nil {
+		t.Fatal("expected migration to itself error")
+	}
+}
+
+func TestRegisterMigrationRoot(t *testing.T) {
+	sm := loadSchemas(t,
+		`{"type": "Tags", "version": "1.0.0", "root": {"type": "array", "items": {"type": "string"}}}`,
+		`{"type": "Tags", "version": "2.0.0", "root": {"type": "list"}}`,
+	)
+
+	// test migrations between schemas whose documents are not objects are
+	// rejected
+	err := sm.RegisterMigration("tags", "1.0.0", "2.0.0")
+	if err == nil || !strings.Contains(err.Error(), "has an array root") {
+		t.Fatalf("expected a root error but got %v", err)
+	}
+
+	// test a migration registered before the schema was loaded
+	sm = jsontype.NewSchemaManager()
+	if err := sm.RegisterMigration("tags", "1.0.0", "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.LoadSchema([]byte(`{"type": "Tags", "version": "2.0.0", "root": {"type": "list"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = sm.Migrate([]byte(`["a"]`), "tags", "1.0.0", "")
+	if err == nil || !strings.Contains(err.Error(), "has a list root") {
+		t.Fatalf("expected a root error but got %v", err)
+	}
+}
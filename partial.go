@@ -0,0 +1,190 @@
+package jsontype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// ValidatePartial validates the properties that are present in a document,
+// with the same type and rule checks as Validate, but does not report missing
+// required properties. Nested objects are validated partially too.
+func (s *Schema) ValidatePartial(document []byte) error {
+	return partial(s.Validate(document))
+}
+
+// ValidatePartialValue validates the properties that are present in a Go
+// value, see ValidatePartial and ValidateValue
+func (s *Schema) ValidatePartialValue(value interface{}) error {
+	return partial(s.ValidateValue(value))
+}
+
+// partial removes the errors for missing properties from validation errors
+func partial(err error) error {
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		return err
+	}
+	present := ValidationErrors{}
+	for _, e := range errs {
+		if e.Kind != ErrorMissingProperty {
+			present = append(present, e)
+		}
+	}
+	if len(present) > 0 {
+		return present
+	}
+	return nil
+}
+
+// ValidateProperty validates a single JSON value against the definition of
+// the property at path, such as name, address.zip, tags[0], or [0].name for
+// a schema with an array root. Values of undefined properties are valid if
+// the schema allows them, as is null for optional properties.
+func (s *Schema) ValidateProperty(path string, value []byte) error {
+	if err := checkJSON(value); err != nil {
+		return err
+	}
+	var data interface{}
+	err := json.Unmarshal(value, &data)
+	if err != nil {
+		return err
+	}
+	return s.validatePath(path, data)
+}
+
+// ValidatePropertyValue validates a Go value against the definition of the
+// property at path, see ValidateProperty
+func (s *Schema) ValidatePropertyValue(path string, value interface{}) error {
+	data, err := toJSONValue(value)
+	if err != nil {
+		return err
+	}
+	return s.validatePath(path, data)
+}
+
+// validatePath validates decoded JSON data against the definition of the
+// property at path
+func (s *Schema) validatePath(path string, data interface{}) error {
+	schema, p, optional, err := s.propertyAt(path)
+	if err != nil {
+		return err
+	}
+	if p == nil || optional && data == nil {
+		return nil
+	}
+
+	var errs ValidationErrors
+	err = schema.validateProperty(path, *p, data, &errs)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// propertyAt returns the definition of the property at path, the schema that
+// defines it and whether it is an optional property. The property is nil when
+// the schema does not describe the value, and ValidationErrors are returned
+// for undefined properties.
+func (s *Schema) propertyAt(path string) (*Schema, *Property, bool, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	schema := s
+	var p *Property
+	if !s.hasObjectRoot() {
+		root := s.root()
+		p = &root
+	}
+	optional := false
+	location := ""
+	for _, segment := range segments {
+		switch {
+		case segment.index >= 0:
+			if p == nil || p.Type != "array" && p.Type != "list" {
+				return nil, nil, false, fmt.Errorf("property %s is not an array", location)
+			}
+			if p.Type == "list" || p.Items == nil {
+				return nil, nil, false, nil
+			}
+			p, optional = p.Items, false
+			location = fmt.Sprintf("%s[%d]", location, segment.index)
+			continue
+
+		case p != nil && p.Type != "object":
+			return nil, nil, false, fmt.Errorf("property %s is a %s and has no property %s", location, p.Type, segment.name)
+
+		case p != nil:
+			if p.Ref == "" {
+				return nil, nil, false, nil
+			}
+			schema, err = schema.lookup(p.Ref)
+			if err != nil {
+				return nil, nil, false, err
+			}
+		}
+
+		location = joinPath(location, segment.name)
+		properties, optionalProperties, err := schema.resolveProperties()
+		if err != nil {
+			return nil, nil, false, err
+		}
+		property, ok := properties[segment.name]
+		optional = false
+		if !ok {
+			property, ok = optionalProperties[segment.name]
+			optional = true
+		}
+		if !ok {
+			if schema.AllowUndefinedProperties {
+				return nil, nil, false, nil
+			}
+			return nil, nil, false, ValidationErrors{schema.withMessage(&ValidationError{
+				Path: location,
+				Kind: ErrorUndefinedProperty,
+			}, nil)}
+		}
+		p = &property
+	}
+	return schema, p, optional, nil
+}
+
+// pathSegment is a property name, or an array index when index is not
+// negative, within a path such as address.lines[0]
+type pathSegment struct {
+	name  string
+	index int
+}
+
+// parsePath parses a path in the format used by ValidationError.Path
+func parsePath(path string) ([]pathSegment, error) {
+	segments := []pathSegment{}
+	for i, field := range strings.Split(path, ".") {
+		name, rest, indexed := strings.Cut(field, "[")
+
+		// only a path into an array root starts with an index, such as [0].id
+		switch {
+		case name != "":
+			segments = append(segments, pathSegment{name: name, index: -1})
+		case i > 0 || !indexed:
+			return nil, fmt.Errorf("invalid property path %q", path)
+		}
+		for rest != "" {
+			index, after, ok := strings.Cut(rest, "]")
+			n, err := strconv.Atoi(index)
+			if !ok || err != nil || n < 0 || after != "" && after[0] != '[' {
+				return nil, fmt.Errorf("invalid property path %q", path)
+			}
+			segments = append(segments, pathSegment{index: n})
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,70 @@
+package jsontype_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+func TestSchemaValidatePartial(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, patchSchemas...), "user")
+
+	// test missing required properties are not reported, even when nested
+	err := s.ValidatePartial([]byte(`{"nickname": "ada", "address": {"zip": "N1"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.ValidatePartial([]byte(`{"name": "Ada Lovelace", "address": {"zip": 1}, "age": 36}`))
+	var errs jsontype.ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("expected 3 validation errors but got %v", err)
+	}
+	for _, e := range errs {
+		if e.Kind == jsontype.ErrorMissingProperty {
+			t.Fatalf("unexpected error %v", e)
+		}
+	}
+
+	err = s.ValidatePartialValue(map[string]interface{}{"scores": []interface{}{1, "two"}})
+	if !errors.As(err, &errs) || errs[0].Path != "scores" {
+		t.Fatalf("expected a validation error but got %v", err)
+	}
+}
+
+func TestSchemaValidateProperty(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, patchSchemas...), "user")
+
+	for path, value := range map[string]string{
+		"name":         `"Ada"`,
+		"nickname":     `null`,
+		"address.city": `"London"`,
+		"scores[1]":    `2`,
+		"meta.any":     `[1, "a"]`,
+	} {
+		if err := s.ValidateProperty(path, []byte(value)); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+
+	for path, value := range map[string]string{
+		"name":         `"Ada Lovelace"`,
+		"address.city": `null`,
+		"address.zip":  `1`,
+		"scores[0]":    `"one"`,
+		"age":          `36`,
+	} {
+		var errs jsontype.ValidationErrors
+		if err := s.ValidateProperty(path, []byte(value)); !errors.As(err, &errs) || errs[0].Path != path {
+			t.Fatalf("%s: expected a validation error but got %v", path, err)
+		}
+	}
+
+	// test invalid paths
+	for _, path := range []string{"", "name.first", "name[0]", "scores[x]"} {
+		if err := s.ValidatePropertyValue(path, "Ada"); err == nil {
+			t.Fatalf("%s: expected error", path)
+		}
+	}
+}
@@ -0,0 +1,515 @@
+package jsontype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// ApplyPatch applies an RFC 6902 JSON Patch to a document and validates the
+// result against the schema. Every operation's path is checked against the
+// schema before the patch is applied, PatchErrors are returned for operations
+// on properties the schema does not define, operations that remove required
+// properties and operations that cannot be applied. ValidationErrors are
+// returned if the patched document does not satisfy the schema.
+func (s *Schema) ApplyPatch(document, patch []byte) ([]byte, error) {
+	operations := []PatchOperation{}
+	err := checkJSON(patch)
+	if err == nil {
+		err = json.Unmarshal(patch, &operations)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON Patch: %w", err)
+	}
+	data, err := decodeDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs PatchErrors
+	values := make([]interface{}, len(operations))
+	for i, operation := range operations {
+		values[i], err = s.checkOperation(operation)
+		if err != nil {
+			errs = append(errs, &PatchError{Index: i, Op: operation.Op, Path: operation.Path, Message: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	for i, operation := range operations {
+		data, err = applyOperation(data, operation, values[i])
+		if err != nil {
+			return nil, PatchErrors{{Index: i, Op: operation.Op, Path: operation.Path, Message: err.Error()}}
+		}
+	}
+
+	err = s.validateDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+// ApplyMergePatch applies an RFC 7386 Merge Patch to a document and validates
+// the result against the schema. The patch is checked against the schema
+// before it is applied, PatchErrors are returned for properties the schema
+// does not define and for required properties the patch removes.
+// ValidationErrors are returned if the patched document does not satisfy the
+// schema.
+func (s *Schema) ApplyMergePatch(document, patch []byte) ([]byte, error) {
+	data, err := decodeDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	merge, err := decodeDocument(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Merge Patch: %w", err)
+	}
+
+	var errs PatchErrors
+	if object, ok := merge.(map[string]interface{}); ok {
+		err = s.checkMergePatch("", object, &errs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	data = mergePatch(data, merge)
+	err = s.validateDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+// checkOperation checks an operation against the schema and returns its
+// decoded value
+func (s *Schema) checkOperation(operation PatchOperation) (interface{}, error) {
+	var value interface{}
+	switch operation.Op {
+	case "add", "replace", "test":
+		if operation.Value == nil {
+			return nil, fmt.Errorf("%s operations must have a value", operation.Op)
+		}
+		var err error
+		value, err = decodeDocument(operation.Value)
+		if err != nil {
+			return nil, err
+		}
+	case "remove", "move", "copy":
+	default:
+		return nil, fmt.Errorf("unknown operation %q", operation.Op)
+	}
+
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+	if operation.Op == "test" {
+		return value, nil
+	}
+	target, err := s.pointerTarget(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace":
+		// optional properties may be null, as they are when validated
+		if value == nil && !target.required {
+			return value, nil
+		}
+		if target.property != nil && !IsType(value, target.property.Type) {
+			return nil, fmt.Errorf("%s must be of type %s but got %s", operation.Path, target.property.Type, jsonKind(value))
+		}
+	case "remove":
+		if target.required {
+			return nil, fmt.Errorf("cannot remove required property %s", operation.Path)
+		}
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		source, err := s.pointerTarget(from)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == "move" && source.required {
+			return nil, fmt.Errorf("cannot move required property %s", operation.From)
+		}
+		if operation.Op == "move" && hasPrefix(path, from) {
+			return nil, fmt.Errorf("cannot move %s into itself", operation.From)
+		}
+	}
+	return value, nil
+}
+
+// pointerTarget describes the value a JSON Pointer refers to within documents
+// that satisfy a schema. property is nil when the schema does not describe
+// the value, and required is true for required properties.
+type pointerTarget struct {
+	property *Property
+	required bool
+}
+
+// pointerTarget checks that a JSON Pointer refers to a value the schema
+// allows, values within undefined properties and objects without a schema
+// are not checked
+func (s *Schema) pointerTarget(path []string) (pointerTarget, error) {
+	target := pointerTarget{}
+	schema := s
+	if !s.hasObjectRoot() {
+		// the document itself is described by the root property
+		root := s.root()
+		target = pointerTarget{property: &root, required: true}
+		schema = nil
+		if root.Type == "object" && len(path) > 0 {
+			ref, err := s.lookup(root.Ref)
+			if err != nil {
+				return target, err
+			}
+			schema = ref
+		}
+	}
+	for i, token := range path {
+		location := joinPointer(path[:i])
+		if location == "" {
+			location = "document"
+		}
+		switch {
+		case schema != nil:
+			properties, optionalProperties, err := schema.resolveProperties()
+			if err != nil {
+				return target, err
+			}
+			target = pointerTarget{}
+			if p, ok := properties[token]; ok {
+				target = pointerTarget{property: &p, required: true}
+			} else if p, ok := optionalProperties[token]; ok {
+				target = pointerTarget{property: &p}
+			} else if !schema.AllowUndefinedProperties {
+				return target, fmt.Errorf("property %s is not defined in schema %s", joinPointer(path[:i+1]), schema.Type)
+			}
+			schema = nil
+
+		case target.property == nil || target.property.Type == "object":
+			return pointerTarget{}, nil
+
+		case target.property.Type == "array" || target.property.Type == "list":
+			if token != "-" {
+				if _, err := parseIndex(token); err != nil {
+					return target, fmt.Errorf("%s is %s and %s is not an index", location, withArticle(target.property.Type), token)
+				}
+			}
+
+			// only the items of arrays are validated
+			if target.property.Type == "array" {
+				target = pointerTarget{property: target.property.Items}
+			} else {
+				target = pointerTarget{}
+			}
+
+		default:
+			return target, fmt.Errorf("%s is %s and has no property %s", location, withArticle(target.property.Type), token)
+		}
+
+		// the properties of objects with a ref are checked against its schema
+		if p := target.property; p != nil && p.Type == "object" && p.Ref != "" && i < len(path)-1 {
+			ref, err := s.lookup(p.Ref)
+			if err != nil {
+				return target, err
+			}
+			schema = ref
+		}
+	}
+	return target, nil
+}
+
+// checkMergePatch checks the properties of a Merge Patch object against the
+// schema, adding an error for every problem to errs
+func (s *Schema) checkMergePatch(path string, patch map[string]interface{}, errs *PatchErrors) error {
+	properties, optionalProperties, err := s.resolveProperties()
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(patch) {
+		pointer := path + "/" + escapePointer(name)
+		value := patch[name]
+		p, required := properties[name]
+		if !required {
+			var ok bool
+			p, ok = optionalProperties[name]
+			if !ok {
+				if !s.AllowUndefinedProperties {
+					*errs = append(*errs, &PatchError{Op: "merge", Path: pointer, Message: fmt.Sprintf("property %s is not defined in schema %s", pointer, s.Type)})
+				}
+				continue
+			}
+		}
+		if value == nil && required {
+			*errs = append(*errs, &PatchError{Op: "merge", Path: pointer, Message: fmt.Sprintf("cannot remove required property %s", pointer)})
+			continue
+		}
+		if object, ok := value.(map[string]interface{}); ok && p.Type == "object" && p.Ref != "" {
+			ref, err := s.lookup(p.Ref)
+			if err != nil {
+				return err
+			}
+			if err := ref.checkMergePatch(pointer, object, errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergePatch applies a Merge Patch to a value
+func mergePatch(target, patch interface{}) interface{} {
+	object, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for name, value := range object {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// applyOperation applies a JSON Patch operation to a document and returns the
+// patched document
+func applyOperation(document interface{}, operation PatchOperation, value interface{}) (interface{}, error) {
+	path, _ := parsePointer(operation.Path)
+	switch operation.Op {
+	case "add":
+		return addPointer(document, path, value)
+	case "remove":
+		document, _, err := removePointer(document, path)
+		return document, err
+	case "replace":
+		if _, err := getPointer(document, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		document, _, err := removePointer(document, path)
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(document, path, value)
+	case "test":
+		current, err := getPointer(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(current, value) {
+			return nil, fmt.Errorf("test failed, %s is %s", operation.Path, formatValue(current))
+		}
+		return document, nil
+	}
+
+	from, _ := parsePointer(operation.From)
+	if operation.Op == "copy" {
+		value, err := getPointer(document, from)
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(document, path, copyValue(value))
+	}
+	document, value, err := removePointer(document, from)
+	if err != nil {
+		return nil, err
+	}
+	return addPointer(document, path, value)
+}
+
+// parsePointer parses an RFC 6901 JSON Pointer into its reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q, it must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// joinPointer formats reference tokens as a JSON Pointer
+func joinPointer(tokens []string) string {
+	pointer := ""
+	for _, token := range tokens {
+		pointer += "/" + escapePointer(token)
+	}
+	return pointer
+}
+
+// hasPrefix reports whether path is within the value at prefix
+func hasPrefix(path, prefix []string) bool {
+	if len(path) <= len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseIndex parses an array index, which cannot have leading zeros
+func parseIndex(token string) (int, error) {
+	if token == "" || len(token) > 1 && token[0] == '0' {
+		return 0, fmt.Errorf("invalid index %q", token)
+	}
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid index %q", token)
+		}
+	}
+	return strconv.Atoi(token)
+}
+
+// getPointer returns the value at path within a document
+func getPointer(document interface{}, path []string) (interface{}, error) {
+	value := document
+	for i, token := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			child, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", joinPointer(path[:i+1]))
+			}
+			value = child
+		case []interface{}:
+			index, err := parseIndex(token)
+			if err != nil || index >= len(v) {
+				return nil, fmt.Errorf("%s does not exist", joinPointer(path[:i+1]))
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("%s does not exist", joinPointer(path[:i+1]))
+		}
+	}
+	return value, nil
+}
+
+// updatePointer replaces the container that holds the value at path with the
+// result of update, and returns the updated document
+func updatePointer(document interface{}, path []string, update func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return update(document, path[0])
+	}
+	child, err := getPointer(document, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updatePointer(child, path[1:], update)
+	if err != nil {
+		return nil, err
+	}
+	switch v := document.(type) {
+	case map[string]interface{}:
+		v[path[0]] = child
+	case []interface{}:
+		index, _ := parseIndex(path[0])
+		v[index] = child
+	}
+	return document, nil
+}
+
+// addPointer adds a value at path within a document, inserting it into
+// arrays, and returns the updated document
+func addPointer(document interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updatePointer(document, path, func(container interface{}, token string) (interface{}, error) {
+		switch v := container.(type) {
+		case map[string]interface{}:
+			v[token] = value
+			return v, nil
+		case []interface{}:
+			if token == "-" {
+				return append(v, value), nil
+			}
+			index, err := parseIndex(token)
+			if err != nil || index > len(v) {
+				return nil, fmt.Errorf("%s is out of range", joinPointer(path))
+			}
+			v = append(v, nil)
+			copy(v[index+1:], v[index:])
+			v[index] = value
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s is not an object or array", joinPointer(path[:len(path)-1]))
+	})
+}
+
+// removePointer removes the value at path within a document, and returns the
+// updated document and the removed value
+func removePointer(document interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the document")
+	}
+	var removed interface{}
+	document, err := updatePointer(document, path, func(container interface{}, token string) (interface{}, error) {
+		switch v := container.(type) {
+		case map[string]interface{}:
+			value, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", joinPointer(path))
+			}
+			removed = value
+			delete(v, token)
+			return v, nil
+		case []interface{}:
+			index, err := parseIndex(token)
+			if err != nil || index >= len(v) {
+				return nil, fmt.Errorf("%s does not exist", joinPointer(path))
+			}
+			removed = v[index]
+			return append(v[:index:index], v[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("%s does not exist", joinPointer(path))
+	})
+	return document, removed, err
+}
+
+// copyValue returns a deep copy of a decoded JSON value
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	}
+	return value
+}
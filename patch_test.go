@@ -0,0 +1,177 @@
+package jsontype_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+var patchSchemas = []string{
+	`{"type": "Address", "properties": {"city": {"type": "string"}}, "optional_properties": {"zip": {"type": "string"}}}`,
+	`{"type": "User", "properties": {
+		"name": {"type": "string", "rules": {"max_length": 10}},
+		"address": {"type": "object", "ref": "Address"},
+		"scores": {"type": "array", "items": {"type": "number"}}
+	}, "optional_properties": {"nickname": {"type": "string"}, "meta": {"type": "object"}}}`,
+}
+
+const patchDocument = `{"name": "Ada", "nickname": "ada", "address": {"city": "London"}, "scores": [1, 2]}`
+
+// applyPatch applies a JSON patch to the patch document
+func applyPatch(t *testing.T, patch string) (string, error) {
+	t.Helper()
+	s := getSchema(t, loadSchemas(t, patchSchemas...), "user")
+	patched, err := s.ApplyPatch([]byte(patchDocument), []byte(patch))
+	return string(patched), err
+}
+
+// expectPatchErrors expects an error to be PatchErrors with the messages
+func expectPatchErrors(t *testing.T, err error, messages ...string) {
+	t.Helper()
+	var errs jsontype.PatchErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected patch errors but got %v", err)
+	}
+	if len(errs) != len(messages) {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	for i, e := range errs {
+		if e.Error() != messages[i] {
+			t.Fatalf("expected %q but got %q", messages[i], e.Error())
+		}
+	}
+}
+
+func TestSchemaApplyPatch(t *testing.T) {
+	patched, err := applyPatch(t, `[
+		{"op": "test", "path": "/name", "value": "Ada"},
+		{"op": "replace", "path": "/name", "value": "Grace"},
+		{"op": "add", "path": "/scores/0", "value": 0},
+		{"op": "remove", "path": "/scores/2"}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"address":{"city":"London"},"name":"Grace","nickname":"ada","scores":[0,1]}`
+	if patched != expected {
+		t.Fatalf("unexpected document %s", patched)
+	}
+}
+
+func TestSchemaApplyPatchMoveCopy(t *testing.T) {
+	patched, err := applyPatch(t, `[
+		{"op": "move", "from": "/nickname", "path": "/address/zip"},
+		{"op": "add", "path": "/meta", "value": {}},
+		{"op": "copy", "from": "/address", "path": "/meta/previous"}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"address":{"city":"London","zip":"ada"},"meta":{"previous":{"city":"London","zip":"ada"}},"name":"Ada","scores":[1,2]}`
+	if patched != expected {
+		t.Fatalf("unexpected document %s", patched)
+	}
+}
+
+func TestSchemaApplyPatchNullOptional(t *testing.T) {
+	// test optional properties may be set to null
+	patched, err := applyPatch(t, `[
+		{"op": "replace", "path": "/nickname", "value": null},
+		{"op": "add", "path": "/address/zip", "value": null}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"address":{"city":"London","zip":null},"name":"Ada","nickname":null,"scores":[1,2]}`
+	if patched != expected {
+		t.Fatalf("unexpected document %s", patched)
+	}
+
+	// test required properties may not
+	_, err = applyPatch(t, `[{"op": "replace", "path": "/name", "value": null}]`)
+	expectPatchErrors(t, err, "operation 0 (replace /name): /name must be of type string but got null")
+}
+
+func TestSchemaApplyPatchChecked(t *testing.T) {
+	// test every operation is checked against the schema before applying
+	_, err := applyPatch(t, `[
+		{"op": "add", "path": "/age", "value": 42},
+		{"op": "remove", "path": "/address/city"},
+		{"op": "add", "path": "/scores/-", "value": "three"},
+		{"op": "replace", "path": "/name/first", "value": "Ada"},
+		{"op": "remove", "path": "/meta/anything"},
+		{"op": "jump", "path": "/name"}
+	]`)
+	expectPatchErrors(t, err,
+		"operation 0 (add /age): property /age is not defined in schema User",
+		"operation 1 (remove /address/city): cannot remove required property /address/city",
+		"operation 2 (add /scores/-): /scores/- must be of type number but got string",
+		"operation 3 (replace /name/first): /name is a string and has no property first",
+		`operation 5 (jump /name): unknown operation "jump"`,
+	)
+}
+
+func TestSchemaApplyPatchFailedTest(t *testing.T) {
+	_, err := applyPatch(t, `[{"op": "test", "path": "/name", "value": "Bob"}]`)
+	var errs jsontype.PatchErrors
+	if !errors.As(err, &errs) || errs[0].Index != 0 {
+		t.Fatalf("expected a failed test but got %v", err)
+	}
+}
+
+func TestSchemaApplyPatchInvalid(t *testing.T) {
+	// test patched documents are validated
+	_, err := applyPatch(t, `[{"op": "replace", "path": "/name", "value": "Ada Lovelace"}]`)
+	var errs jsontype.ValidationErrors
+	if !errors.As(err, &errs) || errs[0].Rule != "max_length" {
+		t.Fatalf("expected a validation error but got %v", err)
+	}
+}
+
+func TestSchemaApplyPatchArrayRoot(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, append(patchSchemas, `{"type": "Addresses", "root": {"type": "array", "items": {"type": "object", "ref": "Address"}}}`)...), "addresses")
+	patched, err := s.ApplyPatch([]byte(`[{"city": "London"}]`), []byte(`[
+		{"op": "add", "path": "/-", "value": {"city": "Paris"}},
+		{"op": "add", "path": "/0/zip", "value": "N1"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(patched) != `[{"city":"London","zip":"N1"},{"city":"Paris"}]` {
+		t.Fatalf("unexpected document %s", patched)
+	}
+
+	// test operations are checked against the items of the root
+	_, err = s.ApplyPatch([]byte(`[{"city": "London"}]`), []byte(`[
+		{"op": "add", "path": "/-", "value": "Paris"},
+		{"op": "remove", "path": "/0/city"},
+		{"op": "add", "path": "/first", "value": {"city": "Paris"}}
+	]`))
+	expectPatchErrors(t, err,
+		"operation 0 (add /-): /- must be of type object but got string",
+		"operation 1 (remove /0/city): cannot remove required property /0/city",
+		"operation 2 (add /first): document is an array and first is not an index",
+	)
+}
+
+func TestSchemaApplyMergePatch(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, patchSchemas...), "user")
+	patched, err := s.ApplyMergePatch([]byte(patchDocument), []byte(`{"nickname": null, "address": {"zip": "N1"}, "scores": [3]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"address":{"city":"London","zip":"N1"},"name":"Ada","scores":[3]}`
+	if string(patched) != expected {
+		t.Fatalf("unexpected document %s", patched)
+	}
+}
+
+func TestSchemaApplyMergePatchChecked(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, patchSchemas...), "user")
+	_, err := s.ApplyMergePatch([]byte(patchDocument), []byte(`{"name": null, "address": {"city": null, "street": "Main"}}`))
+	var errs jsontype.PatchErrors
+	if !errors.As(err, &errs) || len(errs) != 3 || errs[0].Path != "/address/city" || errs[2].Path != "/name" {
+		t.Fatalf("unexpected errors %v", err)
+	}
+}
@@ -0,0 +1,436 @@
+package jsontype
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-reflect"
+)
+
+// RegistryOptions configures a Registry
+type RegistryOptions struct {
+	// Compatibility is the compatibility a new version of a schema must have
+	// with the version before it, it defaults to CompatibilityBackward.
+	// Clients may override it with the compatibility query parameter.
+	Compatibility Compatibility
+
+	// MaxBodySize is the largest request body in bytes that is read, it
+	// defaults to DefaultMaxBodySize
+	MaxBodySize int64
+}
+
+// A Registry is an http.Handler that serves the schemas of a SchemaManager
+// over REST, and persists registered schemas to a Store. The endpoints are:
+//
+//	GET    /schemas                  list schema types and their versions
+//	POST   /schemas                  register a schema
+//	GET    /schemas/{ref}            get a schema, such as order or order@^1.2
+//	GET    /schemas/{type}/versions  list the versions of a schema type
+//	DELETE /schemas/{ref}            delete a schema version, or every version
+//	POST   /schemas/{ref}/validate   validate a document against a schema
+type Registry struct {
+	sm      *SchemaManager
+	store   Store
+	options RegistryOptions
+
+	// mu serializes changes, so compatibility is checked against the schemas
+	// that are registered when the change is made
+	mu sync.Mutex
+}
+
+// NewRegistry creates a Registry, and loads every schema held by the store
+// into the SchemaManager. When the SchemaManager already uses the store, see
+// UseStore, schemas are written through it rather than written twice.
+func NewRegistry(sm *SchemaManager, store Store, options RegistryOptions) (*Registry, error) {
+	if options.Compatibility == "" {
+		options.Compatibility = CompatibilityBackward
+	}
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = DefaultMaxBodySize
+	}
+	reg := &Registry{sm: sm, store: store, options: options}
+	if !reg.writesThrough() {
+		err := sm.loadStore(context.Background(), store)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return reg, nil
+}
+
+// writesThrough reports whether the SchemaManager writes its schemas through
+// to the registry's store itself
+func (reg *Registry) writesThrough() bool {
+	ss := reg.sm.storeSync()
+	if ss == nil || !reflect.TypeOf(ss.store).Comparable() || !reflect.TypeOf(reg.store).Comparable() {
+		return false
+	}
+	return ss.store == reg.store
+}
+
+// RegistrySchema describes a schema type in the list of registered schemas
+type RegistrySchema struct {
+	Type     string   `json:"type"`
+	Latest   string   `json:"latest,omitempty"`
+	Versions []string `json:"versions"`
+}
+
+// RegistrationResult is the response to registering a schema
+type RegistrationResult struct {
+	Schema string               `json:"schema"`
+	Report *CompatibilityReport `json:"report,omitempty"`
+}
+
+// ValidationResult is the response to validating a document
+type ValidationResult struct {
+	Valid  bool             `json:"valid"`
+	Errors ValidationErrors `json:"errors,omitempty"`
+}
+
+func (reg *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	if path != "schemas" && !strings.HasPrefix(path, "schemas/") {
+		reg.problem(w, r, http.StatusNotFound, "")
+		return
+	}
+	ref, action, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(path, "schemas"), "/"), "/")
+
+	switch {
+	case ref == "" && r.Method == http.MethodGet:
+		reg.list(w, r)
+	case ref == "" && r.Method == http.MethodPost:
+		reg.register(w, r)
+	case ref != "" && action == "" && r.Method == http.MethodGet:
+		reg.get(w, r, ref)
+	case ref != "" && action == "" && r.Method == http.MethodDelete:
+		reg.delete(w, r, ref)
+	case ref != "" && action == "versions" && r.Method == http.MethodGet:
+		reg.versions(w, r, ref)
+	case ref != "" && action == "validate" && r.Method == http.MethodPost:
+		reg.validate(w, r, ref)
+	case ref == "" || action == "" || action == "versions" || action == "validate":
+		w.Header().Set("Allow", allowedMethods(ref, action))
+		reg.problem(w, r, http.StatusMethodNotAllowed, "")
+	default:
+		reg.problem(w, r, http.StatusNotFound, "")
+	}
+}
+
+// allowedMethods returns the methods of an endpoint
+func allowedMethods(ref, action string) string {
+	switch {
+	case ref == "":
+		return "GET, POST"
+	case action == "":
+		return "GET, DELETE"
+	case action == "versions":
+		return "GET"
+	}
+	return "POST"
+}
+
+func (reg *Registry) list(w http.ResponseWriter, r *http.Request) {
+	reg.sm.mu.RLock()
+	schemas := []RegistrySchema{}
+	for _, schemaType := range sortedKeys(reg.sm.versions) {
+		versions := reg.sm.versions[schemaType]
+		schema := RegistrySchema{Type: versions[0].Type, Latest: reg.sm.Schemas[schemaType].Version, Versions: []string{}}
+		for _, s := range versions {
+			if s.Version != "" {
+				schema.Versions = append(schema.Versions, s.Version)
+			}
+		}
+		schemas = append(schemas, schema)
+	}
+	reg.sm.mu.RUnlock()
+	writeJSON(w, http.StatusOK, schemas)
+}
+
+func (reg *Registry) get(w http.ResponseWriter, r *http.Request, ref string) {
+	s, err := reg.sm.GetSchema(ref)
+	if err != nil {
+		reg.problem(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, s.String())
+}
+
+func (reg *Registry) versions(w http.ResponseWriter, r *http.Request, schemaType string) {
+	if _, err := reg.sm.GetSchema(schemaType); err != nil {
+		reg.problem(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, reg.sm.ListVersions(schemaType))
+}
+
+// register checks a schema is compatible with the version before it, then
+// loads and stores it. A schema with the same type and version as a registered
+// one is only replaced when the replace query parameter is true.
+func (reg *Registry) register(w http.ResponseWriter, r *http.Request) {
+	def, ok := reg.readBody(w, r)
+	if !ok {
+		return
+	}
+	required, ok := reg.compatibility(w, r)
+	if !ok {
+		return
+	}
+	replace := r.URL.Query().Get("replace") == "true"
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	// the schema is loaded into a copy of the SchemaManager first, so it can
+	// be compared with the version before it
+	staging := NewSchemaManager()
+	reg.sm.mu.RLock()
+	for _, versions := range reg.sm.versions {
+		for _, s := range versions {
+			staging.storeSchema(s)
+		}
+	}
+	reg.sm.mu.RUnlock()
+
+	header, err := decodeSchema(def)
+	if err != nil {
+		reg.problem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errs := staging.loadSchemas(map[string][]byte{"schema": def}, true); len(errs) > 0 {
+		reg.problem(w, r, http.StatusBadRequest, errs[0].Err.Error())
+		return
+	}
+	s := staging.exactVersion(header.Type, header.Version)
+
+	reg.sm.mu.RLock()
+	existing := reg.sm.exactVersion(s.Type, s.Version)
+	previous := existing
+	if previous == nil {
+		for _, v := range reg.sm.versions[strings.ToLower(s.Type)] {
+			if compareSchemaVersions(v.Version, s.Version) < 0 {
+				previous = v
+			}
+		}
+	} else if !replace {
+		reg.sm.mu.RUnlock()
+		reg.problem(w, r, http.StatusConflict, fmt.Sprintf("schema %s is already registered", s.ref()))
+		return
+	}
+	reg.sm.mu.RUnlock()
+
+	result := &RegistrationResult{Schema: s.ref()}
+	if previous != nil {
+		report, err := CompareSchemas(previous, s)
+		if err != nil {
+			reg.problem(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := report.Check(required); err != nil {
+			writeJSON(w, http.StatusConflict, report)
+			return
+		}
+		result.Report = report
+	}
+
+	// the schema is only stored once the SchemaManager accepts it, and is
+	// unloaded again if it cannot be stored
+	err = reg.sm.LoadSchema(def, Replace)
+	if err != nil {
+		reg.problem(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !reg.writesThrough() {
+		err = reg.store.Put(r.Context(), storeKey(s), def)
+		if err != nil {
+			reg.sm.mu.Lock()
+			if existing != nil {
+				reg.sm.storeSchema(existing)
+			} else {
+				reg.sm.removeSchema(s.Type, s.Version)
+			}
+			reg.sm.mu.Unlock()
+			reg.problem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// delete deletes a single version of a schema, or every version when ref
+// does not name a version. Schemas that other schemas extend or reference are
+// not deleted, nor is a version whose neighbours are not compatible with each
+// other.
+func (reg *Registry) delete(w http.ResponseWriter, r *http.Request, ref string) {
+	required, ok := reg.compatibility(w, r)
+	if !ok {
+		return
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	schemaType, version, hasVersion := strings.Cut(ref, "@")
+	reg.sm.mu.RLock()
+	versions := reg.sm.versions[strings.ToLower(schemaType)]
+	deleted := map[*Schema]bool{}
+	var before, after *Schema
+	for i, s := range versions {
+		if !hasVersion || s.Version == version {
+			deleted[s] = true
+			if hasVersion && i > 0 && i < len(versions)-1 {
+				before, after = versions[i-1], versions[i+1]
+			}
+		}
+	}
+	dependent, dependency := reg.sm.dependentOf(deleted)
+	reg.sm.mu.RUnlock()
+
+	if len(deleted) == 0 {
+		reg.problem(w, r, http.StatusNotFound, fmt.Sprintf("schema %s not found", ref))
+		return
+	}
+	if dependent != nil {
+		reg.problem(w, r, http.StatusConflict, fmt.Sprintf("schema %s depends on %s", dependent.ref(), dependency))
+		return
+	}
+
+	// the versions either side of a deleted version become neighbours, so
+	// they must be compatible with each other
+	if before != nil {
+		report, err := CompareSchemas(before, after)
+		if err != nil {
+			reg.problem(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err := report.Check(required); err != nil {
+			writeJSON(w, http.StatusConflict, report)
+			return
+		}
+	}
+
+	if !reg.writesThrough() {
+		for _, s := range versions {
+			if !deleted[s] {
+				continue
+			}
+			err := reg.store.Delete(r.Context(), storeKey(s))
+			if err != nil && !errors.Is(err, ErrSchemaNotFound) {
+				reg.problem(w, r, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+	}
+	err := reg.sm.DeleteSchema(ref)
+	if err != nil {
+		reg.problem(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// compatibility returns the compatibility required of a change, which may be
+// overridden by the compatibility query parameter
+func (reg *Registry) compatibility(w http.ResponseWriter, r *http.Request) (Compatibility, bool) {
+	c := r.URL.Query().Get("compatibility")
+	if c == "" {
+		return reg.options.Compatibility, true
+	}
+	switch required := Compatibility(c); required {
+	case CompatibilityBackward, CompatibilityForward, CompatibilityFull, CompatibilityNone:
+		return required, true
+	}
+	reg.problem(w, r, http.StatusBadRequest, fmt.Sprintf("unknown compatibility %q", c))
+	return "", false
+}
+
+// dependentOf returns a schema, other than the given schemas, that extends or
+// references one of them, along with the reference it uses. The caller must
+// hold sm.mu.
+func (sm *SchemaManager) dependentOf(schemas map[*Schema]bool) (*Schema, string) {
+	for _, schemaType := range sortedKeys(sm.versions) {
+		for _, s := range sm.versions[schemaType] {
+			if schemas[s] {
+				continue
+			}
+			refs := s.references()
+			if s.Extends != "" {
+				refs = append([]string{s.Extends}, refs...)
+			}
+			for _, ref := range refs {
+				if target, err := sm.resolve(ref); err == nil && schemas[target] {
+					return s, ref
+				}
+			}
+		}
+	}
+	return nil, ""
+}
+
+func (reg *Registry) validate(w http.ResponseWriter, r *http.Request, ref string) {
+	s, err := reg.sm.GetSchema(ref)
+	if err != nil {
+		reg.problem(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	document, ok := reg.readBody(w, r)
+	if !ok {
+		return
+	}
+	data, err := decodeDocument(document)
+	if err != nil {
+		reg.problem(w, r, http.StatusBadRequest, "the request body is not valid JSON")
+		return
+	}
+
+	err = s.validateDocument(data)
+	var errs ValidationErrors
+	if err != nil && !errors.As(err, &errs) {
+		reg.problem(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, ValidationResult{Valid: err == nil, Errors: errs})
+}
+
+// readBody reads a request body, writing a problem if it is too large
+func (reg *Registry) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, reg.options.MaxBodySize+1))
+	if err != nil {
+		reg.problem(w, r, http.StatusBadRequest, "the request body could not be read")
+		return nil, false
+	}
+	if int64(len(body)) > reg.options.MaxBodySize {
+		reg.problem(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("the request body is larger than %d bytes", reg.options.MaxBodySize))
+		return nil, false
+	}
+	return body, true
+}
+
+func (reg *Registry) problem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	WriteProblem(w, r, &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+// writeJSON writes a value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
@@ -0,0 +1,206 @@
+package jsontype_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/apageadev/jsontype"
+)
+
+// useStore creates a SchemaManager that uses a store, until the test ends
+func useStore(t *testing.T, store jsontype.Store) *jsontype.SchemaManager {
+	t.Helper()
+	sm := jsontype.NewSchemaManager()
+	ss, err := sm.UseStore(store, jsontype.StoreOptions{PollInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ss.Close() })
+	return sm
+}
+
+// newRegistry creates a Registry backed by a store
+func newRegistry(t *testing.T, sm *jsontype.SchemaManager, store jsontype.Store) *jsontype.Registry {
+	t.Helper()
+	reg, err := jsontype.NewRegistry(sm, store, jsontype.RegistryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+func TestRegistry(t *testing.T) {
+	store, err := jsontype.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Put(context.Background(), "address", []byte(`{"type": "Address", "properties": {"city": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := newRegistry(t, jsontype.NewSchemaManager(), store)
+
+	v1 := `{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string"}, "address": {"type": "object", "ref": "Address"}}}`
+	v2 := `{"type": "Order", "version": "1.1.0", "properties": {"id": {"type": "string"}, "address": {"type": "object", "ref": "Address"}}, "optional_properties": {"note": {"type": "string"}}}`
+	breaking := `{"type": "Order", "version": "2.0.0", "properties": {"id": {"type": "number"}}}`
+	for _, test := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{"POST", "/schemas", v1, http.StatusCreated},
+		{"POST", "/schemas", v1, http.StatusConflict},
+		{"POST", "/schemas", v2, http.StatusCreated},
+		{"POST", "/schemas", breaking, http.StatusConflict},
+		{"POST", "/schemas?compatibility=none", breaking, http.StatusCreated},
+		{"POST", "/schemas", `{"type": "Invoice", "extends": "Unknown", "properties": {}}`, http.StatusBadRequest},
+		{"GET", "/schemas/order@^1", "", http.StatusOK},
+		{"GET", "/schemas/invoice", "", http.StatusNotFound},
+		{"PUT", "/schemas/order", "", http.StatusMethodNotAllowed},
+		{"DELETE", "/schemas/order@2.0.0", "", http.StatusNoContent},
+		{"DELETE", "/schemas/order@2.0.0", "", http.StatusNotFound},
+	} {
+		w := serve(reg, test.method, test.path, "application/json", test.body)
+		if w.Code != test.status {
+			t.Fatalf("%s %s: expected status %d but got %d %s", test.method, test.path, test.status, w.Code, w.Body)
+		}
+	}
+
+	w := serve(reg, "GET", "/schemas", "", "")
+	var schemas []jsontype.RegistrySchema
+	if err := json.Unmarshal(w.Body.Bytes(), &schemas); err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != 2 || schemas[1].Type != "Order" || schemas[1].Latest != "1.1.0" || len(schemas[1].Versions) != 2 {
+		t.Fatalf("unexpected schemas %s", w.Body)
+	}
+
+	w = serve(reg, "POST", "/schemas/order@1.0.0/validate", "application/json", `{"id": 1, "address": {"city": "London"}}`)
+	var result jsontype.ValidationResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || result.Valid || len(result.Errors) != 1 || result.Errors[0].Path != "id" {
+		t.Fatalf("unexpected result %s", w.Body)
+	}
+
+	// test a new registry loads the stored schemas
+	reg = newRegistry(t, jsontype.NewSchemaManager(), store)
+	keys, _ := store.List(context.Background())
+	if len(keys) != 3 {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	w = serve(reg, "GET", "/schemas/order/versions", "", "")
+	if w.Body.String() != `["1.0.0","1.1.0"]` {
+		t.Fatalf("unexpected versions %s", w.Body)
+	}
+}
+
+// countingStore is a MemoryStore that counts writes
+type countingStore struct {
+	*jsontype.MemoryStore
+	puts, deletes int
+}
+
+func (s *countingStore) Put(ctx context.Context, key string, def []byte) error {
+	s.puts++
+	return s.MemoryStore.Put(ctx, key, def)
+}
+
+func (s *countingStore) Delete(ctx context.Context, key string) error {
+	s.deletes++
+	return s.MemoryStore.Delete(ctx, key)
+}
+
+func TestRegistryStore(t *testing.T) {
+	store := &countingStore{MemoryStore: jsontype.NewMemoryStore()}
+	reg := newRegistry(t, useStore(t, store), store)
+
+	// test schemas are written once when the manager uses the same store
+	w := serve(reg, "POST", "/schemas", "application/json", `{"type": "Order", "properties": {"id": {"type": "string"}}}`)
+	if w.Code != http.StatusCreated || store.puts != 1 {
+		t.Fatalf("unexpected response %d %s after %d puts", w.Code, w.Body, store.puts)
+	}
+	w = serve(reg, "DELETE", "/schemas/order", "", "")
+	if w.Code != http.StatusNoContent || store.deletes != 1 {
+		t.Fatalf("unexpected response %d %s after %d deletes", w.Code, w.Body, store.deletes)
+	}
+
+	// test rejected schemas are not written
+	for _, def := range []string{
+		`{"type": "Invoice", "extends": "Unknown", "properties": {}}`,
+		`{"type": "Invoice", "properties": {"order": {"type": "object", "ref": "Unknown"}}}`,
+	} {
+		w = serve(reg, "POST", "/schemas", "application/json", def)
+		if w.Code != http.StatusBadRequest || store.puts != 1 {
+			t.Fatalf("unexpected response %d %s after %d puts", w.Code, w.Body, store.puts)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := jsontype.NewMemoryStore()
+	if err := store.Put(ctx, "order@1.0.0", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if def, err := store.Get(ctx, "order@1.0.0"); err != nil || string(def) != `{}` {
+		t.Fatalf("unexpected definition %s %v", def, err)
+	}
+	if err := store.Delete(ctx, "order@1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "order@1.0.0"); err != jsontype.ErrSchemaNotFound {
+		t.Fatalf("expected ErrSchemaNotFound but got %v", err)
+	}
+}
+
+func TestRegistryStoreFailure(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	reg := newRegistry(t, sm, failingStore{jsontype.NewMemoryStore()})
+
+	// test a schema that cannot be stored is not loaded
+	w := serve(reg, "POST", "/schemas", "application/json", `{"type": "Order", "properties": {"id": {"type": "string"}}}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body)
+	}
+	if _, err := sm.GetSchema("order"); err == nil {
+		t.Fatal("expected the schema not to be loaded")
+	}
+}
+
+func TestRegistryDelete(t *testing.T) {
+	sm := loadSchemas(t,
+		`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "string"}}}`,
+		`{"type": "Order", "version": "1.1.0", "properties": {"id": {"type": "string"}}, "optional_properties": {"note": {"type": "string"}}}`,
+		`{"type": "Order", "version": "1.2.0", "properties": {"id": {"type": "string"}, "note": {"type": "string"}}}`,
+		`{"type": "Refund", "extends": "Order@~1.0", "properties": {"amount": {"type": "number"}}}`,
+		`{"type": "Invoice", "properties": {"order": {"type": "object", "ref": "Order@1.2.0"}}}`,
+	)
+	reg := newRegistry(t, sm, jsontype.NewMemoryStore())
+
+	for _, test := range []struct {
+		path   string
+		status int
+	}{
+		// versions that other schemas extend or reference
+		{"/schemas/order@1.0.0", http.StatusConflict},
+		{"/schemas/order@1.2.0", http.StatusConflict},
+		{"/schemas/order", http.StatusConflict},
+		// 1.0.0 and 1.2.0 are not backward compatible, as note became required
+		{"/schemas/order@1.1.0", http.StatusConflict},
+		{"/schemas/order@1.1.0?compatibility=none", http.StatusNoContent},
+		{"/schemas/invoice", http.StatusNoContent},
+		{"/schemas/order@1.2.0", http.StatusNoContent},
+	} {
+		w := serve(reg, "DELETE", test.path, "", "")
+		if w.Code != test.status {
+			t.Fatalf("DELETE %s: expected status %d but got %d %s", test.path, test.status, w.Code, w.Body)
+		}
+	}
+	if versions := sm.ListVersions("order"); len(versions) != 1 || versions[0] != "1.0.0" {
+		t.Fatalf("unexpected versions %v", versions)
+	}
+}
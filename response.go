@@ -0,0 +1,198 @@
+package jsontype
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// A ResponseRoute selects the schema that validates the bodies of matching
+// responses
+type ResponseRoute struct {
+	// Method is the request method, an empty method matches every method
+	Method string
+	// Path is a path.Match pattern for the request path
+	Path string
+	// Status is the response status code, 0 matches every 2xx status
+	Status int
+	// ContentType is the media type of the response, an empty content type
+	// matches application/json and every media type with a +json suffix. A
+	// response without a Content-Type header is taken to have this media type.
+	ContentType string
+	// Schema names the schema, it may name a version such as order@^2
+	Schema string
+}
+
+// ResponseOptions configures the middleware returned by
+// SchemaManager.ResponseValidator
+type ResponseOptions struct {
+	// Routes select the schema for each response, the first route that
+	// matches the request and status is used. Responses that match no route
+	// are not validated.
+	Routes []ResponseRoute
+
+	// MaxBodySize is the largest body in bytes that is validated, larger
+	// bodies are not validated. It defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+
+	// OnViolation is called for every response that does not satisfy its
+	// schema, after the response has been written, so it is where violations
+	// are counted or reported to metrics. By default violations are logged
+	// with the log package.
+	OnViolation func(r *http.Request, violation *ResponseViolation)
+}
+
+// A ResponseViolation describes a response that does not satisfy its schema
+type ResponseViolation struct {
+	Method string
+	Path   string
+	Status int
+	Schema string
+	// Err is the reason the response is invalid, it is ValidationErrors when
+	// the body does not satisfy the schema
+	Err error
+}
+
+func (v *ResponseViolation) Error() string {
+	return fmt.Sprintf("%s %s %d: response does not satisfy schema %s: %v", v.Method, v.Path, v.Status, v.Schema, v.Err)
+}
+
+// TestingT is the part of testing.TB used to report violations
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// FailOnViolation returns a ResponseOptions.OnViolation function that fails a
+// test for every violation, for contract tests of handlers
+func FailOnViolation(t TestingT) func(r *http.Request, violation *ResponseViolation) {
+	return func(r *http.Request, violation *ResponseViolation) {
+		t.Errorf("%v", violation)
+	}
+}
+
+// ResponseValidator returns net/http middleware that validates response
+// bodies against the schema of the route and status they match. Responses are
+// passed through to the client unchanged as they are written, and are
+// validated once the handler returns.
+func (sm *SchemaManager) ResponseValidator(options ResponseOptions) func(http.Handler) http.Handler {
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = DefaultMaxBodySize
+	}
+	if options.OnViolation == nil {
+		options.OnViolation = func(r *http.Request, violation *ResponseViolation) {
+			log.Print(violation)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := &responseRecorder{ResponseWriter: w, limit: options.MaxBodySize}
+			next.ServeHTTP(recorder, r)
+
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route, ok := options.route(r, status)
+			if !ok || recorder.truncated {
+				return
+			}
+
+			violation := &ResponseViolation{Method: r.Method, Path: r.URL.Path, Status: status, Schema: route.Schema}
+			violation.Err = sm.validateResponse(route, recorder.contentType, recorder.body.Bytes())
+			if violation.Err != nil {
+				options.OnViolation(r, violation)
+			}
+		})
+	}
+}
+
+// validateResponse validates a response body against the schema of a route
+func (sm *SchemaManager) validateResponse(route ResponseRoute, contentType string, body []byte) error {
+	// handlers that don't set a content type are trusted to write the route's
+	// media type, rather than the type net/http sniffs from the body
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !(Route{ContentType: route.ContentType}).accepts(strings.ToLower(mediaType)) {
+			return fmt.Errorf("content type %q is not supported", contentType)
+		}
+	}
+	s, err := sm.GetSchema(route.Schema)
+	if err != nil {
+		return err
+	}
+	data, err := decodeDocument(body)
+	if err != nil {
+		return fmt.Errorf("the body is not valid JSON: %w", err)
+	}
+	return s.validateDocument(data)
+}
+
+// route returns the route for a request and response status
+func (options ResponseOptions) route(r *http.Request, status int) (ResponseRoute, bool) {
+	for _, route := range options.Routes {
+		if !matchRequest(route.Method, route.Path, r) {
+			continue
+		}
+		if route.Status == status || route.Status == 0 && status >= 200 && status < 300 {
+			return route, true
+		}
+	}
+	return ResponseRoute{}, false
+}
+
+// responseRecorder passes a response through to the client and keeps a copy
+// of its body, up to limit bytes
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	contentType string
+	body        bytes.Buffer
+	limit       int64
+	truncated   bool
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.contentType = w.Header().Get("Content-Type")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.record(b[:n])
+	return n, err
+}
+
+// record keeps a copy of part of the body
+func (w *responseRecorder) record(b []byte) {
+	if w.truncated {
+		return
+	}
+	if int64(w.body.Len()+len(b)) > w.limit {
+		w.truncated = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
+
+// Flush sends buffered data to the client, if the ResponseWriter supports it
+func (w *responseRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the ResponseWriter, for http.ResponseController
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
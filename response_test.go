@@ -0,0 +1,72 @@
+package jsontype_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+// violations records the violations reported to it as a TestingT
+type violations []string
+
+func (v *violations) Errorf(format string, args ...interface{}) {
+	*v = append(*v, fmt.Sprintf(format, args...))
+}
+
+func TestResponseValidator(t *testing.T) {
+	sm := loadSchemas(t,
+		`{"type": "Order", "properties": {"id": {"type": "string"}}}`,
+		`{"type": "Error", "properties": {"message": {"type": "string"}}}`,
+	)
+	reported := &violations{}
+	handler := sm.ResponseValidator(jsontype.ResponseOptions{
+		Routes: []jsontype.ResponseRoute{
+			{Method: "GET", Path: "/orders/*", Status: http.StatusNotFound, Schema: "error"},
+			{Method: "GET", Path: "/orders/*", Schema: "order"},
+		},
+		OnViolation: jsontype.FailOnViolation(reported),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orders/untyped":
+			w.Write([]byte(`{"id": "1"}`))
+			return
+		case "/orders/text":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(`{"id": "1"}`))
+			return
+		case "/orders/missing":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "not found"}`))
+			return
+		case "/orders/500":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": `))
+		w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/orders/")))
+		w.Write([]byte(`}`))
+	}))
+
+	for path, violation := range map[string]string{
+		`/orders/"1"`:     "",
+		"/orders/500":     "",
+		"/orders/1":       `GET /orders/1 200: response does not satisfy schema order: property id is not of type string`,
+		"/orders/untyped": "",
+		"/orders/text":    `GET /orders/text 200: response does not satisfy schema order: content type "text/plain" is not supported`,
+		"/orders/missing": `GET /orders/missing 404: response does not satisfy schema error: required property message is missing; property error is not defined in the schema`,
+	} {
+		*reported = nil
+		w := serve(handler, "GET", path, "", "")
+		if w.Code == http.StatusOK && !strings.HasPrefix(w.Body.String(), `{"id": `) {
+			t.Fatalf("%s: the response was changed %s", path, w.Body)
+		}
+		if violation == "" && len(*reported) != 0 || violation != "" && (len(*reported) != 1 || (*reported)[0] != violation) {
+			t.Fatalf("%s: unexpected violations %q", path, *reported)
+		}
+	}
+}
@@ -1,338 +1,265 @@
 package jsontype
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/goccy/go-json"
 	"github.com/goccy/go-reflect"
 	"github.com/gookit/validate"
 )
 
+// ruleSpec describes the argument a rule takes, and the property types the
+// rule can be applied to
+type ruleSpec struct {
+	// arg is one of number, length, options, string, regex, format or value
+	arg   string
+	types []string
+}
+
+// ruleSpecs describes every rule supported by Evaluate
+var ruleSpecs = map[string]ruleSpec{
+	"min":        {arg: "number", types: []string{"number"}},
+	"max":        {arg: "number", types: []string{"number"}},
+	"min_length": {arg: "length", types: []string{"string", "array", "list", "object"}},
+	"max_length": {arg: "length", types: []string{"string", "array", "list", "object"}},
+	"oneof":      {arg: "options", types: []string{"string", "number", "bool"}},
+	"noneof":     {arg: "options", types: []string{"array", "list"}},
+	"allof":      {arg: "options", types: []string{"array", "list"}},
+	"anyof":      {arg: "options", types: []string{"array", "list"}},
+	"regex":      {arg: "regex", types: []string{"string"}},
+	"contains":   {arg: "value", types: []string{"string", "array", "list"}},
+	"startswith": {arg: "string", types: []string{"string"}},
+	"format":     {arg: "format", types: []string{"string"}},
+}
+
+// formats are the formats supported by the format rule
+var formats = []string{
+	"alpha", "alphanum", "alphadash", "email", "base64", "hexcolor", "hexadecimal",
+	"json", "rgbcolor", "url", "fullurl", "ip", "ipv4", "ipv6", "cidr", "cidrv4",
+	"cidrv6", "uuid", "filepath",
+}
+
+// formatValidators check a string against every format of the format rule
+var formatValidators = map[string]func(string) bool{
+	"alpha":       validate.IsAlpha,
+	"alphanum":    validate.IsAlphaNum,
+	"alphadash":   validate.IsAlphaDash,
+	"email":       validate.IsEmail,
+	"base64":      validate.IsBase64,
+	"hexcolor":    validate.IsHexColor,
+	"hexadecimal": validate.IsHexadecimal,
+	"json":        validate.IsJSON,
+	"rgbcolor":    validate.IsRGBColor,
+	"url":         validate.IsURL,
+	"fullurl":     validate.IsFullURL,
+	"ip":          validate.IsIP,
+	"ipv4":        validate.IsIPv4,
+	"ipv6":        validate.IsIPv6,
+	"cidr":        validate.IsCIDR,
+	"cidrv4":      validate.IsCIDRv4,
+	"cidrv6":      validate.IsCIDRv6,
+	"uuid":        validate.IsUUID,
+	"filepath":    validate.IsFilePath,
+}
+
+// A ruleViolation describes a value that does not satisfy a rule, key names
+// the template of its message
+type ruleViolation struct {
+	key      string
+	expected interface{}
+	actual   interface{}
+}
+
+// Evaluate checks a value against a rule, the error names the value property
+// and its message is in English
 func Evaluate(property, ruleType string, ruleArg, value interface{}) error {
+	violation, err := evaluate(ruleType, ruleArg, value)
+	if err != nil || violation == nil {
+		return err
+	}
+	return errors.New(renderEnglish(violation.key, property, violation.expected, violation.actual))
+}
+
+// evaluate checks a value against a rule. A value that does not satisfy the
+// rule is described by a ruleViolation, while an invalid rule is returned as
+// an error.
+func evaluate(ruleType string, ruleArg, value interface{}) (*ruleViolation, error) {
 	switch ruleType {
 	case "min":
+		if n, ok := value.(json.Number); ok {
+			value, _ = toFloat(n)
+		}
 		if !validate.Min(value, ruleArg) {
-			return fmt.Errorf("%s must be greater than %v but got %v", property, ruleArg, value)
+			return &ruleViolation{"min", ruleArg, value}, nil
 		}
 	case "max":
+		if n, ok := value.(json.Number); ok {
+			value, _ = toFloat(n)
+		}
 		if !validate.Max(value, ruleArg) {
-			return fmt.Errorf("%s must be less than %v but got %v", property, ruleArg, value)
+			return &ruleViolation{"max", ruleArg, value}, nil
 		}
 	case "min_length":
 		// ensure our ruleArg is an int
 		min, ok := ruleArg.(float64)
 		if !ok {
 			ruleArgType := reflect.TypeOf(ruleArg)
-			return fmt.Errorf("min_length rule must be an number but got %v", ruleArgType)
+			return nil, fmt.Errorf("min_length rule must be an number but got %v", ruleArgType)
 		}
 		if !validate.MinLength(value, int(min)) {
-			return fmt.Errorf("%s length must be greater than %v but got %v", property, ruleArg, value)
+			return &ruleViolation{"min_length", ruleArg, value}, nil
 		}
 
 	case "max_length":
 		max, ok := ruleArg.(float64)
 		if !ok {
 			ruleArgType := reflect.TypeOf(ruleArg)
-			return fmt.Errorf("max_length rule must be an number but got %v", ruleArgType)
+			return nil, fmt.Errorf("max_length rule must be an number but got %v", ruleArgType)
 		}
 		if !validate.MaxLength(value, int(max)) {
-			return fmt.Errorf("%s length must be less than %v but got %v", property, ruleArg, value)
+			return &ruleViolation{"max_length", ruleArg, value}, nil
 		}
 	case "oneof":
 		options, ok := ruleArg.([]interface{})
 		if !ok {
-			return fmt.Errorf("oneof rule must be an array but got %v", ruleArg)
+			return nil, fmt.Errorf("oneof rule must be an array but got %v", ruleArg)
 		}
 		for _, option := range options {
-			if option == value {
-				return nil
+			if equal(option, value) {
+				return nil, nil
 			}
 		}
-		return fmt.Errorf("%s must be one of %v but got %v", property, options, value)
+		return &ruleViolation{"oneof", options, value}, nil
 
 	case "noneof":
 		options, ok := ruleArg.([]interface{})
 		if !ok {
 			ruleArgType := reflect.TypeOf(ruleArg)
-			return fmt.Errorf("noneof rule must be an array but got %v", ruleArgType)
+			return nil, fmt.Errorf("noneof rule must be an array but got %v", ruleArgType)
 		}
-		values, ok := value.([]interface{})
+		values, ok := toList(value)
 		if !ok {
-			valueType := reflect.TypeOf(value)
-			return fmt.Errorf("%s value must be an array but got %v", property, valueType)
+			return &ruleViolation{"not_array", "array", value}, nil
 		}
 
 		for _, option := range options {
 			for _, val := range values {
-				if reflect.DeepEqual(option, val) {
-					return fmt.Errorf("must not be one of %v but got %v", options, value)
+				if equal(option, val) {
+					return &ruleViolation{"noneof", options, value}, nil
 				}
 			}
 		}
-		return nil
+		return nil, nil
 
 	case "allof":
 		options, ok := ruleArg.([]interface{})
 		if !ok {
-			return fmt.Errorf("allof rule must be an array but got %v", ruleArg)
+			return nil, fmt.Errorf("allof rule must be an array but got %v", ruleArg)
 		}
-		values, ok := value.([]interface{})
+		values, ok := toList(value)
 		if !ok {
-			return fmt.Errorf("allof value must be an array but got %v", value)
+			return &ruleViolation{"not_array", "array", value}, nil
 		}
 		for _, val := range values {
 			found := false
 			for _, option := range options {
-				if option == val {
+				if equal(option, val) {
 					found = true
 				}
 			}
 			if !found {
-				return fmt.Errorf("%s must be all of %v but got %v", property, options, value)
+				return &ruleViolation{"allof", options, value}, nil
 			}
 		}
-		return nil
+		return nil, nil
 
 	case "anyof":
 		options, ok := ruleArg.([]interface{})
 		if !ok {
-			return fmt.Errorf("anyof rule must be an array but got %v", ruleArg)
+			return nil, fmt.Errorf("anyof rule must be an array but got %v", ruleArg)
 		}
-		values, ok := value.([]interface{})
+		values, ok := toList(value)
 		if !ok {
-			valueType := reflect.TypeOf(value)
-			return fmt.Errorf("%s value must be an array but got %v", property, valueType)
+			return &ruleViolation{"not_array", "array", value}, nil
 		}
 		for _, val := range values {
 			found := false
 			for _, option := range options {
-				if option == val {
+				if equal(option, val) {
 					found = true
 				}
 			}
 			if found {
-				return nil
+				return nil, nil
 			}
 		}
-		return fmt.Errorf("%s must be any of %v but got %v", property, options, value)
+		return &ruleViolation{"anyof", options, value}, nil
 
 	case "regex":
 		regex, ok := ruleArg.(string)
 		if !ok {
 			ruleArgType := reflect.TypeOf(ruleArg)
-			return fmt.Errorf("regex rule must be a string but got %v", ruleArgType)
+			return nil, fmt.Errorf("regex rule must be a string but got %v", ruleArgType)
 		}
 
 		vstr, ok := value.(string)
 		if !ok {
-			valueType := reflect.TypeOf(value)
-			return fmt.Errorf("%s must be a string but got %v", property, valueType)
+			return &ruleViolation{"not_string", "string", value}, nil
 		}
 
 		if !validate.Regexp(vstr, regex) {
-			return fmt.Errorf("%s must match %v but got %v", property, regex, value)
+			return &ruleViolation{"regex", regex, value}, nil
 		}
 
 	case "contains":
+		if values, ok := toList(value); ok {
+			for _, val := range values {
+				if equal(ruleArg, val) {
+					return nil, nil
+				}
+			}
+			return &ruleViolation{"contains", ruleArg, value}, nil
+		}
 		if !validate.Contains(value, ruleArg) {
-			return fmt.Errorf("%s must contain %v but got %v", property, ruleArg, value)
+			return &ruleViolation{"contains", ruleArg, value}, nil
 		}
 
 	case "startswith":
 		substr, ok := ruleArg.(string)
 		if !ok {
-			return fmt.Errorf("startswith rule must be a string but got %v", ruleArg)
+			return nil, fmt.Errorf("startswith rule must be a string but got %v", ruleArg)
 		}
 
 		str, ok := value.(string)
 		if !ok {
-			return fmt.Errorf("%s must be a string but got %v", property, value)
+			return &ruleViolation{"not_string", "string", value}, nil
 		}
 
 		if !validate.StartsWith(str, substr) {
-			return fmt.Errorf("%s must start with %v but got %v", property, substr, value)
+			return &ruleViolation{"startswith", substr, value}, nil
 		}
 
 	// TODO: should we support multiple formats for a single property?
 	case "format":
 		format, ok := ruleArg.(string)
 		if !ok {
-			return fmt.Errorf("format rule must be a string but got %v", ruleArg)
+			return nil, fmt.Errorf("format rule must be a string but got %v", ruleArg)
 		}
-		switch format {
-		case "alpha":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsAlpha(v) {
-				return fmt.Errorf("%s must be alpha but got %v", property, value)
-			}
-
-		case "alphanum":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsAlphaNum(v) {
-				return fmt.Errorf("%s must be alpha numeric but got %v", property, value)
-			}
-
-		case "alphadash":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsAlphaDash(v) {
-				return fmt.Errorf("%s must be alpha dash but got %v", property, value)
-			}
-
-		case "email":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsEmail(v) {
-				return fmt.Errorf("%s must be email but got %v", property, value)
-			}
-
-		case "base64":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsBase64(v) {
-				return fmt.Errorf("%s must be base64 but got %v", property, value)
-			}
-
-		case "hexcolor":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsHexColor(v) {
-				return fmt.Errorf("%s must be hex color but got %v", property, value)
-			}
-
-		case "hexadecimal":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsHexadecimal(v) {
-				return fmt.Errorf("%s must be hexadecimal but got %v", property, value)
-			}
-
-		case "json":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsJSON(v) {
-				return fmt.Errorf("%s must be JSON but got %v", property, value)
-			}
-
-		case "rgbcolor":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsRGBColor(v) {
-				return fmt.Errorf("%s must be RGB color but got %v", property, value)
-			}
-
-		case "url":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsURL(v) {
-				return fmt.Errorf("%s must be URL but got %v", property, value)
-			}
-
-		case "fullurl":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsFullURL(v) {
-				return fmt.Errorf("%s must be URL but got %v", property, value)
-			}
-
-		case "ip":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsIP(v) {
-				return fmt.Errorf("%s must be IP but got %v", property, value)
-			}
-
-		case "ipv4":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsIPv4(v) {
-				return fmt.Errorf("%s must be IPv4 but got %v", property, value)
-			}
-
-		case "ipv6":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsIPv6(v) {
-				return fmt.Errorf("%s must be IPv6 but got %v", property, value)
-			}
-
-		case "cidr":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsCIDR(v) {
-				return fmt.Errorf("%s must be CIDR but got %v", property, value)
-			}
-
-		case "cidrv4":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsCIDRv4(v) {
-				return fmt.Errorf("%s must be CIDRv4 but got %v", property, value)
-			}
-
-		case "cidrv6":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsCIDRv6(v) {
-				return fmt.Errorf("%s must be CIDRv6 but got %v", property, value)
-			}
-
-		case "uuid":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsUUID(v) {
-				return fmt.Errorf("%s must be UUID but got %v", property, value)
-			}
-
-		case "filepath":
-			v, ok := value.(string)
-			if !ok {
-				return fmt.Errorf("%s must be a string but got %v", property, value)
-			}
-			if !validate.IsFilePath(v) {
-				return fmt.Errorf("%v (%s) is not a valid file path", property, value)
-			}
+		isFormat, ok := formatValidators[format]
+		if !ok {
+			return nil, nil
+		}
+		v, ok := value.(string)
+		if !ok {
+			return &ruleViolation{"not_string", "string", value}, nil
+		}
+		if !isFormat(v) {
+			return &ruleViolation{"format." + format, format, value}, nil
 		}
 
 	default:
-		return fmt.Errorf("unknown rule %s", ruleType)
+		return nil, fmt.Errorf("unknown rule %s", ruleType)
 	}
-	return nil
+	return nil, nil
 }
@@ -95,6 +95,11 @@ func TestEvalOneOf(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error")
 	}
+	// test that numbers of any kind are compared by value
+	err = jsontype.Evaluate("fake", "oneof", []interface{}{1.0, 2.0}, int64(2))
+	if err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestEvalAnyOf(t *testing.T) {
@@ -1,8 +1,14 @@
 package jsontype
 
 import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/goccy/go-json"
 	"github.com/gookit/validate"
@@ -12,17 +18,54 @@ import (
 // it also allows for custom types to be used withing the schemas
 // as this is the centralized store for all schemas
 type SchemaManager struct {
+	// Schemas holds the latest version of every schema type
 	Schemas map[string]*Schema
+
+	// versions holds every version of every schema type, ordered from the
+	// lowest version to the latest. A schema without a version is lower than
+	// every version.
+	versions map[string][]*Schema
+
+	// migrations holds the migrations registered for every schema type
+	migrations map[string][]*migration
+
+	// store is the StoreSync the schemas are kept in sync with, if any
+	store *StoreSync
+
+	// messages holds the message templates registered for every locale, and
+	// locale is the locale of validation errors
+	messages map[string]Messages
+	locale   string
+
+	// mu guards Schemas, versions, migrations, store, messages and locale, so
+	// schemas can be replaced while documents are being validated
+	mu sync.RWMutex
 }
 
 // A Schema defines an entity and is the atomic unit of the JSONType package
 type Schema struct {
 	Type                     string              `json:"type,omitempty" validate:"required"`
+	Version                  string              `json:"version,omitempty"`
 	Description              string              `json:"description,omitempty"`
 	Extends                  string              `json:"extends,omitempty"`
-	Properties               map[string]Property `json:"properties" validate:"required"`
+	Properties               map[string]Property `json:"properties"`
 	OptionalProperties       map[string]Property `json:"optional_properties,omitempty"`
 	AllowUndefinedProperties bool                `json:"allow_undefined_properties,omitempty" default:"false"`
+
+	// Root defines the kind of document the schema validates, such as an
+	// array of another schema or a number with rules. Documents are objects
+	// with the schema's properties when it is not given. Root only applies to
+	// documents validated against the schema itself, a property that
+	// references the schema is always an object with its properties.
+	Root *Property `json:"root,omitempty"`
+
+	// Messages overrides the message templates of errors in the schema's
+	// properties, for every locale
+	Messages map[string]Messages `json:"messages,omitempty"`
+
+	// manager is the SchemaManager the schema was loaded into, it is used to
+	// resolve extended and referenced schemas during validation
+	manager *SchemaManager
 }
 
 // A property defines a field within a schema. Rules for the property are used
@@ -31,36 +74,284 @@ type Property struct {
 	Type        string                 `json:"type" validate:"required|in:number,string,list,array,bool,object"`
 	Description string                 `json:"description,omitempty"`
 	Rules       map[string]interface{} `json:"rules,omitempty"`
+
+	// Ref names the schema that an object property must satisfy
+	Ref string `json:"ref,omitempty"`
+
+	// Items defines the type of every item within an array property
+	Items *Property `json:"items,omitempty"`
+
+	// Messages overrides the message templates of errors in the property, for
+	// every locale
+	Messages map[string]Messages `json:"messages,omitempty"`
 }
 
 // NewSchemaManager creates and returns an initialized SchemaManager that is empty.
 func NewSchemaManager() *SchemaManager {
 	return &SchemaManager{
-		Schemas: make(map[string]*Schema),
+		Schemas:    make(map[string]*Schema),
+		versions:   make(map[string][]*Schema),
+		migrations: make(map[string][]*migration),
 	}
 }
 
-// LoadSchema loads a schema into the SchemaManager
-// NOTE: LoadSchema will overwrite any existing schema with the same type
-func (sm *SchemaManager) LoadSchema(schemaDef []byte) error {
+// errInvalidUTF8 is returned for a schema definition that is not valid UTF-8,
+// as property names and rules within it could never match a document
+var errInvalidUTF8 = errors.New("schema definition is not valid UTF-8")
+
+// checkJSON returns the syntax error encoding/json finds in data, if any.
+// Input is checked before go-json decodes it, as go-json can panic on some
+// malformed input and accept other malformed input.
+func checkJSON(data []byte) error {
+	if stdjson.Valid(data) {
+		return nil
+	}
+	var v interface{}
+	return stdjson.Unmarshal(data, &v)
+}
+
+// decodeSchema decodes a schema definition
+func decodeSchema(def []byte) (*Schema, error) {
+	if !utf8.Valid(def) {
+		return nil, errInvalidUTF8
+	}
+	if err := checkJSON(def); err != nil {
+		return nil, err
+	}
 	s := &Schema{}
-	err := json.Unmarshal(schemaDef, s)
+	err := json.Unmarshal(def, s)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadOption changes how schemas are loaded
+type LoadOption int
+
+const (
+	// Replace allows a schema to replace a loaded schema with the same type
+	// and version
+	Replace LoadOption = iota + 1
+)
+
+// hasOption reports whether option is within options
+func hasOption(options []LoadOption, option LoadOption) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadSchema loads a schema into the SchemaManager. Every version of a schema
+// type is kept, a schema with the same type and version as a loaded schema is
+// only loaded if the Replace option is given.
+// NOTE: a schema without a version will overwrite the existing schema with the
+// same type that has no version
+func (sm *SchemaManager) LoadSchema(schemaDef []byte, options ...LoadOption) error {
+	if ss := sm.storeSync(); ss != nil {
+		if errs := ss.load(map[string][]byte{"schema": schemaDef}, hasOption(options, Replace)); len(errs) > 0 {
+			return errs[0].Err
+		}
+		return nil
+	}
+	s, err := decodeSchema(schemaDef)
 	if err != nil {
 		return err
 	}
+	return sm.addSchema(s, hasOption(options, Replace))
+}
 
+// addSchema validates a schema and stores it in the SchemaManager. A schema
+// that extends another schema can only be added once its parent is loaded.
+func (sm *SchemaManager) addSchema(s *Schema, replace bool) error {
 	v := validate.Struct(s)
 	if !v.Validate() {
 		return fmt.Errorf("schema is invalid: %s", v.Errors.One())
 	}
+	if strings.Contains(s.Type, "@") {
+		return fmt.Errorf("schema is invalid: type %q contains @, which separates a type from its version", s.Type)
+	}
+	if s.Version != "" {
+		if _, err := parseVersion(s.Version); err != nil {
+			return fmt.Errorf("schema %s has an %v", s.Type, err)
+		}
+	}
+	for _, name := range sortedKeys(s.Properties) {
+		if _, ok := s.OptionalProperties[name]; ok {
+			return fmt.Errorf("schema %s defines property %s in both properties and optional_properties", s.Type, name)
+		}
+	}
+	if s.Root != nil {
+		if v := validate.Struct(s.Root); !v.Validate() {
+			return fmt.Errorf("schema %s has an invalid root: %s", s.Type, v.Errors.One())
+		}
+		if !s.hasObjectRoot() && (len(s.Properties) > 0 || len(s.OptionalProperties) > 0 || s.Extends != "") {
+			return fmt.Errorf("schema %s has %s root, so it cannot define or extend properties", s.Type, withArticle(s.Root.Type))
+		}
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if s.Version != "" && !replace && sm.exactVersion(s.Type, s.Version) != nil {
+		return fmt.Errorf("schema %s@%s is already loaded", s.Type, s.Version)
+	}
+	if s.Extends != "" {
+		if _, err := sm.resolve(s.Extends); err != nil {
+			return fmt.Errorf("schema %s extends unknown schema %s", s.Type, s.Extends)
+		}
+	}
+
+	previous := sm.exactVersion(s.Type, s.Version)
+	sm.storeSchema(s)
+
+	// the extends chain is walked once the schema is stored, so a version
+	// range resolves the same way it will when the schema is used
+	visited := map[*Schema]bool{}
+	for child := s; child.Extends != ""; {
+		visited[child] = true
+		parent, err := sm.resolve(child.Extends)
+		if err != nil {
+			break
+		}
+		if visited[parent] {
+			sm.removeSchema(s.Type, s.Version)
+			if previous != nil {
+				sm.storeSchema(previous)
+			}
+			return fmt.Errorf("schema %s %w", s.ref(), errLoading)
+		}
+		child = parent
+	}
+
+	s.manager = sm
+	return nil
+}
 
+// storeSchema stores a schema, replacing the schema with the same type and
+// version. The caller must hold sm.mu.
+func (sm *SchemaManager) storeSchema(s *Schema) {
+	// a SchemaManager may be created without NewSchemaManager
+	if sm.versions == nil {
+		sm.versions = make(map[string][]*Schema)
+	}
 	schemaType := strings.ToLower(s.Type)
-	sm.Schemas[schemaType] = s
+	versions := []*Schema{}
+	for _, existing := range sm.versions[schemaType] {
+		if existing.Version != s.Version {
+			versions = append(versions, existing)
+		}
+	}
+	versions = append(versions, s)
+	sort.SliceStable(versions, func(i, j int) bool {
+		return compareSchemaVersions(versions[i].Version, versions[j].Version) < 0
+	})
+	sm.versions[schemaType] = versions
+	sm.Schemas[schemaType] = latestVersion(versions)
+}
+
+// removeSchema removes the schema with the type and version, and reports
+// whether it was loaded. The caller must hold sm.mu.
+func (sm *SchemaManager) removeSchema(schemaType, version string) bool {
+	schemaType = strings.ToLower(schemaType)
+	versions := []*Schema{}
+	for _, existing := range sm.versions[schemaType] {
+		if existing.Version != version {
+			versions = append(versions, existing)
+		}
+	}
+	if len(versions) == len(sm.versions[schemaType]) {
+		return false
+	}
+	if len(versions) == 0 {
+		delete(sm.versions, schemaType)
+		delete(sm.Schemas, schemaType)
+		return true
+	}
+	sm.versions[schemaType] = versions
+	sm.Schemas[schemaType] = latestVersion(versions)
+	return true
+}
+
+// latestVersion returns the latest release within ordered versions of a
+// schema, pre-releases are only the latest when there is no release
+func latestVersion(versions []*Schema) *Schema {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if v, err := parseVersion(versions[i].Version); err != nil || len(v.pre) == 0 {
+			return versions[i]
+		}
+	}
+	return versions[len(versions)-1]
+}
+
+// exactVersion returns the schema with the type and exact version. The caller
+// must hold sm.mu.
+func (sm *SchemaManager) exactVersion(schemaType, version string) *Schema {
+	for _, s := range sm.versions[strings.ToLower(schemaType)] {
+		if s.Version == version {
+			return s
+		}
+	}
 	return nil
 }
 
+// keyed returns the schema with the key returned by Schema.key. The caller
+// must hold sm.mu.
+func (sm *SchemaManager) keyed(key string) *Schema {
+	schemaType, version, _ := strings.Cut(key, "@")
+	return sm.exactVersion(schemaType, version)
+}
+
+// resolve returns the schema named by a reference such as order, order@1.2.0
+// or order@^1, a reference without a version is the latest version. The
+// caller must hold sm.mu.
+func (sm *SchemaManager) resolve(ref string) (*Schema, error) {
+	schemaType, constraint, hasVersion := strings.Cut(ref, "@")
+	schemaType = strings.ToLower(schemaType)
+	if !hasVersion || constraint == "latest" {
+		if s, ok := sm.Schemas[schemaType]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("schema %s not found", schemaType)
+	}
+
+	c, err := parseConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %w", ref, err)
+	}
+	versions := sm.versions[schemaType]
+	for i := len(versions) - 1; i >= 0; i-- {
+		v, err := parseVersion(versions[i].Version)
+		if err == nil && c.match(v) {
+			return versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("schema %s@%s not found", schemaType, constraint)
+}
+
+// compareSchemaVersions orders schema versions, a schema without a version is
+// lower than every version
+func compareSchemaVersions(a, b string) int {
+	va, errA := parseVersion(a)
+	vb, errB := parseVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return va.compare(vb)
+}
+
 // ListSchemas returns a list of all schemaTypes in the SchemaManager
 func (sm *SchemaManager) ListSchemas() []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
 	schemaTypes := make([]string, 0, len(sm.Schemas))
 	for schemaType := range sm.Schemas {
 		schemaTypes = append(schemaTypes, schemaType)
@@ -68,20 +359,67 @@ func (sm *SchemaManager) ListSchemas() []string {
 	return schemaTypes
 }
 
-// GetSchema returns a schema from the SchemaManager
+// GetSchema returns a schema from the SchemaManager. schemaType may name a
+// version, or a range of versions, such as order@1.2.0, order@^1.2 or
+// order@>=1.0.0 <2.0.0, in which case the latest matching version is
+// returned. Without a version the latest release is returned.
 func (sm *SchemaManager) GetSchema(schemaType string) (*Schema, error) {
-	schemaType = strings.ToLower(schemaType)
-	if schema, ok := sm.Schemas[schemaType]; ok {
-		return schema, nil
+	sm.mu.RLock()
+	s, err := sm.resolve(schemaType)
+	ss := sm.store
+	sm.mu.RUnlock()
+	if err == nil || ss == nil {
+		return s, err
 	}
-	return nil, fmt.Errorf("schema %s not found", schemaType)
+
+	// the schema may have been stored by another process since the store was
+	// last read
+	if !ss.refreshMissing(context.Background(), schemaType) {
+		return nil, err
+	}
+	sm.mu.RLock()
+	s, err = sm.resolve(schemaType)
+	sm.mu.RUnlock()
+	if err != nil {
+		ss.missing(schemaType)
+	}
+	return s, err
+}
+
+// ListVersions returns the versions of a schema type, from the lowest to the
+// latest
+func (sm *SchemaManager) ListVersions(schemaType string) []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	versions := []string{}
+	for _, s := range sm.versions[strings.ToLower(schemaType)] {
+		if s.Version != "" {
+			versions = append(versions, s.Version)
+		}
+	}
+	return versions
 }
 
-// DeleteSchema deletes a schema from the SchemaManager
+// DeleteSchema deletes a schema from the SchemaManager. Every version of the
+// schema is deleted, unless schemaType names a single version such as
+// order@1.2.0.
 func (sm *SchemaManager) DeleteSchema(schemaType string) error {
+	if ss := sm.storeSync(); ss != nil {
+		return ss.delete(schemaType)
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	schemaType, version, hasVersion := strings.Cut(schemaType, "@")
 	schemaType = strings.ToLower(schemaType)
+	if hasVersion {
+		if s := sm.exactVersion(schemaType, version); s != nil && sm.removeSchema(schemaType, s.Version) {
+			return nil
+		}
+		return fmt.Errorf("schema %s@%s not found", schemaType, version)
+	}
 	if _, ok := sm.Schemas[schemaType]; ok {
 		delete(sm.Schemas, schemaType)
+		delete(sm.versions, schemaType)
 		return nil
 	}
 	return fmt.Errorf("schema %s not found", schemaType)
@@ -89,6 +427,8 @@ func (sm *SchemaManager) DeleteSchema(schemaType string) error {
 
 // SchemaCount returns the number of schemas in the SchemaManager
 func (sm *SchemaManager) SchemaCount() int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
 	return len(sm.Schemas)
 }
 
@@ -98,45 +438,190 @@ func (s *Schema) String() string {
 	return string(b)
 }
 
-// Validate will validate the provided JSON document against the schema
+// Validate will validate the provided JSON document against the schema. If
+// the document does not satisfy the schema, ValidationErrors describing every
+// problem with the document are returned.
 func (s *Schema) Validate(document []byte) error {
 
 	// first we need to validate the document is valid JSON
+	if err := checkJSON(document); err != nil {
+		return err
+	}
 	var jsondata interface{}
 	err := json.Unmarshal(document, &jsondata)
 	if err != nil {
 		return err
 	}
 
-	// next we need to validate the document against the schema's properties
-	for property, p := range s.Properties {
+	return s.validateDocument(jsondata)
+}
 
-		// TODO: handle optional properties
+// ValidateValue will validate a Go value against the schema without encoding
+// it to JSON first. The value may be data that has already been decoded, such
+// as a map[string]interface{} or a json.RawMessage, or any other Go value such
+// as a struct, which is validated as encoding/json would encode it.
+func (s *Schema) ValidateValue(value interface{}) error {
+	data, err := toJSONValue(value)
+	if err != nil {
+		return err
+	}
+	return s.validateDocument(data)
+}
+
+// validateDocument validates decoded JSON data against the schema, if the
+// data does not satisfy the schema ValidationErrors are returned
+func (s *Schema) validateDocument(data interface{}) error {
+	var errs ValidationErrors
+	err := s.validateRoot(data, &errs)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateRoot validates a whole document against the schema's root, and an
+// object root against the schema's properties
+func (s *Schema) validateRoot(data interface{}, errs *ValidationErrors) error {
+	err := s.validateProperty("", s.root(), data, errs)
+	if err != nil {
+		return err
+	}
+	if object, ok := data.(map[string]interface{}); ok && s.hasObjectRoot() {
+		return s.validateObject("", object, errs)
+	}
+	return nil
+}
+
+// root returns the property that describes a whole document
+func (s *Schema) root() Property {
+	if s.Root == nil {
+		return Property{Type: "object"}
+	}
+	return *s.Root
+}
+
+// hasObjectRoot reports whether documents are objects with the schema's own
+// properties
+func (s *Schema) hasObjectRoot() bool {
+	return s.Root == nil || s.Root.Type == "object" && s.Root.Ref == ""
+}
+
+// validateObject validates an object against the schema's properties, path is
+// the location of the object within the document and prefixes property names
+// in errors. Validation errors are added to errs, while errors in the schema
+// itself are returned.
+func (s *Schema) validateObject(path string, object map[string]interface{}, errs *ValidationErrors) error {
+	properties, optionalProperties, err := s.resolveProperties()
+	if err != nil {
+		return err
+	}
+
+	for _, property := range sortedKeys(properties) {
 
 		// Check if the property exists in the data
-		if _, ok := jsondata.(map[string]interface{})[property]; !ok {
-			return fmt.Errorf("required property %s is missing", property)
+		value, ok := object[property]
+		if !ok {
+			p := properties[property]
+			*errs = append(*errs, s.withMessage(&ValidationError{
+				Path: joinPath(path, property),
+				Kind: ErrorMissingProperty,
+			}, &p))
+			continue
 		}
 
-		// if we are not allowing additional properties, then we should check if
-		// there are any additional properties in the data
-		if !s.AllowUndefinedProperties {
-			for key := range jsondata.(map[string]interface{}) {
-				if _, ok := s.Properties[key]; !ok {
-					return fmt.Errorf("property %s is not defined in the schema", key)
-				}
+		err := s.validateProperty(joinPath(path, property), properties[property], value, errs)
+		if err != nil {
+			return err
+		}
+	}
+
+	// optional properties are only validated when present, a null value is
+	// treated the same as a missing one
+	for _, property := range sortedKeys(optionalProperties) {
+		value, ok := object[property]
+		if !ok || value == nil {
+			continue
+		}
+
+		err := s.validateProperty(joinPath(path, property), optionalProperties[property], value, errs)
+		if err != nil {
+			return err
+		}
+	}
+
+	// if we are not allowing additional properties, then we should check if
+	// there are any additional properties in the data
+	if !s.AllowUndefinedProperties {
+		for _, key := range sortedKeys(object) {
+			_, required := properties[key]
+			_, optional := optionalProperties[key]
+			if !required && !optional {
+				*errs = append(*errs, s.withMessage(&ValidationError{
+					Path: joinPath(path, key),
+					Kind: ErrorUndefinedProperty,
+				}, nil))
 			}
 		}
+	}
+
+	return nil
+}
+
+// validateProperty validates a single value against its property definition
+func (s *Schema) validateProperty(path string, p Property, value interface{}, errs *ValidationErrors) error {
+
+	// Check if the property is the correct type, there is no point in
+	// evaluating rules against a value of the wrong type
+	if !IsType(value, p.Type) {
+		*errs = append(*errs, s.withMessage(&ValidationError{
+			Path:     path,
+			Kind:     ErrorInvalidType,
+			Expected: p.Type,
+			Actual:   jsonKind(value),
+		}, &p))
+		return nil
+	}
+
+	// validate value against rules, a rule that is itself invalid is reported
+	// with its error as the message
+	for _, ruleType := range sortedKeys(p.Rules) {
+		violation, err := evaluate(ruleType, p.Rules[ruleType], value)
+		switch {
+		case err != nil:
+			*errs = append(*errs, &ValidationError{
+				Path:    path,
+				Kind:    ErrorRuleViolation,
+				Rule:    ruleType,
+				Message: err.Error(),
+			})
+		case violation != nil:
+			*errs = append(*errs, s.withMessage(&ValidationError{
+				Path:     path,
+				Kind:     ErrorRuleViolation,
+				Rule:     ruleType,
+				Expected: violation.expected,
+				Actual:   violation.actual,
+				key:      violation.key,
+			}, &p))
+		}
+	}
 
-		// Check if the property is the correct type
-		value := jsondata.(map[string]interface{})[property]
-		if !IsType(value, p.Type) {
-			return fmt.Errorf("property %s is not of type %s", property, p.Type)
+	// objects may reference another schema, and arrays may define the type of
+	// their items
+	switch {
+	case p.Type == "object" && p.Ref != "":
+		ref, err := s.lookup(p.Ref)
+		if err != nil {
+			return err
 		}
+		return ref.validateObject(path, value.(map[string]interface{}), errs)
 
-		// validate value against rules
-		for ruleType, ruleArg := range p.Rules {
-			err := Evaluate(property, ruleType, ruleArg, value)
+	case p.Type == "array" && p.Items != nil:
+		for i, item := range value.([]interface{}) {
+			err := s.validateProperty(fmt.Sprintf("%s[%d]", path, i), *p.Items, item, errs)
 			if err != nil {
 				return err
 			}
@@ -145,3 +630,85 @@ func (s *Schema) Validate(document []byte) error {
 
 	return nil
 }
+
+// resolveProperties returns the required and optional properties of the
+// schema, including those inherited from any schemas it extends. Properties
+// defined by the schema itself take precedence over inherited ones.
+func (s *Schema) resolveProperties() (map[string]Property, map[string]Property, error) {
+	return s.inheritProperties(map[*Schema]bool{})
+}
+
+// inheritProperties merges the properties of the schema with those of the
+// schemas it extends, visited holds the schemas already merged so a circular
+// extends chain is reported rather than followed forever
+func (s *Schema) inheritProperties(visited map[*Schema]bool) (map[string]Property, map[string]Property, error) {
+	if s.Extends == "" {
+		return s.Properties, s.OptionalProperties, nil
+	}
+	if visited[s] {
+		return nil, nil, fmt.Errorf("schema %s %w", s.ref(), errLoading)
+	}
+	visited[s] = true
+
+	parent, err := s.lookup(s.Extends)
+	if err != nil {
+		return nil, nil, err
+	}
+	properties, optionalProperties, err := parent.inheritProperties(visited)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged := make(map[string]Property, len(properties)+len(s.Properties))
+	mergedOptional := make(map[string]Property, len(optionalProperties)+len(s.OptionalProperties))
+	for name, p := range properties {
+		merged[name] = p
+	}
+	for name, p := range optionalProperties {
+		mergedOptional[name] = p
+	}
+	for name, p := range s.Properties {
+		delete(mergedOptional, name)
+		merged[name] = p
+	}
+	for name, p := range s.OptionalProperties {
+		delete(merged, name)
+		mergedOptional[name] = p
+	}
+	return merged, mergedOptional, nil
+}
+
+// lookup returns a schema from the SchemaManager the schema was loaded into
+func (s *Schema) lookup(schemaType string) (*Schema, error) {
+	if s.manager == nil {
+		return nil, fmt.Errorf("schema %s must be loaded into a SchemaManager to resolve schema %s", s.Type, schemaType)
+	}
+	return s.manager.GetSchema(schemaType)
+}
+
+// withArticle prefixes a type with its indefinite article
+func withArticle(propertyType string) string {
+	if strings.HasPrefix(propertyType, "a") || strings.HasPrefix(propertyType, "o") {
+		return "an " + propertyType
+	}
+	return "a " + propertyType
+}
+
+// joinPath appends a property name to the path of its parent object
+func joinPath(path, property string) string {
+	if path == "" {
+		return property
+	}
+	return path + "." + property
+}
+
+// sortedKeys returns the keys of a map in sorted order, so that documents are
+// always validated in the same order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,6 +1,9 @@
 package jsontype_test
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/goccy/go-reflect"
@@ -8,6 +11,28 @@ import (
 	"github.com/apageadev/jsontype"
 )
 
+// loadSchemas loads schema definitions into a new SchemaManager
+func loadSchemas(t *testing.T, defs ...string) *jsontype.SchemaManager {
+	t.Helper()
+	sm := jsontype.NewSchemaManager()
+	for _, def := range defs {
+		if err := sm.LoadSchema([]byte(def)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sm
+}
+
+// getSchema gets a schema from a SchemaManager
+func getSchema(t *testing.T, sm *jsontype.SchemaManager, schemaType string) *jsontype.Schema {
+	t.Helper()
+	s, err := sm.GetSchema(schemaType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
 func TestCreateSchemaManager(t *testing.T) {
 	sm := jsontype.NewSchemaManager()
 	if sm == nil {
@@ -37,6 +62,22 @@ func TestSchemaManagerLoadSchema(t *testing.T) {
 	}
 }
 
+func TestSchemaManagerLiteral(t *testing.T) {
+	sm := &jsontype.SchemaManager{Schemas: map[string]*jsontype.Schema{}}
+
+	// test loading into a SchemaManager that was not created by NewSchemaManager
+	err := sm.LoadSchema([]byte(`{"type": "Person", "version": "1.0.0", "properties": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.GetSchema("person@1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.RegisterMigration("person", "1.0.0", "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestSchemaManagerLoadBadSchema(t *testing.T) {
 	sm := jsontype.NewSchemaManager()
 	if sm == nil {
@@ -49,6 +90,26 @@ func TestSchemaManagerLoadBadSchema(t *testing.T) {
 	}
 }
 
+func TestSchemaManagerLoadRequiredAndOptional(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+
+	// test a property cannot be both required and optional
+	err := sm.LoadSchema([]byte(`{"type": "Pet", "properties": {"name": {"type": "string"}}, "optional_properties": {"name": {"type": "number"}}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test a schema may make a property of the schema it extends optional
+	err = sm.LoadSchema([]byte(`{"type": "Animal", "properties": {"name": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sm.LoadSchema([]byte(`{"type": "Dog", "extends": "Animal", "properties": {}, "optional_properties": {"name": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestSchemaManagerGetSchema(t *testing.T) {
 	sm := jsontype.NewSchemaManager()
 	if sm == nil {
@@ -288,3 +349,364 @@ func TestSchemaToString(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestSchemaValidateOptionalProperties(t *testing.T) {
+
+	// create our schema manager
+	sm := jsontype.NewSchemaManager()
+	if sm == nil {
+		t.Fatal("failed to create schema manager")
+	}
+
+	// load our schema into the schema manager
+	err := sm.LoadSchema([]byte(`{"type":"Person","properties":{"name":{"type":"string"}},"optional_properties":{"age":{"type":"number","rules":{"min":0}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// get our schema from the schema manager
+	schema, err := sm.GetSchema("person")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// optional properties may be missing or null
+	err = schema.Validate([]byte(`{"name": "John"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = schema.Validate([]byte(`{"name": "John", "age": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = schema.Validate([]byte(`{"name": "John", "age": 30}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test optional property rule violation
+	err = schema.Validate([]byte(`{"name": "John", "age": -1}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSchemaValidateValue(t *testing.T) {
+
+	// create our schema manager
+	sm := jsontype.NewSchemaManager()
+	if sm == nil {
+		t.Fatal("failed to create schema manager")
+	}
+
+	// load our schema into the schema manager
+	err := sm.LoadSchema([]byte(`{"type":"Person","properties":{"name":{"type":"string"},"age":{"type":"number","rules":{"oneof":[30,40]}},"tags":{"type":"array","items":{"type":"string"}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// get our schema from the schema manager
+	schema, err := sm.GetSchema("person")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test already decoded data
+	err = schema.ValidateValue(map[string]interface{}{"name": "John", "age": 30.0, "tags": []interface{}{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test go kinds inside decoded data
+	err = schema.ValidateValue(map[string]interface{}{"name": "John", "age": 30, "tags": []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test raw json
+	err = schema.ValidateValue(json.RawMessage(`{"name": "John", "age": 40, "tags": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test structs
+	type Person struct {
+		Name string   `json:"name"`
+		Age  uint8    `json:"age"`
+		Tags []string `json:"tags"`
+		Note string   `json:"note,omitempty"`
+	}
+	err = schema.ValidateValue(&Person{Name: "John", Age: 30, Tags: []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test rule violation
+	err = schema.ValidateValue(Person{Name: "John", Age: 31, Tags: []string{"a"}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test undefined property
+	err = schema.ValidateValue(Person{Name: "John", Age: 30, Tags: []string{"a"}, Note: "hi"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test non-object documents
+	err = schema.ValidateValue([]string{"John"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	err = schema.Validate([]byte(`42`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test null values for required properties
+	err = schema.Validate([]byte(`{"name": null, "age": 30, "tags": []}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// malformedJSON is a json.Marshaler that returns malformed JSON
+type malformedJSON struct{}
+
+func (malformedJSON) MarshalJSON() ([]byte, error) {
+	return []byte(`{"name": `), nil
+}
+
+// node is a struct that may point to itself
+type node struct {
+	Name string `json:"name"`
+	Next *node  `json:"next"`
+}
+
+func TestSchemaValidateValueCycle(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, `{"type": "Node", "allow_undefined_properties": true, "properties": {"name": {"type": "string"}}}`), "node")
+
+	// test values that contain themselves fail rather than recursing forever
+	n := &node{Name: "a"}
+	n.Next = n
+	if err := s.ValidateValue(n); err == nil {
+		t.Fatal("expected error")
+	}
+	m := map[string]interface{}{"name": "a"}
+	m["next"] = m
+	if err := s.ValidateValue(m); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test the same value may appear more than once
+	shared := &node{Name: "b"}
+	if err := s.ValidateValue(map[string]interface{}{"name": "a", "x": shared, "y": shared}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaValidateValueMalformedJSON(t *testing.T) {
+	s := getSchema(t, loadSchemas(t, `{"type": "Node", "allow_undefined_properties": true, "properties": {}}`), "node")
+	for _, value := range []interface{}{json.RawMessage(`{"name": `), malformedJSON{}, map[string]interface{}{"x": malformedJSON{}}} {
+		if err := s.ValidateValue(value); err == nil {
+			t.Fatalf("%#v: expected error", value)
+		}
+	}
+}
+
+func TestSchemaManagerVersions(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	for _, def := range []string{
+		`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "number"}}}`,
+		`{"type": "Order", "version": "2.0.0", "properties": {"id": {"type": "string"}}}`,
+		`{"type": "Order", "version": "1.2.0", "properties": {"id": {"type": "number"}, "note": {"type": "string"}}}`,
+		`{"type": "Order", "version": "3.0.0-beta.1", "properties": {}}`,
+	} {
+		err := sm.LoadSchema([]byte(def))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions := sm.ListVersions("order")
+	if !reflect.DeepEqual(versions, []string{"1.0.0", "1.2.0", "2.0.0", "3.0.0-beta.1"}) {
+		t.Fatalf("unexpected versions %v", versions)
+	}
+
+	for ref, expected := range map[string]string{
+		"order":                  "2.0.0",
+		"Order@latest":           "2.0.0",
+		"order@1.0.0":            "1.0.0",
+		"order@^1":               "1.2.0",
+		"order@~1.0":             "1.0.0",
+		"order@1.x":              "1.2.0",
+		"order@>=1.0.0 <2.0.0":   "1.2.0",
+		"order@^2 || ^1":         "2.0.0",
+		"order@*":                "2.0.0",
+		"order@3.0.0-beta.1":     "3.0.0-beta.1",
+		"order@>=3.0.0-beta.0":   "3.0.0-beta.1",
+		"order@>1.2.0 <=2.0.0":   "2.0.0",
+		"order@^1.0.0 <1.1":      "1.0.0",
+		"order@v1.2.0+build.123": "1.2.0",
+	} {
+		schema, err := sm.GetSchema(ref)
+		if err != nil {
+			t.Fatalf("%s: %v", ref, err)
+		}
+		if schema.Version != expected {
+			t.Fatalf("%s: expected version %s but got %s", ref, expected, schema.Version)
+		}
+	}
+
+	for _, ref := range []string{"order@^4", "order@1.1.0", "order@bad", "missing@1.0.0"} {
+		_, err := sm.GetSchema(ref)
+		if err == nil {
+			t.Fatalf("%s: expected error", ref)
+		}
+	}
+
+	// test replacing a version requires the Replace option
+	replacement := []byte(`{"type": "Order", "version": "1.0.0", "properties": {"id": {"type": "bool"}}}`)
+	err := sm.LoadSchema(replacement)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	err = sm.LoadSchema(replacement, jsontype.Replace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, _ := sm.GetSchema("order@1.0.0")
+	if err := schema.Validate([]byte(`{"id": true}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// test invalid versions
+	err = sm.LoadSchema([]byte(`{"type": "Order", "version": "1.0", "properties": {}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test extending a version of a schema
+	err = sm.LoadSchema([]byte(`{"type": "Refund", "extends": "Order@^1", "properties": {"amount": {"type": "number"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	refund, _ := sm.GetSchema("refund")
+	if err := refund.Validate([]byte(`{"id": 1, "note": "", "amount": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// test deleting a single version, then every version
+	err = sm.DeleteSchema("order@2.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema, _ := sm.GetSchema("order"); schema.Version != "1.2.0" {
+		t.Fatalf("expected the latest version to be 1.2.0 but got %s", schema.Version)
+	}
+	err = sm.DeleteSchema("order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sm.ListVersions("order")) != 0 {
+		t.Fatal("expected every version to be deleted")
+	}
+}
+
+func TestSchemaManagerVersionRangeCycle(t *testing.T) {
+	sm := loadSchemas(t,
+		`{"type": "A", "version": "1.0.0", "properties": {"a": {"type": "string"}}}`,
+		`{"type": "B", "extends": "A@^1", "properties": {"b": {"type": "string"}}}`,
+	)
+
+	// test a new version that a version range will resolve to cannot close a
+	// circular extends chain
+	err := sm.LoadSchema([]byte(`{"type": "A", "version": "1.1.0", "extends": "B", "properties": {}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if versions := sm.ListVersions("a"); len(versions) != 1 || versions[0] != "1.0.0" {
+		t.Fatalf("unexpected versions %v", versions)
+	}
+
+	err = sm.LoadSchema([]byte(`{"type": "C", "extends": "B", "properties": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := getSchema(t, sm, "c")
+	if err := schema.Validate([]byte(`{"a": "", "b": ""}`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaRoot(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	err := sm.LoadSchemas(map[string][]byte{
+		"orders.json": []byte(`{"type": "Orders", "root": {"type": "array", "items": {"type": "object", "ref": "Order"}, "rules": {"max_length": 2}}}`),
+		"order.json":  []byte(`{"type": "Order", "properties": {"id": {"type": "number"}}}`),
+		"price.json":  []byte(`{"type": "Price", "root": {"type": "number", "rules": {"min": 0}}}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, _ := sm.GetSchema("orders")
+	price, _ := sm.GetSchema("price")
+
+	if err := orders.Validate([]byte(`[{"id": 1}, {"id": 2}]`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := price.Validate([]byte(`9.99`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// test errors within and of the root
+	for document, expected := range map[string]string{
+		`[{"id": 1}, {"id": "2"}]`:          "[1].id",
+		`[{"id": 1}, {"id": 2}, {"id": 3}]`: "",
+		`{"id": 1}`:                         "",
+	} {
+		var errs jsontype.ValidationErrors
+		if err := orders.Validate([]byte(document)); !errors.As(err, &errs) || len(errs) != 1 || errs[0].Path != expected {
+			t.Fatalf("%s: expected an error at %q but got %v", document, expected, err)
+		}
+		if err := orders.ValidateStream(strings.NewReader(document)); err == nil {
+			t.Fatalf("%s: expected the stream validator to fail", document)
+		}
+	}
+	err = price.Validate([]byte(`"9.99"`))
+	if err == nil || err.Error() != "document must be a number but got string" {
+		t.Fatalf("expected a root type error but got %v", err)
+	}
+	if err := price.Validate([]byte(`-1`)); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test the properties of an array root
+	if err := orders.ValidateProperty("[0].id", []byte(`"1"`)); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test a schema with a root that is not an object cannot have properties
+	err = sm.LoadSchema([]byte(`{"type": "Bad", "root": {"type": "array"}, "properties": {"id": {"type": "number"}}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	err = sm.LoadSchema([]byte(`{"type": "Bad", "root": {"type": "date"}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test generated documents satisfy the root
+	for _, s := range []*jsontype.Schema{orders, price} {
+		document, err := jsontype.NewGenerator(s, jsontype.GenerateOptions{}).Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Validate(document); err != nil {
+			t.Fatalf("%s: %v", document, err)
+		}
+	}
+}
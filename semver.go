@@ -0,0 +1,244 @@
+package jsontype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version is a semantic version, see https://semver.org
+type version struct {
+	major, minor, patch uint64
+	pre                 []string
+}
+
+// parseVersion parses a semantic version such as 1.2.3 or v1.2.3-beta.1,
+// build metadata is ignored
+func parseVersion(s string) (version, error) {
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return version{}, err
+	}
+	if parts != 3 {
+		return version{}, fmt.Errorf("invalid version %s, it must be major.minor.patch", s)
+	}
+	return v, nil
+}
+
+// parsePartialVersion parses a version that may be missing its minor and
+// patch numbers, or use x or * in their place, and returns how many of the
+// numbers were given
+func parsePartialVersion(s string) (version, int, error) {
+	v := version{}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, hasPre := strings.Cut(s, "-")
+	if hasPre {
+		if pre == "" {
+			return v, 0, fmt.Errorf("invalid version %s, the pre-release is empty", s)
+		}
+		v.pre = strings.Split(pre, ".")
+	}
+
+	numbers := []*uint64{&v.major, &v.minor, &v.patch}
+	fields := strings.Split(s, ".")
+	if len(fields) > 3 {
+		return v, 0, fmt.Errorf("invalid version %s", s)
+	}
+	for i, field := range fields {
+		if field == "x" || field == "X" || field == "*" {
+			if hasPre {
+				return v, 0, fmt.Errorf("invalid version %s, a partial version cannot have a pre-release", s)
+			}
+			return v, i, nil
+		}
+		n, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return v, 0, fmt.Errorf("invalid version %s", s)
+		}
+		*numbers[i] = n
+	}
+	if hasPre && len(fields) != 3 {
+		return v, 0, fmt.Errorf("invalid version %s, a partial version cannot have a pre-release", s)
+	}
+	return v, len(fields), nil
+}
+
+func (v version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+	if len(v.pre) > 0 {
+		s += "-" + strings.Join(v.pre, ".")
+	}
+	return s
+}
+
+// compare returns -1, 0 or 1 when v is lower than, equal to or higher than o
+func (v version) compare(o version) int {
+	for _, pair := range [][2]uint64{{v.major, o.major}, {v.minor, o.minor}, {v.patch, o.patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// a pre-release is lower than the release
+	switch {
+	case len(v.pre) == 0 && len(o.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(o.pre); i++ {
+		if c := comparePreRelease(v.pre[i], o.pre[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.pre) < len(o.pre):
+		return -1
+	case len(v.pre) > len(o.pre):
+		return 1
+	}
+	return 0
+}
+
+// comparePreRelease compares pre-release identifiers, numeric identifiers
+// are compared numerically and are lower than alphanumeric ones
+func comparePreRelease(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		if na == nb {
+			return 0
+		}
+		if na < nb {
+			return -1
+		}
+		return 1
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// versionConstraint matches versions, it is a set of alternatives separated
+// by || where every comparator of an alternative must match
+type versionConstraint [][]versionComparator
+
+type versionComparator struct {
+	op string
+	v  version
+}
+
+// parseConstraint parses a version constraint such as 1.2.3, ^1.2, ~1.2.0,
+// 1.x, >=1.0.0 <2.0.0 or ^1 || ^2
+func parseConstraint(s string) (versionConstraint, error) {
+	c := versionConstraint{}
+	for _, alternative := range strings.Split(s, "||") {
+		comparators := []versionComparator{}
+		fields := strings.Fields(alternative)
+		if len(fields) == 0 {
+			fields = []string{"*"}
+		}
+		for _, field := range fields {
+			parsed, err := parseComparator(field)
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, parsed...)
+		}
+		c = append(c, comparators)
+	}
+	return c, nil
+}
+
+// parseComparator parses a single comparator, ranges such as ^1.2 become a
+// lower and upper bound
+func parseComparator(s string) ([]versionComparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op, s = prefix, s[len(prefix):]
+			break
+		}
+	}
+	if s == "*" || s == "x" || s == "X" {
+		return []versionComparator{{op: ">=", v: version{}}}, nil
+	}
+
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return nil, err
+	}
+
+	// upper bounds exclude the pre-releases of the next version
+	upper := func(v version) versionComparator {
+		v.pre = []string{"0"}
+		return versionComparator{op: "<", v: v}
+	}
+	next := func() versionComparator {
+		switch {
+		case parts == 1 || op == "^" && v.major > 0:
+			return upper(version{major: v.major + 1})
+		case op == "^" && parts == 3 && v.minor == 0:
+			return upper(version{patch: v.patch + 1})
+		}
+		return upper(version{major: v.major, minor: v.minor + 1})
+	}
+
+	switch op {
+	case "^", "~":
+		return []versionComparator{{op: ">=", v: v}, next()}, nil
+	case "", "=":
+		if parts == 3 {
+			return []versionComparator{{op: "=", v: v}}, nil
+		}
+		return []versionComparator{{op: ">=", v: v}, next()}, nil
+	}
+	return []versionComparator{{op: op, v: v}}, nil
+}
+
+// match reports whether v satisfies the constraint. Pre-releases only match
+// a comparator set that names a pre-release of the same version.
+func (c versionConstraint) match(v version) bool {
+	for _, comparators := range c {
+		matched := true
+		allowPre := len(v.pre) == 0
+		for _, comparator := range comparators {
+			if !comparator.match(v) {
+				matched = false
+				break
+			}
+			cv := comparator.v
+			if comparator.op != "<" && len(cv.pre) > 0 && cv.major == v.major && cv.minor == v.minor && cv.patch == v.patch {
+				allowPre = true
+			}
+		}
+		if matched && allowPre {
+			return true
+		}
+	}
+	return false
+}
+
+func (c versionComparator) match(v version) bool {
+	cmp := v.compare(c.v)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return cmp == 0
+}
@@ -0,0 +1,222 @@
+package jsontype
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrSchemaNotFound is returned by a Store for a key it does not hold
+var ErrSchemaNotFound = errors.New("schema not found")
+
+// A Store persists schema definitions. Schemas are stored by key, the lower
+// case type and version of the schema such as order@1.2.0, or the type alone
+// for a schema without a version.
+type Store interface {
+	// List returns the keys of every stored schema
+	List(ctx context.Context) ([]string, error)
+	// Get returns the definition stored with a key, or ErrSchemaNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put stores a definition, replacing any definition with the same key
+	Put(ctx context.Context, key string, definition []byte) error
+	// Delete removes the definition with a key, or returns ErrSchemaNotFound
+	Delete(ctx context.Context, key string) error
+}
+
+// storeKey returns the key a schema is stored with
+func storeKey(s *Schema) string {
+	return strings.ToLower(s.ref())
+}
+
+// loadStore loads every schema held by a store into the SchemaManager, without
+// writing them through to the store the SchemaManager uses
+func (sm *SchemaManager) loadStore(ctx context.Context, store Store) error {
+	keys, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	files := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		def, err := store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		files[key] = def
+	}
+	if errs := sm.loadSchemas(files, true); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// MemoryStore is a Store that holds schemas in memory
+type MemoryStore struct {
+	mu       sync.RWMutex
+	schemas  map[string][]byte
+	revision int
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{schemas: make(map[string][]byte)}
+}
+
+func (m *MemoryStore) List(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return sortedKeys(m.schemas), nil
+}
+
+func (m *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	def, ok := m.schemas[key]
+	if !ok {
+		return nil, ErrSchemaNotFound
+	}
+	return append([]byte{}, def...), nil
+}
+
+func (m *MemoryStore) Put(ctx context.Context, key string, definition []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schemas[key] = append([]byte{}, definition...)
+	m.revision++
+	return nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.schemas[key]; !ok {
+		return ErrSchemaNotFound
+	}
+	delete(m.schemas, key)
+	m.revision++
+	return nil
+}
+
+// Revision returns the number of changes made to the store
+func (m *MemoryStore) Revision(ctx context.Context) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return strconv.Itoa(m.revision), nil
+}
+
+// DirStore is a Store that keeps every schema in a file of a directory, named
+// after its key such as order@1.2.0.json
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates a DirStore for a directory, creating the directory if
+// it does not exist
+func NewDirStore(dir string) (*DirStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &DirStore{dir: dir}, nil
+}
+
+// path returns the file a key is stored in
+func (d *DirStore) path(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid schema key %q", key)
+	}
+	return filepath.Join(d.dir, key+".json"), nil
+}
+
+func (d *DirStore) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			keys = append(keys, strings.TrimSuffix(entry.Name(), ".json"))
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (d *DirStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	def, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSchemaNotFound
+	}
+	return def, err
+}
+
+// Put writes the definition to a temporary file which is then renamed, so
+// readers never see a partially written schema
+func (d *DirStore) Put(ctx context.Context, key string, definition []byte) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(d.dir, ".schema-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(definition)
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (d *DirStore) Delete(ctx context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrSchemaNotFound
+	}
+	return err
+}
+
+// Revision returns a hash of the name, modification time and size of every
+// schema file, so other processes writing to the directory are noticed
+func (d *DirStore) Revision(ctx context.Context) (string, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New64a()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(h, "%s %d %d\n", entry.Name(), info.ModTime().UnixNano(), info.Size())
+	}
+	return strconv.FormatUint(h.Sum64(), 16), nil
+}
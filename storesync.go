@@ -0,0 +1,355 @@
+package jsontype
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A RevisionStore is a Store that can report cheaply whether it has changed,
+// so a StoreSync only reads every schema when something was put or deleted
+type RevisionStore interface {
+	Store
+	// Revision returns a value that changes whenever a schema is put or
+	// deleted
+	Revision(ctx context.Context) (string, error)
+}
+
+// StoreOptions configures how a SchemaManager uses a Store
+type StoreOptions struct {
+	// PollInterval is how often the store is checked for schemas put or
+	// deleted by other processes, it defaults to a second
+	PollInterval time.Duration
+}
+
+// StoreSync keeps the schemas of a SchemaManager in sync with a Store, so
+// every process that shares the store sees the same schemas. The schemas
+// loaded into the SchemaManager act as a cache of the store:
+//
+//   - LoadSchema, LoadSchemas, LoadFS and LoadDir write the schemas they load
+//     through to the store, and DeleteSchema deletes them from it
+//   - GetSchema checks the store for a schema that is not loaded before it
+//     fails, a schema that is still missing is not checked for again until
+//     the store is next refreshed
+//   - schemas put or deleted by other processes are loaded or deleted every
+//     PollInterval
+//
+// Like a Watcher, every change read from the store is applied atomically,
+// and sent to subscribers as WatchEvents.
+type StoreSync struct {
+	sm      *SchemaManager
+	store   Store
+	options StoreOptions
+
+	// mu guards subscribers, files and revision, and serializes refreshes and
+	// writes
+	mu          sync.Mutex
+	subscribers []func(WatchEvent)
+	// files maps the keys of the schemas loaded from the store to their store
+	// key and definition
+	files map[string]watchedFile
+	// revision is the revision of a RevisionStore as of the last refresh
+	revision string
+	// misses holds the references GetSchema did not find since the last
+	// refresh
+	misses map[string]bool
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// UseStore loads every schema held by the store into the SchemaManager, and
+// keeps the two in sync until the returned StoreSync is closed. Only one
+// store can be used at a time. Schemas loaded before UseStore is called are
+// kept, but are not written to the store.
+func (sm *SchemaManager) UseStore(store Store, options StoreOptions) (*StoreSync, error) {
+	if options.PollInterval <= 0 {
+		options.PollInterval = time.Second
+	}
+	ss := &StoreSync{
+		sm:      sm,
+		store:   store,
+		options: options,
+		files:   make(map[string]watchedFile),
+		misses:  make(map[string]bool),
+		done:    make(chan struct{}),
+	}
+	if _, err := ss.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+
+	sm.mu.Lock()
+	if sm.store != nil {
+		sm.mu.Unlock()
+		return nil, errors.New("the schema manager already uses a store")
+	}
+	sm.store = ss
+	sm.mu.Unlock()
+
+	ss.wg.Add(1)
+	go ss.poll()
+	return ss, nil
+}
+
+// storeSync returns the StoreSync in use, or nil
+func (sm *SchemaManager) storeSync() *StoreSync {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.store
+}
+
+// Subscribe registers fn to be called with every event, in the order the
+// events happen
+func (ss *StoreSync) Subscribe(fn func(WatchEvent)) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.subscribers = append(ss.subscribers, fn)
+}
+
+// Refresh reads the store immediately, it returns the reason the refresh
+// failed if it did
+func (ss *StoreSync) Refresh(ctx context.Context) error {
+	events, err := ss.refresh(ctx)
+	ss.send(events)
+	return err
+}
+
+// Close stops keeping the SchemaManager in sync with the store, the loaded
+// schemas are kept. Calling Close again has no effect.
+func (ss *StoreSync) Close() error {
+	ss.closeOnce.Do(func() {
+		ss.sm.mu.Lock()
+		if ss.sm.store == ss {
+			ss.sm.store = nil
+		}
+		ss.sm.mu.Unlock()
+		close(ss.done)
+		ss.wg.Wait()
+	})
+	return nil
+}
+
+// refreshMissing refreshes the store for a reference GetSchema did not find,
+// and reports whether it did. The store is not refreshed for a reference that
+// was already missing since the last refresh.
+func (ss *StoreSync) refreshMissing(ctx context.Context, ref string) bool {
+	ss.mu.Lock()
+	missed := ss.misses[strings.ToLower(ref)]
+	ss.mu.Unlock()
+	return !missed && ss.Refresh(ctx) == nil
+}
+
+// missing records a reference that is still not found after a refresh
+func (ss *StoreSync) missing(ref string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.misses[strings.ToLower(ref)] = true
+}
+
+// poll refreshes the schemas every PollInterval
+func (ss *StoreSync) poll() {
+	defer ss.wg.Done()
+	ticker := time.NewTicker(ss.options.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ss.done:
+			return
+		case <-ticker.C:
+			ss.Refresh(context.Background())
+		}
+	}
+}
+
+// refresh reads every schema held by the store, and replaces the schemas
+// previously loaded from it when any have changed
+func (ss *StoreSync) refresh(ctx context.Context) ([]WatchEvent, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	fail := func(err error) ([]WatchEvent, error) {
+		return []WatchEvent{{Kind: ReloadFailed, Err: err}}, err
+	}
+	ss.misses = make(map[string]bool)
+
+	// the revision is read first, so a change made while refreshing is picked
+	// up by the next refresh
+	var revision string
+	if rs, ok := ss.store.(RevisionStore); ok {
+		var err error
+		revision, err = rs.Revision(ctx)
+		if err != nil {
+			return fail(err)
+		}
+		if revision != "" && revision == ss.revision {
+			return nil, nil
+		}
+	}
+
+	keys, err := ss.store.List(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	files := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		def, err := ss.store.Get(ctx, key)
+		if errors.Is(err, ErrSchemaNotFound) {
+			continue
+		}
+		if err != nil {
+			return fail(LoadErrors{{File: key, Err: err}})
+		}
+		files[key] = def
+	}
+	ss.revision = revision
+	if ss.unchanged(files) {
+		return nil, nil
+	}
+
+	loaded, events, errs := ss.sm.syncSchemas(ss.files, files, func(key string) string {
+		return key
+	})
+	if len(errs) > 0 {
+		return fail(errs)
+	}
+	ss.files = loaded
+	return events, nil
+}
+
+// unchanged reports whether the definitions held by the store are the ones
+// loaded from it. The caller must hold ss.mu.
+func (ss *StoreSync) unchanged(files map[string][]byte) bool {
+	if len(files) != len(ss.files) {
+		return false
+	}
+	for _, file := range ss.files {
+		def, ok := files[file.name]
+		if !ok || string(def) != string(file.def) {
+			return false
+		}
+	}
+	return true
+}
+
+// load loads a set of schema definitions into the SchemaManager, and writes
+// every schema that loads to the store. A schema that cannot be written is
+// unloaded again, and reported as a LoadError.
+func (ss *StoreSync) load(files map[string][]byte, replace bool) LoadErrors {
+	ss.mu.Lock()
+
+	headers := make(map[string]*Schema, len(files))
+	previous := make(map[string]*Schema, len(files))
+	ss.sm.mu.RLock()
+	for name, def := range files {
+		if header, err := decodeSchema(def); err == nil {
+			headers[name] = header
+			previous[name] = ss.sm.exactVersion(header.Type, header.Version)
+		}
+	}
+	ss.sm.mu.RUnlock()
+
+	errs := ss.sm.loadSchemas(files, replace)
+	failed := make(map[string]bool, len(errs))
+	for _, e := range errs {
+		failed[e.File] = true
+	}
+
+	ctx := context.Background()
+	events := []WatchEvent{}
+	for _, name := range sortedKeys(headers) {
+		if failed[name] {
+			continue
+		}
+		header := headers[name]
+		key := storeKey(header)
+		err := ss.store.Put(ctx, key, files[name])
+		if err != nil {
+			ss.sm.mu.Lock()
+			if previous[name] != nil {
+				ss.sm.storeSchema(previous[name])
+			} else {
+				ss.sm.removeSchema(header.Type, header.Version)
+			}
+			ss.sm.mu.Unlock()
+			errs = append(errs, &LoadError{File: name, Err: fmt.Errorf("schema %s could not be stored: %w", header.ref(), err)})
+			continue
+		}
+
+		ss.files[header.key()] = watchedFile{name: key, def: files[name]}
+		kind := SchemaLoaded
+		if previous[name] != nil {
+			kind = SchemaReplaced
+		}
+		events = append(events, WatchEvent{Kind: kind, Schema: header.Type, Version: header.Version, File: key})
+	}
+	ss.mu.Unlock()
+
+	ss.send(events)
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].File < errs[j].File
+	})
+	return errs
+}
+
+// delete deletes the schemas named by a reference from the store, and then
+// from the SchemaManager
+func (ss *StoreSync) delete(ref string) error {
+	ss.mu.Lock()
+
+	schemaType, version, hasVersion := strings.Cut(ref, "@")
+	ss.sm.mu.RLock()
+	schemas := []*Schema{}
+	for _, s := range ss.sm.versions[strings.ToLower(schemaType)] {
+		if !hasVersion || s.Version == version {
+			schemas = append(schemas, s)
+		}
+	}
+	ss.sm.mu.RUnlock()
+
+	ctx := context.Background()
+	events := []WatchEvent{}
+	for _, s := range schemas {
+		key := storeKey(s)
+		if file, ok := ss.files[s.key()]; ok {
+			key = file.name
+		}
+		err := ss.store.Delete(ctx, key)
+		if err != nil && !errors.Is(err, ErrSchemaNotFound) {
+			ss.mu.Unlock()
+			ss.send(events)
+			return fmt.Errorf("schema %s could not be deleted: %w", s.ref(), err)
+		}
+		ss.sm.mu.Lock()
+		ss.sm.removeSchema(s.Type, s.Version)
+		ss.sm.mu.Unlock()
+		delete(ss.files, s.key())
+		events = append(events, WatchEvent{Kind: SchemaDeleted, Schema: s.Type, Version: s.Version, File: key})
+	}
+	ss.mu.Unlock()
+
+	ss.send(events)
+	if len(schemas) == 0 {
+		if hasVersion {
+			return fmt.Errorf("schema %s@%s not found", strings.ToLower(schemaType), version)
+		}
+		return fmt.Errorf("schema %s not found", strings.ToLower(schemaType))
+	}
+	return nil
+}
+
+// send calls every subscriber with the events
+func (ss *StoreSync) send(events []WatchEvent) {
+	ss.mu.Lock()
+	subscribers := append([]func(WatchEvent){}, ss.subscribers...)
+	ss.mu.Unlock()
+	for _, event := range events {
+		for _, fn := range subscribers {
+			fn(event)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package jsontype_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apageadev/jsontype"
+)
+
+const storedAnimal = `{"type": "Animal", "properties": {"name": {"type": "string"}}}`
+
+// animalStore creates a store that holds the Animal schema
+func animalStore(t *testing.T) *jsontype.MemoryStore {
+	t.Helper()
+	store := jsontype.NewMemoryStore()
+	if err := store.Put(context.Background(), "animal", []byte(storedAnimal)); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// failingStore is a MemoryStore that fails to put schemas
+type failingStore struct {
+	*jsontype.MemoryStore
+}
+
+func (s failingStore) Put(ctx context.Context, key string, def []byte) error {
+	return errors.New("the store is read only")
+}
+
+// withTimeout fails the test if fn does not return within a few seconds
+func withTimeout(t *testing.T, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestSchemaManagerUseStore(t *testing.T) {
+	sm := useStore(t, animalStore(t))
+	if _, err := sm.GetSchema("animal"); err != nil {
+		t.Fatal(err)
+	}
+
+	// test only one store can be used at a time
+	if _, err := sm.UseStore(jsontype.NewMemoryStore(), jsontype.StoreOptions{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestStoreSyncWriteThrough(t *testing.T) {
+	store := animalStore(t)
+	sm := useStore(t, store)
+	err := sm.LoadSchema([]byte(`{"type": "Dog", "version": "1.0.0", "extends": "Animal", "properties": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(context.Background(), "dog@1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreSyncWriteFailure(t *testing.T) {
+	sm := useStore(t, failingStore{animalStore(t)})
+
+	// test a schema that cannot be stored is not loaded
+	err := sm.LoadSchema([]byte(`{"type": "Dog", "extends": "Animal", "properties": {}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := sm.GetSchema("dog"); err == nil {
+		t.Fatal("expected the schema to be unloaded")
+	}
+}
+
+func TestStoreSyncReadThrough(t *testing.T) {
+	store := animalStore(t)
+	sm := useStore(t, store)
+
+	// test schemas stored by another process are read through
+	err := store.Put(context.Background(), "cat", []byte(`{"type": "Cat", "extends": "Animal", "properties": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.GetSchema("cat"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// revisionCounter is a MemoryStore that counts how often it is refreshed
+type revisionCounter struct {
+	*jsontype.MemoryStore
+	revisions int
+}
+
+func (s *revisionCounter) Revision(ctx context.Context) (string, error) {
+	s.revisions++
+	return s.MemoryStore.Revision(ctx)
+}
+
+func TestStoreSyncReadThroughMisses(t *testing.T) {
+	store := &revisionCounter{MemoryStore: animalStore(t)}
+	sm := jsontype.NewSchemaManager()
+	ss, err := sm.UseStore(store, jsontype.StoreOptions{PollInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+
+	// test a schema that is still missing does not refresh the store again
+	for i := 0; i < 3; i++ {
+		if _, err := sm.GetSchema("cat"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if store.revisions != 2 {
+		t.Fatalf("expected 2 refreshes but got %d", store.revisions)
+	}
+
+	// test the miss is forgotten once the store is refreshed, so the schema
+	// is read through when it is stored
+	if err := ss.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err = store.Put(context.Background(), "cat", []byte(`{"type": "Cat", "extends": "Animal", "properties": {}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sm.GetSchema("cat"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreSyncUnknownDependency(t *testing.T) {
+	sm := useStore(t, jsontype.NewMemoryStore())
+
+	// test loading a schema whose dependencies are missing fails rather than
+	// reading the store while it is being written
+	withTimeout(t, func() {
+		for _, def := range []string{
+			`{"type": "Dog", "extends": "Animal", "properties": {}}`,
+			`{"type": "Kennel", "properties": {"dog": {"type": "object", "ref": "Dog"}}}`,
+		} {
+			if err := sm.LoadSchema([]byte(def)); err == nil {
+				t.Errorf("expected error loading %s", def)
+			}
+		}
+	})
+	if sm.SchemaCount() != 0 {
+		t.Fatalf("unexpected %d schemas", sm.SchemaCount())
+	}
+}
+
+func TestStoreSyncInvalidRefresh(t *testing.T) {
+	ctx := context.Background()
+	store := animalStore(t)
+	sm := jsontype.NewSchemaManager()
+	ss, err := sm.UseStore(store, jsontype.StoreOptions{PollInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+	if err := sm.LoadSchema([]byte(`{"type": "Dog", "extends": "Animal", "properties": {}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// test an invalid change to the store does not replace the loaded schemas
+	if err := store.Delete(ctx, "animal"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ss.Refresh(ctx); err == nil || sm.SchemaCount() != 2 {
+		t.Fatalf("expected the refresh to fail but got %v with %d schemas", err, sm.SchemaCount())
+	}
+}
+
+func TestStoreSyncDelete(t *testing.T) {
+	ctx := context.Background()
+	store := animalStore(t)
+	sm := useStore(t, store)
+	if err := sm.DeleteSchema("animal"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "animal"); !errors.Is(err, jsontype.ErrSchemaNotFound) {
+		t.Fatalf("expected the schema to be deleted from the store but got %v", err)
+	}
+	if err := sm.DeleteSchema("animal"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestStoreSyncEvents(t *testing.T) {
+	ctx := context.Background()
+	store := animalStore(t)
+	sm := jsontype.NewSchemaManager()
+	ss, err := sm.UseStore(store, jsontype.StoreOptions{PollInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ss.Close()
+	events := []jsontype.WatchEvent{}
+	ss.Subscribe(func(event jsontype.WatchEvent) {
+		events = append(events, event)
+	})
+
+	sm.LoadSchema([]byte(`{"type": "Dog", "extends": "Animal", "properties": {}}`))
+	store.Put(ctx, "cat", []byte(`{"type": "Cat", "extends": "Animal", "properties": {}}`))
+	ss.Refresh(ctx)
+	store.Delete(ctx, "animal")
+	ss.Refresh(ctx)
+	sm.DeleteSchema("dog")
+
+	kinds := []jsontype.WatchEventKind{jsontype.SchemaLoaded, jsontype.SchemaLoaded, jsontype.ReloadFailed, jsontype.SchemaDeleted}
+	if len(events) != len(kinds) {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	for i, event := range events {
+		if event.Kind != kinds[i] {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	}
+}
+
+func TestStoreSyncClose(t *testing.T) {
+	store := animalStore(t)
+	sm := jsontype.NewSchemaManager()
+	ss, err := sm.UseStore(store, jsontype.StoreOptions{PollInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss.Close()
+	ss.Close()
+
+	// test the loaded schemas are kept, and new schemas are not stored
+	if err := sm.LoadSchema([]byte(`{"type": "Dog", "extends": "Animal", "properties": {}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(context.Background(), "dog"); !errors.Is(err, jsontype.ErrSchemaNotFound) {
+		t.Fatalf("expected the schema not to be stored but got %v", err)
+	}
+}
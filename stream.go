@@ -0,0 +1,410 @@
+package jsontype
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// NOTE: the streaming validators use the encoding/json tokenizer, as unlike
+// go-json it reports syntax errors within the token stream
+
+// ValidateStream validates a single JSON document read from r against the
+// schema. Unlike Validate, the document is never held in memory as a whole.
+// It is tokenized incrementally and validated as it is read, only values that
+// have rules which need the whole value, such as max_length on an array, are
+// buffered.
+func (s *Schema) ValidateStream(r io.Reader) error {
+	v := newStreamValidator(r)
+	err := v.validateDocument(s)
+	if err != nil {
+		return err
+	}
+
+	// there must not be anything after the document
+	if _, err := v.dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after the document")
+		}
+		return err
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// ValidateRecords validates a JSON array of records read from r, where every
+// record is a document that must satisfy the schema. report is called with
+// the index and validation result of every record as it is read, so memory
+// use does not grow with the number of records. An error is only returned if
+// the array itself cannot be read.
+func (s *Schema) ValidateRecords(r io.Reader, report func(index int, err error)) error {
+	v := newStreamValidator(r)
+	tok, err := v.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("records must be an array but got %s", tokenKind(tok))
+	}
+
+	for index := 0; v.dec.More(); index++ {
+		err := v.validateDocument(s)
+		if err != nil {
+			return err
+		}
+		if len(v.errs) > 0 {
+			report(index, v.errs)
+		} else {
+			report(index, nil)
+		}
+		v.errs = nil
+	}
+
+	// consume the closing delimiter
+	_, err = v.dec.Token()
+	return err
+}
+
+// DefaultMaxLineSize is the longest line ValidateLines accepts when
+// LinesOptions.MaxLineSize is not set
+const DefaultMaxLineSize = 1024 * 1024
+
+// LinesOptions configures how a JSON Lines stream is validated
+type LinesOptions struct {
+	// MaxLineSize is the longest line in bytes that is validated, longer lines
+	// are reported as errors without being held in memory. Defaults to
+	// DefaultMaxLineSize.
+	MaxLineSize int
+}
+
+// ValidateLines validates a JSON Lines (NDJSON) stream read from r, where
+// every line is a separate document that must satisfy the schema. report is
+// called with the line number, starting at 1, and validation result of every
+// non-empty line. Only a single line is held in memory at a time. An error is
+// only returned if the stream cannot be read.
+func (s *Schema) ValidateLines(r io.Reader, options LinesOptions, report func(line int, err error)) error {
+	maxLineSize := options.MaxLineSize
+	if maxLineSize <= 0 {
+		maxLineSize = DefaultMaxLineSize
+	}
+
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, tooLong, err := readLine(br, maxLineSize)
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if tooLong {
+			report(line, fmt.Errorf("line is longer than %d bytes", maxLineSize))
+		} else if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 {
+			report(line, s.ValidateStream(bytes.NewReader(trimmed)))
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// readLine reads the next line from br. Once a line is longer than max the
+// rest of it is discarded rather than buffered, and tooLong is returned.
+func readLine(br *bufio.Reader, max int) (line []byte, tooLong bool, err error) {
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if !tooLong {
+			line = append(line, chunk...)
+			if len(bytes.TrimRight(line, "\r\n")) > max {
+				line, tooLong = nil, true
+			}
+		}
+		if err != bufio.ErrBufferFull {
+			return line, tooLong, err
+		}
+	}
+}
+
+// streamValidator validates documents token by token
+type streamValidator struct {
+	dec  *json.Decoder
+	errs ValidationErrors
+}
+
+func newStreamValidator(r io.Reader) *streamValidator {
+	return &streamValidator{dec: json.NewDecoder(r)}
+}
+
+// validateDocument reads the next document and validates it against the
+// schema, validation errors are added to v.errs while errors reading the
+// document or in the schema itself are returned
+func (v *streamValidator) validateDocument(s *Schema) error {
+	tok, err := v.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch {
+	case !s.hasObjectRoot():
+		return v.validateValue(s, "", s.root(), tok)
+	case len(s.root().Rules) > 0:
+		value, err := v.readValue(tok)
+		if err != nil {
+			return err
+		}
+		return s.validateRoot(value, &v.errs)
+	case tok != json.Delim('{'):
+		root := s.root()
+		v.errs = append(v.errs, s.withMessage(&ValidationError{
+			Kind:     ErrorInvalidType,
+			Expected: "object",
+			Actual:   tokenKind(tok),
+		}, &root))
+		return v.skip(tok)
+	}
+	return v.validateObject(s, "")
+}
+
+// validateObject validates an object whose opening delimiter has already been
+// read against the schema's properties
+func (v *streamValidator) validateObject(s *Schema, path string) error {
+	properties, optionalProperties, err := s.resolveProperties()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(properties))
+	undefined := []string{}
+	for v.dec.More() {
+		tok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		seen[key] = true
+
+		p, required := properties[key]
+		if !required {
+			var optional bool
+			p, optional = optionalProperties[key]
+			if !optional {
+				if !s.AllowUndefinedProperties {
+					undefined = append(undefined, key)
+				}
+				err := v.skipValue()
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		tok, err = v.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		// optional properties are only validated when present, a null value is
+		// treated the same as a missing one
+		if tok == nil && !required {
+			continue
+		}
+
+		err = v.validateValue(s, joinPath(path, key), p, tok)
+		if err != nil {
+			return err
+		}
+	}
+
+	// consume the closing delimiter
+	if _, err := v.dec.Token(); err != nil {
+		return err
+	}
+
+	for _, property := range sortedKeys(properties) {
+		if !seen[property] {
+			p := properties[property]
+			v.errs = append(v.errs, s.withMessage(&ValidationError{
+				Path: joinPath(path, property),
+				Kind: ErrorMissingProperty,
+			}, &p))
+		}
+	}
+
+	// undefined properties are reported in sorted order, the same as Validate
+	sort.Strings(undefined)
+	for _, key := range undefined {
+		v.errs = append(v.errs, s.withMessage(&ValidationError{
+			Path: joinPath(path, key),
+			Kind: ErrorUndefinedProperty,
+		}, nil))
+	}
+	return nil
+}
+
+// validateValue validates the value starting with tok against its property
+// definition. Objects and arrays are validated as they are read unless the
+// property has rules, in which case the value is read into memory first.
+func (v *streamValidator) validateValue(s *Schema, path string, p Property, tok json.Token) error {
+	delim, isDelim := tok.(json.Delim)
+	if !isDelim || len(p.Rules) > 0 {
+		value, err := v.readValue(tok)
+		if err != nil {
+			return err
+		}
+		return s.validateProperty(path, p, value, &v.errs)
+	}
+
+	switch {
+	case delim == '{' && p.Type == "object":
+		if p.Ref == "" {
+			return v.skip(tok)
+		}
+		ref, err := s.lookup(p.Ref)
+		if err != nil {
+			return err
+		}
+		return v.validateObject(ref, path)
+
+	case delim == '[' && (p.Type == "array" || p.Type == "list"):
+		return v.validateArray(s, path, p)
+	}
+
+	v.errs = append(v.errs, s.withMessage(&ValidationError{
+		Path:     path,
+		Kind:     ErrorInvalidType,
+		Expected: p.Type,
+		Actual:   tokenKind(tok),
+	}, &p))
+	return v.skip(tok)
+}
+
+// validateArray validates the items of an array or list whose opening
+// delimiter has already been read
+func (v *streamValidator) validateArray(s *Schema, path string, p Property) error {
+	firstKind := ""
+	homogeneous := true
+	mark := len(v.errs)
+	for i := 0; v.dec.More(); i++ {
+		tok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		// items in an array must be of the same type, once they are not the
+		// errors of the items validated so far are replaced by a type error
+		kind := tokenKind(tok)
+		if firstKind == "" {
+			firstKind = kind
+		}
+		if kind != firstKind && p.Type == "array" && homogeneous {
+			homogeneous = false
+			v.errs = append(v.errs[:mark], s.withMessage(&ValidationError{
+				Path:     path,
+				Kind:     ErrorInvalidType,
+				Expected: p.Type,
+				Actual:   "list",
+			}, &p))
+		}
+
+		if homogeneous && p.Type == "array" && p.Items != nil {
+			err = v.validateValue(s, fmt.Sprintf("%s[%d]", path, i), *p.Items, tok)
+		} else {
+			err = v.skip(tok)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// consume the closing delimiter
+	_, err := v.dec.Token()
+	return err
+}
+
+// readValue reads the value starting with tok into memory
+func (v *streamValidator) readValue(tok json.Token) (interface{}, error) {
+	switch tok {
+	case json.Delim('{'):
+		object := make(map[string]interface{})
+		for v.dec.More() {
+			key, err := v.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			next, err := v.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := v.readValue(next)
+			if err != nil {
+				return nil, err
+			}
+			object[key.(string)] = value
+		}
+		_, err := v.dec.Token()
+		return object, err
+
+	case json.Delim('['):
+		items := []interface{}{}
+		for v.dec.More() {
+			next, err := v.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := v.readValue(next)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		_, err := v.dec.Token()
+		return items, err
+	}
+	return tok, nil
+}
+
+// skipValue reads and discards the next value
+func (v *streamValidator) skipValue() error {
+	tok, err := v.dec.Token()
+	if err != nil {
+		return err
+	}
+	return v.skip(tok)
+}
+
+// skip discards the value starting with tok
+func (v *streamValidator) skip(tok json.Token) error {
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// tokenKind returns the JSON type of the value starting with tok
+func tokenKind(tok json.Token) string {
+	switch tok {
+	case json.Delim('{'):
+		return "object"
+	case json.Delim('['):
+		return "list"
+	}
+	return jsonKind(tok)
+}
@@ -0,0 +1,198 @@
+package jsontype_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+var streamSchemas = []string{
+	`{"type": "Address", "properties": {"zip": {"type": "string", "rules": {"regex": "^[0-9]{5}$"}}}}`,
+	`{
+		"type": "Customer",
+		"properties": {
+			"name": {"type": "string", "rules": {"max_length": 5}},
+			"address": {"type": "object", "ref": "Address"},
+			"orders": {"type": "array", "items": {"type": "number", "rules": {"min": 1}}},
+			"tags": {"type": "array", "rules": {"max_length": 2}},
+			"history": {"type": "list"}
+		},
+		"optional_properties": {
+			"email": {"type": "string", "rules": {"format": "email"}}
+		}
+	}`,
+}
+
+const streamCustomer = `{"name": "Luna", "address": {"zip": "12345"}, "orders": [], "tags": [], "history": []}`
+
+func TestSchemaValidateStream(t *testing.T) {
+	schema := getSchema(t, loadSchemas(t, streamSchemas...), "customer")
+
+	err := schema.ValidateStream(strings.NewReader(`{"name": "Luna", "address": {"zip": "12345"}, "orders": [1, 2], "tags": ["a"], "history": [1, "a", {"b": [2]}], "email": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaValidateStreamMatchesValidate(t *testing.T) {
+	schema := getSchema(t, loadSchemas(t, streamSchemas...), "customer")
+
+	// the stream validator must agree with Validate
+	for _, document := range []string{
+		`{"name": "Blueberry", "address": {"zip": "1"}, "orders": [0, 2], "tags": ["a", "b", "c"], "history": []}`,
+		`{"name": "Luna", "address": {"zip": "12345"}, "orders": [1, "2"], "tags": [], "history": {}, "extra": {"a": [1]}}`,
+		`{"name": 1, "address": [], "orders": {}, "tags": "a", "email": "nope"}`,
+		`[{"name": "Luna"}]`,
+		`"Luna"`,
+	} {
+		var expected, errs jsontype.ValidationErrors
+		if !errors.As(schema.Validate([]byte(document)), &expected) {
+			t.Fatalf("%s: expected Validate to fail", document)
+		}
+		if !errors.As(schema.ValidateStream(strings.NewReader(document)), &errs) || len(errs) != len(expected) {
+			t.Fatalf("%s: expected %v but got %v", document, expected, errs)
+		}
+	}
+}
+
+func TestSchemaValidateStreamArrayRoot(t *testing.T) {
+	sm := loadSchemas(t, append(streamSchemas, `{"type": "Customers", "root": {"type": "array", "items": {"type": "object", "ref": "Customer"}}}`)...)
+	schema := getSchema(t, sm, "customers")
+
+	if err := schema.ValidateStream(strings.NewReader("[" + streamCustomer + "]")); err != nil {
+		t.Fatal(err)
+	}
+
+	// test that errors within items have indexed paths
+	var errs jsontype.ValidationErrors
+	err := schema.ValidateStream(strings.NewReader(`[` + streamCustomer + `, {"name": "Luna"}]`))
+	if !errors.As(err, &errs) || errs[0].Path != "[1].address" {
+		t.Fatalf("expected an error at [1].address but got %v", err)
+	}
+	if err := schema.ValidateStream(strings.NewReader(streamCustomer)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSchemaValidateStreamBadJSON(t *testing.T) {
+	schema := getSchema(t, loadSchemas(t, streamSchemas...), "customer")
+
+	err := schema.ValidateStream(strings.NewReader(`{"name": "Luna", "orders": [1,,]}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSchemaValidateStreamTrailingData(t *testing.T) {
+	schema := getSchema(t, loadSchemas(t, streamSchemas...), "customer")
+
+	err := schema.ValidateStream(strings.NewReader(streamCustomer + ` {}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSchemaValidateRecords(t *testing.T) {
+	schema := getSchema(t, loadSchemas(t, streamSchemas...), "customer")
+
+	records := `[
+		{"name": "Luna", "address": {"zip": "12345"}, "orders": [], "tags": [], "history": []},
+		{"name": "Blueberry", "address": {"zip": "12345"}, "orders": [], "tags": [], "history": []},
+		42
+	]`
+
+	results := []error{}
+	err := schema.ValidateRecords(strings.NewReader(records), func(index int, err error) {
+		if index != len(results) {
+			t.Fatalf("expected index %d but got %d", len(results), index)
+		}
+		results = append(results, err)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 3 || results[0] != nil || results[1] == nil || results[2] == nil {
+		t.Fatalf("unexpected results %v", results)
+	}
+}
+
+func TestSchemaValidateRecordsNotArray(t *testing.T) {
+	schema := getSchema(t, loadSchemas(t, streamSchemas...), "customer")
+
+	err := schema.ValidateRecords(strings.NewReader(`{}`), func(int, error) {})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSchemaValidateLines(t *testing.T) {
+	schema := getSchema(t, loadSchemas(t, streamSchemas...), "customer")
+
+	lines := streamCustomer + `
+
+{"name": "Blueberry", "address": {"zip": "12345"}, "orders": [], "tags": [], "history": []}
+{"name": "Luna",
+` + streamCustomer
+
+	results := map[int]error{}
+	err := schema.ValidateLines(strings.NewReader(lines), jsontype.LinesOptions{}, func(line int, err error) {
+		results[line] = err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results but got %d", len(results))
+	}
+	if results[1] != nil || results[5] != nil {
+		t.Fatalf("expected lines 1 and 5 to be valid: %v", results)
+	}
+	if results[3] == nil || results[4] == nil {
+		t.Fatalf("expected lines 3 and 4 to be invalid: %v", results)
+	}
+}
+
+func TestSchemaValidateLinesMaxLineSize(t *testing.T) {
+	schema := getSchema(t, loadSchemas(t, streamSchemas...), "customer")
+
+	// test a line that is too long is reported and the next line is still read
+	lines := `{"name": "` + strings.Repeat("a", 10000) + `"}` + "\n" + streamCustomer
+	results := map[int]error{}
+	err := schema.ValidateLines(strings.NewReader(lines), jsontype.LinesOptions{MaxLineSize: len(streamCustomer)}, func(line int, err error) {
+		results[line] = err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results but got %d", len(results))
+	}
+	if results[1] == nil || !strings.Contains(results[1].Error(), "longer than") {
+		t.Fatalf("expected line 1 to be too long: %v", results[1])
+	}
+	if results[2] != nil {
+		t.Fatalf("expected line 2 to be valid: %v", results[2])
+	}
+}
+
+func TestSchemaValidateStreamUndefinedOrder(t *testing.T) {
+	schema := getSchema(t, loadSchemas(t, streamSchemas...), "customer")
+
+	// test undefined properties are reported in the same order as Validate
+	document := `{"zebra": 1, "name": "Luna", "address": {"zip": "12345"}, "orders": [], "tags": [], "history": [], "apple": 2}`
+	var expected, errs jsontype.ValidationErrors
+	if !errors.As(schema.Validate([]byte(document)), &expected) {
+		t.Fatal("expected Validate to fail")
+	}
+	if !errors.As(schema.ValidateStream(strings.NewReader(document)), &errs) {
+		t.Fatal("expected ValidateStream to fail")
+	}
+	if errs.Error() != expected.Error() {
+		t.Fatalf("expected %v but got %v", expected, errs)
+	}
+}
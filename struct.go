@@ -0,0 +1,370 @@
+package jsontype
+
+import (
+	"encoding"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-reflect"
+)
+
+// structTagFlags are the flags that can be declared in a jsontype struct tag
+//   - optional: the property is optional even if it is not a pointer or omitempty
+//   - required: the property is required even if it is a pointer or omitempty
+//   - extends: an embedded struct becomes the schema's parent instead of being flattened
+var structTagFlags = map[string]bool{
+	"optional": true,
+	"required": true,
+	"extends":  true,
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// SchemaFromStruct derives a Schema from a Go struct, or a pointer to one.
+//
+// The schema's type is the struct's type name and property names are taken
+// from json struct tags. Rules are declared with a jsontype struct tag such as
+// `jsontype:"max_length=5,format=email"`, options for oneof, noneof, allof and
+// anyof are separated by "|". Pointer and omitempty fields become optional
+// properties. Embedded structs are flattened, unless they are tagged with
+// `jsontype:"extends"` in which case the embedded struct becomes the schema's
+// parent. Nested structs become object properties that reference the schema
+// of the nested struct's type.
+func SchemaFromStruct(v interface{}) (*Schema, error) {
+	s, _, err := schemaFromType(reflect.TypeOf(v))
+	return s, err
+}
+
+// LoadStruct derives a schema from a Go struct and loads it into the
+// SchemaManager, along with the schemas of any structs it extends or
+// references that have not been loaded yet. The schemas are loaded together,
+// like LoadSchemas, and written through to the store in use.
+// NOTE: like LoadSchema, the schema will overwrite an existing schema with the
+// same type that has no version
+func (sm *SchemaManager) LoadStruct(v interface{}) error {
+	files := map[string][]byte{}
+	err := sm.structSchemas(reflect.TypeOf(v), files, map[reflect.Type]bool{})
+	if err != nil {
+		return err
+	}
+	if errs := sm.load(files, false); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// structSchemas adds the definitions of the schema of a struct type, and of
+// the struct types it depends on that are not loaded, to files
+func (sm *SchemaManager) structSchemas(t reflect.Type, files map[string][]byte, seen map[reflect.Type]bool) error {
+	s, dependencies, err := schemaFromType(t)
+	if err != nil {
+		return err
+	}
+	seen[t] = true
+	files[s.Type] = []byte(s.String())
+
+	for _, dependency := range dependencies {
+		if seen[dependency] {
+			continue
+		}
+		if _, err := sm.GetSchema(dependency.Name()); err == nil {
+			continue
+		}
+		err := sm.structSchemas(dependency, files, seen)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaFromType derives a schema from a struct type, and returns the struct
+// types that the schema extends or references
+func schemaFromType(t reflect.Type) (*Schema, []reflect.Type, error) {
+	if t == nil {
+		return nil, nil, fmt.Errorf("cannot derive a schema from nil")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("cannot derive a schema from %v, it must be a struct", t)
+	}
+	if t.Name() == "" {
+		return nil, nil, fmt.Errorf("cannot derive a schema from an anonymous struct")
+	}
+
+	d := &structDeriver{
+		schema: &Schema{
+			Type:               t.Name(),
+			Properties:         make(map[string]Property),
+			OptionalProperties: make(map[string]Property),
+		},
+		dependencies: []reflect.Type{},
+	}
+	err := d.addFields(t, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(d.schema.OptionalProperties) == 0 {
+		d.schema.OptionalProperties = nil
+	}
+	return d.schema, d.dependencies, nil
+}
+
+// structDeriver collects the properties of a struct, and its embedded
+// structs, into a schema
+type structDeriver struct {
+	schema       *Schema
+	dependencies []reflect.Type
+}
+
+// addFields adds the fields of a struct to the schema, fields of the outermost
+// struct take precedence over fields of embedded structs
+func (d *structDeriver) addFields(t reflect.Type, outermost bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, jsonOpts, _ := strings.Cut(jsonTag, ",")
+
+		fieldType := field.Type
+		optional := false
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+			optional = true
+		}
+		if hasTagOption(jsonOpts, "omitempty") {
+			optional = true
+		}
+
+		rules, flags, err := parseStructTag(field.Tag.Get("jsontype"))
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+		}
+
+		// embedded structs without a json name either become the parent of the
+		// schema or have their fields flattened into it
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			if !flags["extends"] {
+				err := d.addFields(fieldType, false)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if !outermost || d.schema.Extends != "" {
+				return fmt.Errorf("field %s.%s: a schema can only extend a single struct", t.Name(), field.Name)
+			}
+			if fieldType.Name() == "" {
+				return fmt.Errorf("field %s.%s: cannot extend an anonymous struct", t.Name(), field.Name)
+			}
+			d.schema.Extends = fieldType.Name()
+			d.dependencies = append(d.dependencies, fieldType)
+			continue
+		}
+		if flags["extends"] {
+			return fmt.Errorf("field %s.%s: only embedded structs can be extended", t.Name(), field.Name)
+		}
+
+		// unexported fields are not encoded to JSON
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if flags["optional"] {
+			optional = true
+		}
+		if flags["required"] {
+			optional = false
+		}
+
+		p, err := d.property(fieldType)
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+		}
+		p.Description = field.Tag.Get("description")
+		err = applyRules(&p, rules)
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+		}
+
+		_, required := d.schema.Properties[name]
+		_, alsoOptional := d.schema.OptionalProperties[name]
+		if (required || alsoOptional) && !outermost {
+			continue
+		}
+		delete(d.schema.Properties, name)
+		delete(d.schema.OptionalProperties, name)
+		if optional {
+			d.schema.OptionalProperties[name] = p
+		} else {
+			d.schema.Properties[name] = p
+		}
+	}
+	return nil
+}
+
+// property returns the property definition for a Go type
+func (d *structDeriver) property(t reflect.Type) (Property, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// types such as time.Time are encoded as strings
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return Property{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Property{Type: "string"}, nil
+	case reflect.Bool:
+		return Property{Type: "bool"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return Property{Type: "number"}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return Property{}, fmt.Errorf("map keys must be strings but got %v", t.Key())
+		}
+		return Property{Type: "object"}, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return Property{Type: "object"}, nil
+		}
+		d.dependencies = append(d.dependencies, t)
+		return Property{Type: "object", Ref: t.Name()}, nil
+	case reflect.Slice, reflect.Array:
+		// byte slices are encoded as base64 strings
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return Property{Type: "string", Rules: map[string]interface{}{"format": "base64"}}, nil
+		}
+		if t.Elem().Kind() == reflect.Interface {
+			return Property{Type: "list"}, nil
+		}
+		items, err := d.property(t.Elem())
+		if err != nil {
+			return Property{}, err
+		}
+		return Property{Type: "array", Items: &items}, nil
+	}
+	return Property{}, fmt.Errorf("unsupported type %v", t)
+}
+
+// parseStructTag parses a jsontype struct tag into its rules and flags. As
+// rule arguments such as regular expressions may contain commas, a segment
+// that does not start with a known rule or flag belongs to the previous rule.
+func parseStructTag(tag string) (map[string]string, map[string]bool, error) {
+	rules := make(map[string]string)
+	flags := make(map[string]bool)
+	if tag == "" {
+		return rules, flags, nil
+	}
+
+	last := ""
+	for _, segment := range strings.Split(tag, ",") {
+		name, arg, hasArg := strings.Cut(segment, "=")
+		name = strings.TrimSpace(name)
+		switch {
+		case hasArg && ruleSpecs[name].arg != "":
+			rules[name] = arg
+			last = name
+		case !hasArg && structTagFlags[name]:
+			flags[name] = true
+			last = ""
+		case last != "":
+			rules[last] += "," + segment
+		case hasArg:
+			return nil, nil, fmt.Errorf("unknown rule %s", name)
+		default:
+			return nil, nil, fmt.Errorf("unknown flag %s", name)
+		}
+	}
+	return rules, flags, nil
+}
+
+// applyRules converts struct tag rule arguments to the types used by
+// Evaluate, and adds them to the property
+func applyRules(p *Property, rules map[string]string) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	if p.Rules == nil {
+		p.Rules = make(map[string]interface{}, len(rules))
+	}
+
+	// options are compared against array items rather than the array itself
+	valueType := p.Type
+	if p.Type == "array" && p.Items != nil {
+		valueType = p.Items.Type
+	}
+
+	for rule, arg := range rules {
+		switch ruleSpecs[rule].arg {
+		case "number", "length":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return fmt.Errorf("%s rule must be a number but got %s", rule, arg)
+			}
+			p.Rules[rule] = n
+		case "options":
+			options := []interface{}{}
+			for _, option := range strings.Split(arg, "|") {
+				v, err := parseTagValue(option, valueType)
+				if err != nil {
+					return fmt.Errorf("%s rule: %w", rule, err)
+				}
+				options = append(options, v)
+			}
+			p.Rules[rule] = options
+		case "value":
+			v, err := parseTagValue(arg, valueType)
+			if err != nil {
+				return fmt.Errorf("%s rule: %w", rule, err)
+			}
+			p.Rules[rule] = v
+		default:
+			p.Rules[rule] = arg
+		}
+	}
+	return nil
+}
+
+// parseTagValue parses a struct tag value as the JSON type it is compared to
+func parseTagValue(value, valueType string) (interface{}, error) {
+	switch valueType {
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", value)
+		}
+		return n, nil
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a bool", value)
+		}
+		return b, nil
+	}
+	return value, nil
+}
+
+// hasTagOption reports whether a comma separated list of struct tag options
+// contains the named option
+func hasTagOption(options, name string) bool {
+	for _, option := range strings.Split(options, ",") {
+		if option == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package jsontype_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apageadev/jsontype"
+)
+
+type Animal struct {
+	Name string `json:"name" jsontype:"max_length=5" description:"the name of the animal"`
+}
+
+type Address struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip" jsontype:"regex=^[0-9]{5}$"`
+}
+
+type Timestamps struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Dog struct {
+	Animal `jsontype:"extends"`
+	Timestamps
+
+	Breed    string   `json:"breed" jsontype:"oneof=beagle|poodle"`
+	Age      int      `json:"age" jsontype:"min=0,max=30"`
+	Email    string   `json:"email,omitempty" jsontype:"format=email"`
+	Nickname *string  `json:"nickname"`
+	Tags     []string `json:"tags"`
+	Address  Address  `json:"address"`
+	Friends  []Dog    `json:"friends,omitempty"`
+	Extra    []any    `json:"extra,omitempty"`
+	Secret   string   `json:"-"`
+	internal string
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	schema, err := jsontype.SchemaFromStruct(&Dog{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if schema.Type != "Dog" {
+		t.Fatalf("expected type Dog but got %s", schema.Type)
+	}
+
+	if schema.Extends != "Animal" {
+		t.Fatalf("expected Dog to extend Animal but got %s", schema.Extends)
+	}
+
+	// test flattened embedded struct
+	if _, ok := schema.Properties["created_at"]; !ok {
+		t.Fatal("expected created_at to be flattened into the schema")
+	}
+
+	// test pointer and omitempty fields are optional
+	for _, name := range []string{"email", "nickname", "friends", "extra"} {
+		if _, ok := schema.OptionalProperties[name]; !ok {
+			t.Fatalf("expected %s to be optional", name)
+		}
+	}
+
+	// test skipped fields
+	if _, ok := schema.Properties["Secret"]; ok {
+		t.Fatal("expected Secret to be skipped")
+	}
+	if _, ok := schema.Properties["internal"]; ok {
+		t.Fatal("expected internal to be skipped")
+	}
+
+	if schema.Properties["address"].Ref != "Address" {
+		t.Fatal("expected address to reference the Address schema")
+	}
+
+	tags := schema.Properties["tags"]
+	if tags.Type != "array" || tags.Items == nil || tags.Items.Type != "string" {
+		t.Fatal("expected tags to be an array of strings")
+	}
+
+	if schema.OptionalProperties["extra"].Type != "list" {
+		t.Fatal("expected extra to be a list")
+	}
+
+	if schema.Properties["age"].Rules["max"] != 30.0 {
+		t.Fatal("expected age to have a max rule of 30")
+	}
+
+	breeds, ok := schema.Properties["breed"].Rules["oneof"].([]interface{})
+	if !ok || len(breeds) != 2 {
+		t.Fatal("expected breed to have two oneof options")
+	}
+
+	// test bad input
+	_, err = jsontype.SchemaFromStruct("not a struct")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSchemaFromStructBadTag(t *testing.T) {
+	type BadRule struct {
+		Name string `json:"name" jsontype:"longest=5"`
+	}
+	_, err := jsontype.SchemaFromStruct(BadRule{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	type BadArg struct {
+		Name string `json:"name" jsontype:"max_length=five"`
+	}
+	_, err = jsontype.SchemaFromStruct(BadArg{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	type BadExtends struct {
+		Name string `json:"name" jsontype:"extends"`
+	}
+	_, err = jsontype.SchemaFromStruct(BadExtends{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSchemaFromStructRegexWithComma(t *testing.T) {
+	type Code struct {
+		Code string `json:"code" jsontype:"regex=^[a-z]{2,3}$,min_length=2"`
+	}
+	schema, err := jsontype.SchemaFromStruct(Code{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if schema.Properties["code"].Rules["regex"] != "^[a-z]{2,3}$" {
+		t.Fatalf("unexpected regex %v", schema.Properties["code"].Rules["regex"])
+	}
+}
+
+func TestSchemaManagerLoadStruct(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	err := sm.LoadStruct(Dog{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Dog, Animal and Address should all be loaded
+	if sm.SchemaCount() != 3 {
+		t.Fatalf("expected 3 schemas but got %d", sm.SchemaCount())
+	}
+
+	schema, err := sm.GetSchema("dog")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = schema.Validate([]byte(`{
+		"name": "Rex",
+		"created_at": "2023-01-01T00:00:00Z",
+		"breed": "beagle",
+		"age": 3,
+		"nickname": null,
+		"tags": ["good", "boy"],
+		"address": {"street": "1 Main St", "zip": "12345"},
+		"friends": [{
+			"name": "Fido",
+			"created_at": "2023-01-01T00:00:00Z",
+			"breed": "poodle",
+			"age": 5,
+			"tags": [],
+			"address": {"street": "2 Main St", "zip": "12345"}
+		}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test inherited rule violation
+	err = schema.Validate([]byte(`{"name": "Blueberry", "created_at": "", "breed": "beagle", "age": 3, "tags": [], "address": {"street": "", "zip": "12345"}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test referenced schema violation
+	err = schema.Validate([]byte(`{"name": "Rex", "created_at": "", "breed": "beagle", "age": 3, "tags": [], "address": {"street": "", "zip": "1"}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test array item type violation
+	err = schema.Validate([]byte(`{"name": "Rex", "created_at": "", "breed": "beagle", "age": 3, "tags": [1], "address": {"street": "", "zip": "12345"}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// test the derived schema survives a round trip through String
+	err = sm.LoadSchema([]byte(schema.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaManagerLoadSchemaExtends(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+
+	// test unknown parent
+	err := sm.LoadSchema([]byte(`{"type": "Dog", "extends": "Animal", "properties": {"breed": {"type": "string"}}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	err = sm.LoadSchema([]byte(`{"type": "Animal", "properties": {"name": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sm.LoadSchema([]byte(`{"type": "Dog", "extends": "Animal", "properties": {"breed": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test circular extends
+	err = sm.LoadSchema([]byte(`{"type": "Animal", "extends": "Dog", "properties": {"name": {"type": "string"}}}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	schema, err := sm.GetSchema("dog")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = schema.Validate([]byte(`{"name": "Rex", "breed": "beagle"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test missing inherited property
+	err = schema.Validate([]byte(`{"breed": "beagle"}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSchemaManagerLoadStructStore(t *testing.T) {
+	store := jsontype.NewMemoryStore()
+	sm := useStore(t, store)
+
+	// test derived schemas are written through to the store like loaded ones
+	if err := sm.LoadStruct(Dog{}); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := store.List(context.Background())
+	if err != nil || len(keys) != 3 {
+		t.Fatalf("unexpected keys %v %v", keys, err)
+	}
+
+	// test the schema replaces a loaded schema of the same type
+	if err := sm.LoadSchema([]byte(`{"type": "Address", "properties": {}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.LoadStruct(Address{}); err != nil {
+		t.Fatal(err)
+	}
+	address, err := sm.GetSchema("address")
+	if err != nil || len(address.Properties) != 2 {
+		t.Fatalf("expected the derived schema but got %v %v", address, err)
+	}
+}
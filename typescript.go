@@ -0,0 +1,224 @@
+package jsontype
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// WriteTypeScript writes a TypeScript interface for every schema in the
+// SchemaManager to w. Optional properties become optional members, oneof
+// rules become unions of literal types, references between schemas and
+// extends become references to the generated interfaces, and descriptions
+// become JSDoc comments.
+func (sm *SchemaManager) WriteTypeScript(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "// Code generated by jsontype. DO NOT EDIT.")
+
+	schemaTypes := sm.ListSchemas()
+	sort.Strings(schemaTypes)
+	for _, schemaType := range schemaTypes {
+		s, err := sm.GetSchema(schemaType)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintln(bw)
+		sm.writeInterface(bw, s)
+	}
+	return bw.Flush()
+}
+
+// writeInterface writes the TypeScript interface for a single schema, or a
+// type alias for a schema whose root is not an object
+func (sm *SchemaManager) writeInterface(w io.Writer, s *Schema) {
+	writeJSDoc(w, "", s.Description)
+	if !s.hasObjectRoot() {
+		fmt.Fprintf(w, "export type %s = %s;\n", tsTypeName(s.Type), sm.tsType(*s.Root))
+		return
+	}
+
+	declaration := "export interface " + tsTypeName(s.Type)
+	if s.Extends != "" {
+		if parent, err := sm.GetSchema(s.Extends); err == nil {
+			declaration += " extends " + sm.tsParent(s, parent)
+		}
+	}
+	fmt.Fprintln(w, declaration+" {")
+
+	names := make([]string, 0, len(s.Properties)+len(s.OptionalProperties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	for name := range s.OptionalProperties {
+		if _, ok := s.Properties[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p, required := s.Properties[name]
+		if !required {
+			p = s.OptionalProperties[name]
+		}
+
+		// optional properties may also be null
+		member, tsType := tsPropertyName(name), sm.tsType(p)
+		if !required {
+			member += "?"
+			tsType += " | null"
+		}
+		writeJSDoc(w, "  ", p.Description)
+		fmt.Fprintf(w, "  %s: %s;\n", member, tsType)
+	}
+
+	if s.AllowUndefinedProperties {
+		fmt.Fprintln(w, "  [key: string]: unknown;")
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// tsType returns the TypeScript type of a property
+func (sm *SchemaManager) tsType(p Property) string {
+	if options, ok := p.Rules["oneof"].([]interface{}); ok && len(options) > 0 {
+		if union, ok := tsLiteralUnion(options); ok {
+			return union
+		}
+	}
+
+	switch p.Type {
+	case "string":
+		return "string"
+	case "number":
+		return "number"
+	case "bool":
+		return "boolean"
+	case "object":
+		if p.Ref != "" {
+			return sm.tsReference(p.Ref)
+		}
+		return "Record<string, unknown>"
+	case "array":
+		// allof restricts every item of an array to the listed options
+		if options, ok := p.Rules["allof"].([]interface{}); ok && len(options) > 0 {
+			if union, ok := tsLiteralUnion(options); ok {
+				return "(" + union + ")[]"
+			}
+		}
+		if p.Items == nil {
+			return "unknown[]"
+		}
+		items := sm.tsType(*p.Items)
+		if strings.Contains(items, " ") {
+			items = "(" + items + ")"
+		}
+		return items + "[]"
+	case "list":
+		return "unknown[]"
+	}
+	return "unknown"
+}
+
+// tsReference returns the name of the interface generated for a referenced
+// schema, or unknown when the schema is not loaded as no interface is
+// generated for it
+func (sm *SchemaManager) tsReference(schemaType string) string {
+	if s, err := sm.GetSchema(schemaType); err == nil {
+		return tsTypeName(s.Type)
+	}
+	return "unknown"
+}
+
+// tsParent returns the interface a schema's interface extends. Properties the
+// schema redefines are omitted from the parent, as an interface cannot change
+// the type of an inherited member or make it optional.
+func (sm *SchemaManager) tsParent(s, parent *Schema) string {
+	name := tsTypeName(parent.Type)
+	properties, optionalProperties, err := parent.resolveProperties()
+	if err != nil {
+		return name
+	}
+	overridden := []string{}
+	for _, own := range []map[string]Property{s.Properties, s.OptionalProperties} {
+		for property := range own {
+			_, required := properties[property]
+			_, optional := optionalProperties[property]
+			if required || optional {
+				quoted, _ := json.Marshal(property)
+				overridden = append(overridden, string(quoted))
+			}
+		}
+	}
+	if len(overridden) == 0 {
+		return name
+	}
+	sort.Strings(overridden)
+	return fmt.Sprintf("Omit<%s, %s>", name, strings.Join(overridden, " | "))
+}
+
+// tsLiteralUnion returns a union of literal types for a list of options, it
+// reports false if an option can not be written as a literal type
+func tsLiteralUnion(options []interface{}) (string, bool) {
+	literals := make([]string, 0, len(options))
+	for _, option := range options {
+		switch option.(type) {
+		case string, float64, bool, nil:
+			literal, err := json.Marshal(option)
+			if err != nil {
+				return "", false
+			}
+			literals = append(literals, string(literal))
+		default:
+			return "", false
+		}
+	}
+	return strings.Join(literals, " | "), true
+}
+
+// tsTypeName converts a schema type to a valid TypeScript identifier
+func tsTypeName(schemaType string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || r == '$' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, schemaType)
+	if name == "" || ('0' <= name[0] && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// tsPropertyName quotes property names that are not valid identifiers
+func tsPropertyName(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	quoted, _ := json.Marshal(name)
+	return string(quoted)
+}
+
+// writeJSDoc writes a description as a JSDoc comment
+func writeJSDoc(w io.Writer, indent, description string) {
+	if description == "" {
+		return
+	}
+	description = strings.ReplaceAll(description, "*/", "*\\/")
+	lines := strings.Split(strings.TrimSpace(description), "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(w, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(w, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(w, "%s * %s\n", indent, strings.TrimSpace(line))
+	}
+	fmt.Fprintf(w, "%s */\n", indent)
+}
@@ -0,0 +1,123 @@
+package jsontype_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apageadev/jsontype"
+)
+
+func TestSchemaManagerWriteTypeScript(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+
+	err := sm.LoadSchema([]byte(`{"type":"Animal","description":"An animal","properties":{"name":{"type":"string","description":"the name\nof the animal"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sm.LoadSchema([]byte(`{
+		"type": "Dog",
+		"extends": "animal",
+		"allow_undefined_properties": true,
+		"properties": {
+			"breed": {"type": "string", "rules": {"oneof": ["beagle", "poodle"]}},
+			"age": {"type": "number"},
+			"good": {"type": "bool"},
+			"owner": {"type": "object", "ref": "person"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"sizes": {"type": "array", "items": {"type": "number", "rules": {"oneof": [1, 2]}}},
+			"extra": {"type": "list"},
+			"meta": {"type": "object"}
+		},
+		"optional_properties": {
+			"nick-name": {"type": "string", "description": "what friends call the dog"}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sm.LoadSchema([]byte(`{"type":"Dogs","root":{"type":"array","items":{"type":"object","ref":"dog"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = sm.WriteTypeScript(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `// Code generated by jsontype. DO NOT EDIT.
+
+/** An animal */
+export interface Animal {
+  /**
+   * the name
+   * of the animal
+   */
+  name: string;
+}
+
+export interface Dog extends Animal {
+  age: number;
+  breed: "beagle" | "poodle";
+  extra: unknown[];
+  good: boolean;
+  meta: Record<string, unknown>;
+  /** what friends call the dog */
+  "nick-name"?: string | null;
+  owner: unknown;
+  sizes: (1 | 2)[];
+  tags: string[];
+  [key: string]: unknown;
+}
+
+export type Dogs = Dog[];
+`
+	if buf.String() != expected {
+		t.Fatalf("unexpected TypeScript output:\n%s", buf.String())
+	}
+}
+
+func TestWriteTypeScriptOverride(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	for _, def := range []string{
+		`{"type": "Animal", "properties": {"name": {"type": "string"}, "age": {"type": "number"}}}`,
+		`{"type": "Puppy", "extends": "Animal", "properties": {}, "optional_properties": {"age": {"type": "number"}}}`,
+	} {
+		if err := sm.LoadSchema([]byte(def)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := sm.WriteTypeScript(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// test that a redefined property is omitted from the parent
+	expected := "export interface Puppy extends Omit<Animal, \"age\"> {\n  age?: number | null;\n}\n"
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Fatalf("unexpected TypeScript output:\n%s", buf.String())
+	}
+}
+
+func TestWriteTypeScriptUnresolvedRef(t *testing.T) {
+	sm := jsontype.NewSchemaManager()
+	err := sm.LoadSchema([]byte(`{"type": "Pet", "properties": {"owner": {"type": "object", "ref": "Person"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := sm.WriteTypeScript(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// test that a schema that is not loaded is not referenced
+	if !strings.Contains(buf.String(), "  owner: unknown;\n") {
+		t.Fatalf("unexpected TypeScript output:\n%s", buf.String())
+	}
+}
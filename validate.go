@@ -1,43 +1,54 @@
 package jsontype
 
 import (
+	"github.com/goccy/go-json"
 	"github.com/goccy/go-reflect"
 )
 
 func IsString(value interface{}) bool {
-	return reflect.TypeOf(value).Kind() == reflect.String
+	if _, ok := value.(json.Number); ok {
+		return false
+	}
+	return value != nil && reflect.TypeOf(value).Kind() == reflect.String
 }
 
+// NOTE: numbers may be of any Go integer or float kind, or a json.Number
 func IsNumber(value interface{}) bool {
-	numberTypes := []string{"int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64"}
-	isNumberType := false
-	for _, t := range numberTypes {
-		if reflect.TypeOf(value).Kind().String() == t {
-			isNumberType = true
-		}
+	if _, ok := value.(json.Number); ok {
+		return true
 	}
-	return isNumberType
+	if value == nil {
+		return false
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
 }
 
 func IsBool(value interface{}) bool {
-	return reflect.TypeOf(value).Kind() == reflect.Bool
+	return value != nil && reflect.TypeOf(value).Kind() == reflect.Bool
 }
 
 func IsObject(value interface{}) bool {
-	return reflect.TypeOf(value).Kind() == reflect.Map
+	return value != nil && reflect.TypeOf(value).Kind() == reflect.Map
 }
 
 // NOTE: items in an array must be of the same type, otherwise it is a list
 func IsArray(value interface{}) bool {
-	if reflect.TypeOf(value).Kind() != reflect.Slice {
+	if !IsList(value) {
 		return false
 	}
 
 	// check if all items in the array are of the same type
-	if len(value.([]interface{})) > 0 {
-		firstItem := value.([]interface{})[0]
-		for _, item := range value.([]interface{}) {
-			if reflect.TypeOf(item) != reflect.TypeOf(firstItem) {
+	items, _ := toList(value)
+	if len(items) > 0 {
+		firstItem := jsonKind(items[0])
+		for _, item := range items {
+			if jsonKind(item) != firstItem {
 				return false
 			}
 		}
@@ -46,7 +57,11 @@ func IsArray(value interface{}) bool {
 }
 
 func IsList(value interface{}) bool {
-	return reflect.TypeOf(value).Kind() == reflect.Slice
+	if value == nil {
+		return false
+	}
+	kind := reflect.TypeOf(value).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
 }
 
 func IsNull(value interface{}) bool {
@@ -72,3 +87,19 @@ func IsType(value interface{}, typeToValidate string) bool {
 	}
 	return false
 }
+
+// toList returns the items of a slice or array of any type
+func toList(value interface{}) ([]interface{}, bool) {
+	if items, ok := value.([]interface{}); ok {
+		return items, true
+	}
+	if !IsList(value) {
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, true
+}
@@ -29,6 +29,35 @@ func TestNumberType(t *testing.T) {
 	if !jsontype.IsNumber(1.0e-10) {
 		t.Fatal("expected number")
 	}
+
+	if !jsontype.IsNumber(int64(1)) || !jsontype.IsNumber(uint8(1)) || !jsontype.IsNumber(float32(1)) {
+		t.Fatal("expected number")
+	}
+
+	type Age int
+	if !jsontype.IsNumber(Age(1)) {
+		t.Fatal("expected number")
+	}
+
+	if !jsontype.IsNumber(json.Number("1")) {
+		t.Fatal("expected number")
+	}
+
+	if jsontype.IsString(json.Number("1")) {
+		t.Fatal("expected json.Number not to be a string")
+	}
+
+	if jsontype.IsNumber("1") {
+		t.Fatal("expected string not to be a number")
+	}
+}
+
+func TestNilValueTypes(t *testing.T) {
+	for _, typeToValidate := range []string{"string", "number", "bool", "object", "array", "list"} {
+		if jsontype.IsType(nil, typeToValidate) {
+			t.Fatalf("expected nil not to be %s", typeToValidate)
+		}
+	}
 }
 
 func TestBoolType(t *testing.T) {
@@ -97,6 +126,15 @@ func TestArrayType(t *testing.T) {
 	if jsontype.IsArray(1) {
 		t.Fatal("expected list")
 	}
+
+	// test go slices
+	if !jsontype.IsArray([]string{"a", "b"}) {
+		t.Fatal("expected array")
+	}
+
+	if !jsontype.IsArray([]interface{}{1, 2.5, int64(3)}) {
+		t.Fatal("expected array")
+	}
 }
 
 func TestListType(t *testing.T) {
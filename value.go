@@ -0,0 +1,525 @@
+package jsontype
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-reflect"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+)
+
+// toJSONValue converts a Go value into the values produced by decoding JSON
+// into an interface{}: map[string]interface{}, []interface{}, string, bool,
+// nil and numbers. Structs, maps and slices are converted following the same
+// rules as encoding/json, while numbers keep their Go kind so that large
+// integers do not lose precision. Data that has already been decoded is
+// returned as is, without being copied.
+func toJSONValue(v interface{}) (interface{}, error) {
+	c := &valueConverter{visiting: map[visit]bool{}}
+	value, _, err := c.value(v)
+	return value, err
+}
+
+// errValueCycle is returned for values that contain themselves, which have no
+// JSON encoding
+var errValueCycle = errors.New("unsupported value: encountered a cycle")
+
+// visit identifies a pointer, map or slice being converted
+type visit struct {
+	ptr uintptr
+	len int
+}
+
+// A valueConverter converts a value, tracking the pointers, maps and slices
+// that are being converted so a value that contains itself fails rather than
+// recursing forever
+type valueConverter struct {
+	visiting map[visit]bool
+}
+
+// enter marks the pointer, map or slice rv as being converted, leave must be
+// called once it is converted
+func (c *valueConverter) enter(rv reflect.Value) (visit, error) {
+	key := visit{ptr: rv.Pointer()}
+	if rv.Kind() == reflect.Slice {
+		key.len = rv.Len()
+	}
+	if key.ptr == 0 || rv.Kind() == reflect.Slice && key.len == 0 {
+		return visit{}, nil
+	}
+	if c.visiting[key] {
+		return key, errValueCycle
+	}
+	c.visiting[key] = true
+	return key, nil
+}
+
+func (c *valueConverter) leave(key visit) {
+	delete(c.visiting, key)
+}
+
+// value converts a value, and reports whether the converted value differs
+// from the original
+func (c *valueConverter) value(v interface{}) (interface{}, bool, error) {
+	switch v := v.(type) {
+	case nil, string, bool, float64, int64, uint64, json.Number:
+		return v, false, nil
+
+	case map[string]interface{}:
+		key, err := c.enter(reflect.ValueOf(v))
+		if err != nil {
+			return nil, false, err
+		}
+		defer c.leave(key)
+		var converted map[string]interface{}
+		for key, item := range v {
+			value, changed, err := c.value(item)
+			if err != nil {
+				return nil, false, err
+			}
+			if changed && converted == nil {
+				converted = make(map[string]interface{}, len(v))
+				for k, i := range v {
+					converted[k] = i
+				}
+			}
+			if converted != nil {
+				converted[key] = value
+			}
+		}
+		if converted != nil {
+			return converted, true, nil
+		}
+		return v, false, nil
+
+	case []interface{}:
+		key, err := c.enter(reflect.ValueOf(v))
+		if err != nil {
+			return nil, false, err
+		}
+		defer c.leave(key)
+		var converted []interface{}
+		for i, item := range v {
+			value, changed, err := c.value(item)
+			if err != nil {
+				return nil, false, err
+			}
+			if changed && converted == nil {
+				converted = make([]interface{}, len(v))
+				copy(converted, v)
+			}
+			if converted != nil {
+				converted[i] = value
+			}
+		}
+		if converted != nil {
+			return converted, true, nil
+		}
+		return v, false, nil
+
+	case json.RawMessage:
+		data, err := decodeDocument(v)
+		return data, true, err
+	}
+
+	value, err := c.reflectValue(reflect.ValueOf(v))
+	return value, true, err
+}
+
+// reflectValue converts any Go value using reflection
+func (c *valueConverter) reflectValue(rv reflect.Value) (interface{}, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	// types with their own JSON or text encoding are encoded with it
+	t := rv.Type()
+	if t == rawMessageType {
+		return decodeDocument(rv.Bytes())
+	}
+	if (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil() {
+		return nil, nil
+	}
+	if marshaler, ok := marshalerOf(rv, jsonMarshalerType).(json.Marshaler); ok {
+		b, err := marshaler.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		return decodeDocument(b)
+	}
+	if marshaler, ok := marshalerOf(rv, textMarshalerType).(encoding.TextMarshaler); ok {
+		b, err := marshaler.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.Kind() == reflect.Ptr {
+			key, err := c.enter(rv)
+			if err != nil {
+				return nil, err
+			}
+			defer c.leave(key)
+		}
+		elem := rv.Elem()
+		if !elem.CanInterface() {
+			return c.reflectValue(elem)
+		}
+		value, _, err := c.value(elem.Interface())
+		return value, err
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("unsupported value %v", f)
+		}
+		if rv.Kind() == reflect.Float32 {
+			// keep the shortest representation of the float32
+			f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64)
+		}
+		return f, nil
+
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(rv.Bytes()), nil
+		}
+		key, err := c.enter(rv)
+		if err != nil {
+			return nil, err
+		}
+		defer c.leave(key)
+		fallthrough
+	case reflect.Array:
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			item, err := c.reflectValue(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		key, err := c.enter(rv)
+		if err != nil {
+			return nil, err
+		}
+		defer c.leave(key)
+		object := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := mapKey(reflect.ToValue(iter.Key()))
+			if err != nil {
+				return nil, err
+			}
+			item, err := c.reflectValue(reflect.ToValue(iter.Value()))
+			if err != nil {
+				return nil, err
+			}
+			object[key] = item
+		}
+		return object, nil
+
+	case reflect.Struct:
+		object := make(map[string]interface{})
+		err := c.addStructFields(object, rv)
+		return object, err
+	}
+
+	return nil, fmt.Errorf("unsupported type %v", t)
+}
+
+// addStructFields adds the encoded fields of a struct to an object
+func (c *valueConverter) addStructFields(object map[string]interface{}, rv reflect.Value) error {
+	for _, f := range jsonFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.Index, false)
+		if !ok {
+			continue
+		}
+		if f.OmitEmpty && isEmptyValue(fv) {
+			continue
+		}
+
+		value, err := c.reflectValue(fv)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		// the string option encodes numbers and bools as strings
+		if f.Quoted {
+			switch value.(type) {
+			case bool, int64, uint64, float64:
+				value = fmt.Sprint(value)
+			}
+		}
+		object[f.Name] = value
+	}
+	return nil
+}
+
+// jsonField is a struct field as it is encoded by encoding/json
+type jsonField struct {
+	Name      string
+	Index     []int
+	OmitEmpty bool
+	Quoted    bool
+}
+
+var jsonFieldCache sync.Map
+
+// jsonFields returns the fields of a struct that are encoded to JSON. Fields
+// of embedded structs are flattened, and never replace fields of an outer
+// struct.
+func jsonFields(t reflect.Type) []jsonField {
+	if fields, ok := jsonFieldCache.Load(t); ok {
+		return fields.([]jsonField)
+	}
+
+	type embeddedStruct struct {
+		t     reflect.Type
+		index []int
+	}
+
+	fields := []jsonField{}
+	names := map[string]bool{}
+	visited := map[reflect.Type]bool{}
+	level := []embeddedStruct{{t: t}}
+	for len(level) > 0 {
+		next := []embeddedStruct{}
+		levelFields := []jsonField{}
+		for _, current := range level {
+			if visited[current.t] {
+				continue
+			}
+			visited[current.t] = true
+
+			for i := 0; i < current.t.NumField(); i++ {
+				field := current.t.Field(i)
+				jsonTag := field.Tag.Get("json")
+				if jsonTag == "-" {
+					continue
+				}
+				name, jsonOpts, _ := strings.Cut(jsonTag, ",")
+				index := append(append([]int{}, current.index...), i)
+
+				fieldType := field.Type
+				if fieldType.Kind() == reflect.Ptr {
+					fieldType = fieldType.Elem()
+				}
+				if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+					next = append(next, embeddedStruct{t: fieldType, index: index})
+					continue
+				}
+
+				// unexported fields are not encoded to JSON
+				if field.PkgPath != "" {
+					continue
+				}
+				if name == "" {
+					name = field.Name
+				}
+				levelFields = append(levelFields, jsonField{
+					Name:      name,
+					Index:     index,
+					OmitEmpty: hasTagOption(jsonOpts, "omitempty"),
+					Quoted:    hasTagOption(jsonOpts, "string"),
+				})
+			}
+		}
+
+		for _, f := range levelFields {
+			if !names[f.Name] {
+				names[f.Name] = true
+				fields = append(fields, f)
+			}
+		}
+		level = next
+	}
+
+	jsonFieldCache.Store(t, fields)
+	return fields
+}
+
+// fieldByIndex returns a nested field of a struct. Embedded pointers that are
+// nil are allocated if alloc is true, otherwise the field is not returned.
+func fieldByIndex(rv reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !alloc || !rv.CanSet() {
+					return reflect.Value{}, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// marshalerOf returns the value as an interface{} if it, or a pointer to it,
+// implements the marshaler interface
+func marshalerOf(rv reflect.Value, marshaler reflect.Type) interface{} {
+	if !rv.CanInterface() {
+		return nil
+	}
+	if rv.Type().Implements(marshaler) {
+		return rv.Interface()
+	}
+	if rv.CanAddr() && reflect.PtrTo(rv.Type()).Implements(marshaler) {
+		return rv.Addr().Interface()
+	}
+	return nil
+}
+
+// mapKey converts a map key to a string following the rules of encoding/json
+func mapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if tm, ok := marshalerOf(key, textMarshalerType).(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type %v", key.Type())
+}
+
+// isEmptyValue reports whether a value is omitted by the omitempty option
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// jsonKind returns the JSON type of a value
+func jsonKind(value interface{}) string {
+	switch {
+	case value == nil:
+		return "null"
+	case IsNumber(value):
+		return "number"
+	case IsString(value):
+		return "string"
+	case IsBool(value):
+		return "bool"
+	case IsObject(value):
+		return "object"
+	case IsList(value):
+		return "list"
+	}
+	return reflect.TypeOf(value).String()
+}
+
+// toFloat converts a number of any kind to a float64
+func toFloat(value interface{}) (float64, bool) {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	if !IsNumber(value) {
+		return 0, false
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	}
+	return rv.Float(), true
+}
+
+// equal reports whether two values are equal once decoded from JSON, numbers
+// are equal if they have the same value regardless of their Go kind
+func equal(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			if other, ok := b[key]; !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// decodeDocument decodes a JSON document, numbers are decoded as json.Number
+// so they keep their precision
+func decodeDocument(document []byte) (interface{}, error) {
+	if err := checkJSON(document); err != nil {
+		return nil, err
+	}
+	var data interface{}
+	d := json.NewDecoder(bytes.NewReader(document))
+	d.UseNumber()
+	err := d.Decode(&data)
+	return data, err
+}
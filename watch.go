@@ -0,0 +1,396 @@
+package jsontype
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchEventKind identifies what happened to a watched schema
+type WatchEventKind string
+
+const (
+	// SchemaLoaded is sent when a schema is added to the directory or store
+	SchemaLoaded WatchEventKind = "loaded"
+	// SchemaReplaced is sent when a schema's definition changes
+	SchemaReplaced WatchEventKind = "replaced"
+	// SchemaDeleted is sent when a schema is removed from the directory or store
+	SchemaDeleted WatchEventKind = "deleted"
+	// ReloadFailed is sent when a change cannot be applied, the previously
+	// loaded schemas are kept
+	ReloadFailed WatchEventKind = "reload_failed"
+)
+
+// A WatchEvent describes a change made to a SchemaManager by a Watcher or a
+// StoreSync
+type WatchEvent struct {
+	Kind WatchEventKind
+	// Schema and Version are the type and version of the schema that changed,
+	// they are empty when a reload fails
+	Schema  string
+	Version string
+	// File is the file the schema was loaded from, or its key within a Store
+	File string
+	// Err is the reason a reload failed, it is a LoadErrors when schema files
+	// could not be loaded
+	Err error
+}
+
+// WatchOptions configures a Watcher
+type WatchOptions struct {
+	// Pattern selects the schema files within the directory, see LoadFS
+	Pattern string
+	// PollInterval makes the watcher poll the directory for changes instead of
+	// using file system notifications. Polling is also used, with an interval
+	// of a second, when notifications are not available.
+	PollInterval time.Duration
+	// Debounce is how long the watcher waits after a notification for further
+	// changes, so a change set is applied at once. It defaults to 100ms.
+	Debounce time.Duration
+}
+
+// Watcher keeps the schemas of a SchemaManager in sync with a directory.
+// Every change set is applied atomically, either every schema in the
+// directory is loaded or none are, so a half written or invalid schema never
+// replaces one that was loaded.
+type Watcher struct {
+	sm      *SchemaManager
+	dir     string
+	options WatchOptions
+
+	// mu guards subscribers and files, and serializes reloads
+	mu          sync.Mutex
+	subscribers []func(WatchEvent)
+	// files maps the keys of the schemas loaded by the watcher to the file
+	// and definition they were loaded from
+	files map[string]watchedFile
+	// modified is the modification time and size of every schema file as of
+	// the last poll
+	modified map[string]string
+
+	// sending serializes applying a change with sending its events, so the
+	// events of concurrent reloads are sent in the order they were applied
+	sending sync.Mutex
+
+	notify    *fsnotify.Watcher
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// watchedFile is a schema file loaded by a Watcher
+type watchedFile struct {
+	name string
+	def  []byte
+}
+
+// Watch loads the schemas within dir into the SchemaManager, and keeps them
+// in sync with the directory until the returned Watcher is closed. An error
+// is returned if the schemas cannot be loaded initially, later failures are
+// sent to subscribers as ReloadFailed events.
+func (sm *SchemaManager) Watch(dir string, options WatchOptions) (*Watcher, error) {
+	if options.Debounce <= 0 {
+		options.Debounce = 100 * time.Millisecond
+	}
+	w := &Watcher{
+		sm:       sm,
+		dir:      dir,
+		options:  options,
+		files:    make(map[string]watchedFile),
+		modified: make(map[string]string),
+		done:     make(chan struct{}),
+	}
+	if _, err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	if options.PollInterval <= 0 {
+		notify, err := fsnotify.NewWatcher()
+		if err == nil {
+			err = w.addDirs(notify)
+		}
+		if err == nil {
+			w.notify = notify
+		} else {
+			if notify != nil {
+				notify.Close()
+			}
+			w.options.PollInterval = time.Second
+		}
+	}
+
+	w.wg.Add(1)
+	if w.notify != nil {
+		go w.watch()
+	} else {
+		go w.poll()
+	}
+	return w, nil
+}
+
+// Subscribe registers fn to be called with every event, in the order the
+// events happen. fn must not call Reload.
+func (w *Watcher) Subscribe(fn func(WatchEvent)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload reloads the directory immediately, it returns the reason the reload
+// failed if it did
+func (w *Watcher) Reload() error {
+	w.sending.Lock()
+	defer w.sending.Unlock()
+	events, err := w.reload()
+	w.send(events)
+	return err
+}
+
+// Close stops watching the directory, the loaded schemas are kept. Calling
+// Close again returns the result of the first call.
+func (w *Watcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		if w.notify != nil {
+			w.closeErr = w.notify.Close()
+		}
+		w.wg.Wait()
+	})
+	return w.closeErr
+}
+
+// fail sends a ReloadFailed event for an error that happened outside of a
+// reload
+func (w *Watcher) fail(err error) {
+	w.sending.Lock()
+	defer w.sending.Unlock()
+	w.send([]WatchEvent{{Kind: ReloadFailed, Err: err}})
+}
+
+// watch reloads the directory when file system notifications are received
+func (w *Watcher) watch() {
+	defer w.wg.Done()
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.notify.Events:
+			if !ok {
+				return
+			}
+			// new directories are watched too, unless a pattern selects files
+			if event.Op&fsnotify.Create != 0 && w.options.Pattern == "" {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					w.addDirs(w.notify)
+				}
+			}
+			debounce = time.After(w.options.Debounce)
+		case err, ok := <-w.notify.Errors:
+			if !ok {
+				return
+			}
+			w.fail(err)
+		case <-debounce:
+			debounce = nil
+			w.Reload()
+		}
+	}
+}
+
+// poll reloads the directory when the modification time or size of a schema
+// file changes
+func (w *Watcher) poll() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.options.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			modified, err := w.modifications()
+			if err != nil {
+				w.send([]WatchEvent{{Kind: ReloadFailed, Err: err}})
+				continue
+			}
+			w.mu.Lock()
+			changed := !equalStrings(modified, w.modified)
+			w.mu.Unlock()
+			if changed {
+				w.Reload()
+			}
+		}
+	}
+}
+
+// addDirs watches dir and every directory within it
+func (w *Watcher) addDirs(notify *fsnotify.Watcher) error {
+	if w.options.Pattern != "" {
+		return notify.Add(w.dir)
+	}
+	return filepath.WalkDir(w.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return notify.Add(path)
+	})
+}
+
+// modifications returns the modification time and size of every schema file
+func (w *Watcher) modifications() (map[string]string, error) {
+	fsys := os.DirFS(w.dir)
+	names, err := schemaFileNames(fsys, w.options.Pattern)
+	if err != nil && w.options.Pattern == "" {
+		return nil, err
+	}
+	modified := make(map[string]string, len(names))
+	for _, name := range names {
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			continue
+		}
+		modified[name] = fmt.Sprintf("%v %d", info.ModTime().UnixNano(), info.Size())
+	}
+	return modified, nil
+}
+
+// reload loads every schema within the directory into a copy of the
+// SchemaManager, and only if that succeeds replaces the schemas previously
+// loaded by the watcher. The events for the change are returned.
+func (w *Watcher) reload() ([]WatchEvent, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fail := func(err error) ([]WatchEvent, error) {
+		return []WatchEvent{{Kind: ReloadFailed, Err: err}}, err
+	}
+
+	// the modifications are recorded first, so a change made while reloading
+	// is picked up by the next poll
+	modified, err := w.modifications()
+	if err != nil {
+		return fail(err)
+	}
+	w.modified = modified
+
+	fsys := os.DirFS(w.dir)
+	files := make(map[string][]byte, len(modified))
+	for _, name := range sortedKeys(modified) {
+		def, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fail(LoadErrors{{File: filepath.Join(w.dir, name), Err: err}})
+		}
+		files[name] = def
+	}
+
+	loaded, events, errs := w.sm.syncSchemas(w.files, files, func(name string) string {
+		return filepath.Join(w.dir, filepath.FromSlash(name))
+	})
+	if len(errs) > 0 {
+		return fail(errs)
+	}
+	w.files = loaded
+	return events, nil
+}
+
+// syncSchemas replaces the schemas previously loaded from a source with the
+// definitions it now holds, keyed by name. previous maps the keys of the
+// schemas loaded before to their file and definition. Every definition is
+// loaded into a copy of the SchemaManager first, without the schemas loaded
+// from the source before, so a schema that depends on a removed schema fails
+// to load, and only if every one loads are the schemas replaced. file returns
+// the name reported for a definition. The loaded schemas and the events for
+// the change are returned.
+func (sm *SchemaManager) syncSchemas(previous map[string]watchedFile, files map[string][]byte, file func(name string) string) (map[string]watchedFile, []WatchEvent, LoadErrors) {
+	keys := make(map[string]string, len(files))
+	for name, def := range files {
+		if header, err := decodeSchema(def); err == nil {
+			keys[name] = header.key()
+		}
+	}
+
+	staging := NewSchemaManager()
+	sm.mu.RLock()
+	for _, versions := range sm.versions {
+		for _, s := range versions {
+			if _, ok := previous[s.key()]; !ok {
+				staging.storeSchema(s)
+			}
+		}
+	}
+	sm.mu.RUnlock()
+
+	if errs := staging.loadSchemas(files, true); len(errs) > 0 {
+		for _, e := range errs {
+			e.File = file(e.File)
+		}
+		return nil, nil, errs
+	}
+
+	events := []WatchEvent{}
+	loaded := make(map[string]watchedFile, len(files))
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for _, name := range sortedKeys(keys) {
+		key := keys[name]
+		loaded[key] = watchedFile{name: file(name), def: files[name]}
+
+		s := staging.keyed(key)
+		before, ok := previous[key]
+		exists := sm.exactVersion(s.Type, s.Version) != nil
+		if ok && exists && before.name == loaded[key].name && string(before.def) == string(files[name]) {
+			continue
+		}
+
+		s.manager = sm
+		sm.storeSchema(s)
+		kind := SchemaLoaded
+		if exists {
+			kind = SchemaReplaced
+		}
+		events = append(events, WatchEvent{Kind: kind, Schema: s.Type, Version: s.Version, File: loaded[key].name})
+	}
+	for _, key := range sortedKeys(previous) {
+		if _, ok := loaded[key]; ok {
+			continue
+		}
+		schemaType, version, _ := strings.Cut(key, "@")
+		if s := sm.exactVersion(schemaType, version); s != nil {
+			sm.removeSchema(schemaType, version)
+			events = append(events, WatchEvent{Kind: SchemaDeleted, Schema: s.Type, Version: s.Version, File: previous[key].name})
+		}
+	}
+	return loaded, events, nil
+}
+
+// send calls every subscriber with the events
+func (w *Watcher) send(events []WatchEvent) {
+	w.mu.Lock()
+	subscribers := append([]func(WatchEvent){}, w.subscribers...)
+	w.mu.Unlock()
+	for _, event := range events {
+		for _, fn := range subscribers {
+			fn(event)
+		}
+	}
+}
+
+func equalStrings(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if other, ok := b[k]; !ok || other != v {
+			return false
+		}
+	}
+	return true
+}
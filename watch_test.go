@@ -0,0 +1,139 @@
+package jsontype_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apageadev/jsontype"
+)
+
+// watchEvents subscribes to a watcher and returns a function that waits for
+// the next event
+func watchEvents(t *testing.T, w *jsontype.Watcher) func() jsontype.WatchEvent {
+	events := make(chan jsontype.WatchEvent, 16)
+	w.Subscribe(func(event jsontype.WatchEvent) {
+		events <- event
+	})
+	return func() jsontype.WatchEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a watch event")
+		}
+		return jsontype.WatchEvent{}
+	}
+}
+
+func writeSchema(t *testing.T, dir, name, def string) {
+	err := os.WriteFile(filepath.Join(dir, name), []byte(def), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testWatch(t *testing.T, options jsontype.WatchOptions) {
+	dir := t.TempDir()
+	writeSchema(t, dir, "animal.json", `{"type": "Animal", "properties": {"name": {"type": "string"}}}`)
+
+	sm := jsontype.NewSchemaManager()
+	w, err := sm.Watch(dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	next := watchEvents(t, w)
+
+	// test loading a new schema
+	writeSchema(t, dir, "dog.json", `{"type": "Dog", "extends": "Animal", "properties": {}}`)
+	event := next()
+	if event.Kind != jsontype.SchemaLoaded || event.Schema != "Dog" || event.File != filepath.Join(dir, "dog.json") {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	// test an invalid schema does not replace the loaded one
+	writeSchema(t, dir, "animal.json", `{"type": "Animal", "properties": {`)
+	event = next()
+	if event.Kind != jsontype.ReloadFailed || event.Err == nil {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	dog, err := sm.GetSchema("dog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dog.Validate([]byte(`{"name": "Luna"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// test replacing a schema
+	writeSchema(t, dir, "animal.json", `{"type": "Animal", "properties": {"name": {"type": "string", "rules": {"max_length": 3}}}}`)
+	event = next()
+	if event.Kind != jsontype.SchemaReplaced || event.Schema != "Animal" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	if err := dog.Validate([]byte(`{"name": "Luna"}`)); err == nil {
+		t.Fatal("expected the replaced parent to be used")
+	}
+
+	// test deleting a schema that another schema depends on fails as a whole
+	err = os.Remove(filepath.Join(dir, "animal.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event = next()
+	if event.Kind != jsontype.ReloadFailed || sm.SchemaCount() != 2 {
+		t.Fatalf("unexpected event %+v with %d schemas", event, sm.SchemaCount())
+	}
+
+	err = os.Remove(filepath.Join(dir, "dog.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for sm.SchemaCount() > 0 {
+		event = next()
+		if event.Kind != jsontype.SchemaDeleted {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	}
+}
+
+func TestSchemaManagerWatch(t *testing.T) {
+	testWatch(t, jsontype.WatchOptions{Debounce: 20 * time.Millisecond})
+}
+
+func TestSchemaManagerWatchPolling(t *testing.T) {
+	testWatch(t, jsontype.WatchOptions{PollInterval: 10 * time.Millisecond})
+}
+
+func TestSchemaManagerWatchInvalid(t *testing.T) {
+	dir := t.TempDir()
+	writeSchema(t, dir, "dog.json", `{"type": "Dog", "extends": "Animal", "properties": {}}`)
+
+	sm := jsontype.NewSchemaManager()
+	_, err := sm.Watch(dir, jsontype.WatchOptions{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if sm.SchemaCount() != 0 {
+		t.Fatalf("expected no schemas but got %d", sm.SchemaCount())
+	}
+}
+
+func TestWatcherCloseTwice(t *testing.T) {
+	dir := t.TempDir()
+	writeSchema(t, dir, "animal.json", `{"type": "Animal", "properties": {"name": {"type": "string"}}}`)
+
+	sm := jsontype.NewSchemaManager()
+	w, err := sm.Watch(dir, jsontype.WatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// test closing a watcher again returns the first result
+	first := w.Close()
+	if err := w.Close(); err != first {
+		t.Fatalf("expected %v but got %v", first, err)
+	}
+}